@@ -61,6 +61,10 @@ type Config struct {
 	ProxyForceHTTPS bool
 	// token for analytics tracking
 	AnalyticsToken string
+	// LocalToken is a secret that marks requests as coming from this peer,
+	// letting them see private datasets. Requests send it in the
+	// Qri-Local-Token header. if empty, no request is trusted as local
+	LocalToken string
 	// set to true to run entire server with in-memory structures
 	MemOnly bool
 	// disable networking
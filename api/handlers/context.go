@@ -18,6 +18,15 @@ type QriCtxKey string
 // to a context.Context
 const DatasetRefCtxKey QriCtxKey = "datasetRef"
 
+// LocalRequestCtxKey marks a request as coming from this peer. It's set
+// by the server for requests carrying the configured local token in the
+// LocalTokenHeader
+const LocalRequestCtxKey QriCtxKey = "localRequest"
+
+// LocalTokenHeader is the request header local clients send the local
+// token in
+const LocalTokenHeader = "Qri-Local-Token"
+
 // DatasetRefFromReq examines the path element of a request URL
 // to
 func DatasetRefFromReq(r *http.Request) (repo.DatasetRef, error) {
@@ -1,22 +1,28 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	util "github.com/datatogether/api/apiutil"
-	// "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsutil"
+	"github.com/qri-io/qri/catalog"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
+	"github.com/qri-io/qri/query"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/stats"
 )
 
 // DatasetHandlers wraps a requests struct to interface with http.HandlerFunc
@@ -141,6 +147,90 @@ func (h *DatasetHandlers) ZipDatasetHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// DataHandler is the endpoint for reading dataset data
+func (h *DatasetHandlers) DataHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.dataHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// StatsHandler is the endpoint for dataset column statistics
+func (h *DatasetHandlers) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.statsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// VerifyHandler is the endpoint for checking dataset integrity
+func (h *DatasetHandlers) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.verifyHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// CatalogHandler is the endpoint for data catalog entries
+func (h *DatasetHandlers) CatalogHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.catalogHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// LineageHandler is the endpoint for dataset lineage graphs
+func (h *DatasetHandlers) LineageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.lineageHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// BlameHandler is the endpoint for row-level dataset blame
+func (h *DatasetHandlers) BlameHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.blameHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// StreamDataHandler is the endpoint for streaming dataset data
+func (h *DatasetHandlers) StreamDataHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.streamDataHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Request) {
 	args, err := DatasetRefFromPath(r.URL.Path[len("/export/"):])
 	if err != nil {
@@ -154,15 +244,290 @@ func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Reque
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	if hidden(h.repo, r, *res) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	if r.FormValue("format") != "" {
+		h.exportDataHandler(w, r, res.Dataset)
+		return
+	}
 
+	buf := &bytes.Buffer{}
+	if err := core.WriteZipPackage(h.repo, res.Dataset, buf); err != nil {
+		h.log.Infof("error writing zip archive: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=\"%s.zip\"", "dataset"))
-	dsutil.WriteZipArchive(h.repo.Store(), res.Dataset, w)
+	w.Write(buf.Bytes())
+}
+
+// exportDataHandler writes dataset data converted to the format param.
+// csv output accepts "delimiter" and "header" params
+func (h *DatasetHandlers) exportDataHandler(w http.ResponseWriter, r *http.Request, ds *dataset.Dataset) {
+	format, err := core.ParseExportFormat(r.FormValue("format"))
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	delim, err := core.ParseCSVDelimiter(r.FormValue("delimiter"))
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	src, err := repo.LoadData(h.repo, ds)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	contentTypes := map[core.ExportFormat]string{
+		core.ExportFormatCSV:    "text/csv",
+		core.ExportFormatJSON:   "application/json",
+		core.ExportFormatNDJSON: "application/x-ndjson",
+		core.ExportFormatCBOR:   "application/cbor",
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=\"data.%s\"", format))
+
+	err = core.ConvertData(ds.Structure, src, w, &core.ConvertParams{
+		Format:       format,
+		CSVDelimiter: delim,
+		CSVHeader:    r.FormValue("header") != "false",
+	})
+	if err != nil {
+		h.log.Infof("error converting data: %s", err.Error())
+	}
+}
+
+// dataHandler responds with dataset data. rows can be shaped with params:
+//     columns: comma-separated column names to select
+//     filter: [column][op][value] predicate, may be repeated
+//     sort: comma-separated column names, prefix with "-" to sort descending
+//     distinct: "true" to remove duplicate rows
+//     format: json (default) or csv
+func (h *DatasetHandlers) dataHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/data/"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if ref.IsEmpty() {
+		util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+		return
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.Get(&ref, res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if hidden(h.repo, r, *res) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	p, err := structuredDataParamsFromRequest(r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	p.Path = datastore.NewKey(res.Path)
+
+	data := &core.StructuredData{}
+	if err := h.StructuredData(p, data); err != nil {
+		if _, ok := err.(core.UnknownColumnError); ok {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		h.log.Infof("error reading dataset data: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if p.Format == dataset.CSVDataFormat {
+		if raw, ok := data.Data.(json.RawMessage); ok {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write(raw)
+			return
+		}
+	}
+	util.WriteResponse(w, data)
+}
+
+// streamDataHandler writes rows as newline-delimited json or csv using chunked
+// transfer encoding. Rows are selected with either:
+//     a cursor param: a token from a previous response's Qri-Next-Cursor header
+//     a Range header in the form "rows=[start]-[end]"
+//     start & limit params
+// the response Qri-Next-Cursor header gives a token to continue reading
+func (h *DatasetHandlers) streamDataHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.StreamDataParams{
+		Format: r.FormValue("format"),
+		Cursor: r.FormValue("cursor"),
+		Limit:  -1,
+	}
+	if i, err := util.ReqParamInt("start", r); err == nil {
+		p.Start = i
+	}
+	if i, err := util.ReqParamInt("limit", r); err == nil {
+		p.Limit = i
+	}
+
+	var rowRange *core.RowRange
+	if hdr := r.Header.Get("Range"); hdr != "" {
+		rr, err := core.ParseRowRange(hdr)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		rowRange = &rr
+		p.Start, p.Limit = rr.Start, rr.Limit()
+	}
+
+	if p.Cursor != "" {
+		c, err := core.ParseDataCursor(p.Cursor)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		if cursorHidden(h.repo, r, c) {
+			util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+			return
+		}
+	} else {
+		ref, err := DatasetRefFromPath(r.URL.Path[len("/stream/"):])
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		if ref.IsEmpty() {
+			util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+			return
+		}
+		res := &repo.DatasetRef{}
+		if err := h.Get(&ref, res); err != nil {
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if hidden(h.repo, r, *res) {
+			util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+			return
+		}
+		p.Path = datastore.NewKey(res.Path)
+		if res.Name == "" {
+			// name cursors for earlier versions so remote requests can resume them
+			if got, err := repo.VersionRef(h.repo, res.Path); err == nil {
+				res.Peername, res.Name = got.Peername, got.Name
+			}
+		}
+		if res.Name != "" {
+			p.Ref = repo.DatasetRef{Peername: res.Peername, Name: res.Name}.String()
+		}
+	}
+
+	stream, err := h.StreamData(p)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if stream.Entries > 0 && stream.Start >= stream.Entries {
+		w.Header().Set("Content-Range", fmt.Sprintf("rows */%d", stream.Entries))
+		util.WriteErrResponse(w, http.StatusRequestedRangeNotSatisfiable, fmt.Errorf("start row %d is past the end of data", stream.Start))
+		return
+	}
+
+	w.Header().Set("Content-Type", stream.ContentType())
+	w.Header().Set("Accept-Ranges", "rows")
+	if next := stream.NextCursor(); next != "" {
+		w.Header().Set("Qri-Next-Cursor", next)
+	}
+	if rowRange != nil {
+		total := "*"
+		if stream.Entries > 0 {
+			total = strconv.Itoa(stream.Entries)
+		}
+		end := rowRange.End
+		if end < 0 || (stream.Entries > 0 && end >= stream.Entries) {
+			end = stream.Entries - 1
+		}
+		if end >= rowRange.Start {
+			w.Header().Set("Content-Range", fmt.Sprintf("rows %d-%d/%s", rowRange.Start, end, total))
+		}
+		w.WriteHeader(http.StatusPartialContent)
+	}
+
+	if _, err := stream.Write(flushWriter{w}); err != nil {
+		// headers are already sent, all we can do is log
+		h.log.Infof("error streaming data: %s", err.Error())
+	}
+}
+
+// flushWriter flushes http responses when data is flushed, which
+// sends each chunk as it's written
+type flushWriter struct {
+	http.ResponseWriter
+}
+
+// Flush implements the http.Flusher interface
+func (fw flushWriter) Flush() {
+	if f, ok := fw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func structuredDataParamsFromRequest(r *http.Request) (*core.StructuredDataParams, error) {
+	lp := core.ListParamsFromRequest(r)
+	p := &core.StructuredDataParams{
+		Format:       dataset.JSONDataFormat,
+		FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		Limit:        lp.Limit,
+		Offset:       lp.Offset,
+		All:          r.FormValue("all") == "true",
+		Distinct:     r.FormValue("distinct") == "true",
+	}
+
+	if f := r.FormValue("format"); f != "" {
+		format, err := dataset.ParseDataFormatString(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data format: %s", f)
+		}
+		switch format {
+		case dataset.JSONDataFormat:
+		case dataset.CSVDataFormat:
+			p.Format = format
+			p.FormatConfig = nil
+		default:
+			return nil, fmt.Errorf("invalid data format. currently only json or csv are supported")
+		}
+	}
+
+	if cols := r.FormValue("columns"); cols != "" {
+		p.Columns = strings.Split(cols, ",")
+	}
+	for _, str := range r.Form["filter"] {
+		pred, err := query.ParsePredicate(str)
+		if err != nil {
+			return nil, err
+		}
+		p.Filters = append(p.Filters, pred)
+	}
+	if sort := r.FormValue("sort"); sort != "" {
+		for _, key := range strings.Split(sort, ",") {
+			p.Sort = append(p.Sort, query.ParseSortKey(key))
+		}
+	}
+	return p, nil
 }
 
 func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 	args := core.ListParamsFromRequest(r)
 	args.OrderBy = "created"
+	args.PublicOnly = !isLocalRequest(r)
 	res := []repo.DatasetRef{}
 	if err := h.List(&args, &res); err != nil {
 		h.log.Infof("error listing datasets: %s", err.Error())
@@ -174,6 +539,211 @@ func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *DatasetHandlers) statsHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/stats/"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if ref.IsEmpty() {
+		util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+		return
+	}
+	if hiddenRef(h.repo, r, ref) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	res := &stats.Stats{}
+	if err := h.Stats(&ref, res); err != nil {
+		h.log.Infof("error getting stats: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// verifyHandler responds with verification results for a dataset. set the
+// history param to "true" to verify all previous versions
+func (h *DatasetHandlers) verifyHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/verify/"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if ref.IsEmpty() {
+		util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+		return
+	}
+	if hiddenRef(h.repo, r, ref) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	p := &core.VerifyParams{
+		Ref:     ref,
+		History: r.FormValue("history") == "true",
+	}
+	res := []core.VerifyResult{}
+	if err := h.Verify(p, &res); err != nil {
+		h.log.Infof("error verifying dataset: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// catalogHandler responds with a catalog entry for a dataset, or a catalog
+// of all datasets if no dataset is given. The format param picks a catalog
+// format, otherwise one is negotiated from the Accept header, defaulting to
+// dcat
+func (h *DatasetHandlers) catalogHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.CatalogParams{
+		Format: catalog.Negotiate(r.Header.Get("Accept"), catalog.DCAT),
+	}
+	if f := r.FormValue("format"); f != "" {
+		format, err := catalog.ParseFormat(f)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		p.Format = format
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	p.BaseURL = scheme + "://" + r.Host
+
+	if path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/catalog"), "/"); path != "" {
+		ref, err := DatasetRefFromPath(path)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		if hiddenRef(h.repo, r, ref) {
+			util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+			return
+		}
+		p.Ref = ref
+	}
+
+	res := []byte{}
+	if err := h.Catalog(p, &res); err != nil {
+		h.log.Infof("error getting catalog: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", p.Format.ContentType())
+	w.Write(res)
+}
+
+// lineageContentTypes maps lineage formats to response content types
+var lineageContentTypes = map[string]string{
+	repo.LineageFormatDOT:     "text/vnd.graphviz",
+	repo.LineageFormatMermaid: "text/plain",
+}
+
+// lineageHandler responds with the lineage graph of a dataset. the depth
+// param limits transforms followed, the format param can be dot or mermaid
+// to get an encoded graph instead of json
+func (h *DatasetHandlers) lineageHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/lineage/"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if ref.IsEmpty() {
+		util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+		return
+	}
+	if hiddenRef(h.repo, r, ref) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	p := &core.LineageParams{Ref: ref}
+	if d := r.FormValue("depth"); d != "" {
+		if p.Depth, err = strconv.Atoi(d); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid depth: %s", d))
+			return
+		}
+	}
+
+	format := r.FormValue("format")
+	if format != "" && format != repo.LineageFormatJSON && lineageContentTypes[format] == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported lineage format: '%s'", format))
+		return
+	}
+
+	res := &repo.LineageGraph{}
+	if err := h.Lineage(p, res); err != nil {
+		h.log.Infof("error getting lineage: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !isLocalRequest(r) {
+		if err := res.Filter(h.repo, ""); err != nil {
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if ct := lineageContentTypes[format]; ct != "" {
+		data, err := res.Encode(format)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", ct)
+		w.Write(data)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// blameHandler responds with the version that last changed each row of a
+// dataset. the key param names a column to match rows by, offset & limit
+// select a range of rows
+func (h *DatasetHandlers) blameHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/blame/"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if ref.IsEmpty() {
+		util.WriteErrResponse(w, http.StatusBadRequest, errors.New("no dataset name or hash given"))
+		return
+	}
+	if hiddenRef(h.repo, r, ref) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
+
+	p := &core.BlameParams{Ref: ref, Key: r.FormValue("key")}
+	if o := r.FormValue("offset"); o != "" {
+		if p.Offset, err = strconv.Atoi(o); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %s", o))
+			return
+		}
+	}
+	if l := r.FormValue("limit"); l != "" {
+		if p.Limit, err = strconv.Atoi(l); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", l))
+			return
+		}
+	}
+
+	res := &core.BlameResult{}
+	if err := h.Blame(p, res); err != nil {
+		h.log.Infof("error getting blame: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
 func (h *DatasetHandlers) getHandler(w http.ResponseWriter, r *http.Request) {
 	res := &repo.DatasetRef{}
 	args, err := DatasetRefFromPath(r.URL.Path)
@@ -190,6 +760,10 @@ func (h *DatasetHandlers) getHandler(w http.ResponseWriter, r *http.Request) {
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	if hidden(h.repo, r, *res) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
+	}
 	util.WriteResponse(w, res)
 }
 
@@ -319,14 +893,52 @@ func (h *DatasetHandlers) addHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		h.addPackageHandler(w, r, ref)
+		return
+	}
+
 	if ref.Peername == "" || ref.Name == "" {
 		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("need peername and dataset name: '/add/[peername]/[datasetname]'"))
 		return
 	}
 
 	res := repo.DatasetRef{}
-	err = h.Add(&ref, &res)
+	err = h.Add(&core.AddParams{Ref: ref}, &res)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+// addPackageHandler imports a zip archive package uploaded as the "package"
+// form file. the dataset name is taken from the request path if present,
+// otherwise from the uploaded filename
+func (h *DatasetHandlers) addPackageHandler(w http.ResponseWriter, r *http.Request, ref repo.DatasetRef) {
+	pkg, header, err := r.FormFile("package")
 	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error reading package file: %s", err.Error()))
+		return
+	}
+	defer pkg.Close()
+
+	data, err := ioutil.ReadAll(pkg)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error reading package file: %s", err.Error()))
+		return
+	}
+
+	p := &core.AddPackageParams{
+		Peername:    ref.Peername,
+		Name:        ref.Name,
+		Zip:         data,
+		ZipFilename: header.Filename,
+	}
+	res := repo.DatasetRef{}
+	if err := h.AddPackage(p, &res); err != nil {
+		h.log.Infof("error adding package: %s", err.Error())
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -446,8 +1058,14 @@ func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	params := &core.RemoveParams{
+		Ref:         p,
+		Version:     r.FormValue("version"),
+		AllVersions: r.FormValue("all_versions") == "true",
+		Purge:       r.FormValue("purge") == "true",
+	}
 	res := false
-	if err := h.Remove(&p, &res); err != nil {
+	if err := h.Remove(params, &res); err != nil {
 		h.log.Infof("error deleting dataset: %s", err.Error())
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
@@ -54,6 +54,12 @@ func (h *HistoryHandlers) logHandler(w http.ResponseWriter, r *http.Request) {
 	params := &core.LogParams{
 		ListParams: lp,
 		Ref:        args,
+		Author:     r.FormValue("author"),
+	}
+
+	if hiddenRef(h.repo, r, args) {
+		util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+		return
 	}
 
 	res := []repo.DatasetRef{}
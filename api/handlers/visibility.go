@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+)
+
+// isLocalRequest is true for requests the server trusts as coming from this
+// peer, which can see private datasets. Trust is only given to requests that
+// present the configured local token, see LocalRequestCtxKey
+func isLocalRequest(r *http.Request) bool {
+	local, _ := r.Context().Value(LocalRequestCtxKey).(bool)
+	return local
+}
+
+// hidden checks if a dataset should be hidden from the sender of a request.
+// Remote requests are anonymous, & can only see public datasets. Paths are
+// checked against the dataset they're a version of, remote requests can't see
+// paths that aren't a version of a named dataset in this repo
+func hidden(rp repo.Repo, r *http.Request, ref repo.DatasetRef) bool {
+	if isLocalRequest(r) {
+		return false
+	}
+	if ref.Path != "" {
+		got, err := repo.VersionRef(rp, ref.Path)
+		if err != nil {
+			return true
+		}
+		ref = got
+	}
+	ok, err := repo.CanView(rp, ref, "")
+	return err != nil || !ok
+}
+
+// hiddenRef resolves a reference before checking if it's hidden from the
+// sender of a request
+func hiddenRef(rp repo.Repo, r *http.Request, ref repo.DatasetRef) bool {
+	if err := repo.CanonicalizeDatasetRef(rp, &ref); err != nil {
+		return !isLocalRequest(r)
+	}
+	return hidden(rp, r, ref)
+}
+
+// cursorHidden checks if the dataset a data cursor reads from is hidden from
+// the sender of a request. Remote requests must use cursors that name a
+// dataset, StreamData checks the cursor path is a version of the named dataset
+func cursorHidden(rp repo.Repo, r *http.Request, c core.DataCursor) bool {
+	if isLocalRequest(r) {
+		return false
+	}
+	if c.Ref == "" {
+		return true
+	}
+	ref, err := repo.ParseDatasetRef(c.Ref)
+	if err != nil {
+		return true
+	}
+	got, err := rp.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		return true
+	}
+	return hidden(rp, r, repo.DatasetRef{Peername: got.Peername, Name: got.Name})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestHidden(t *testing.T) {
+	r, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	prev, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+	movies := repo.DatasetRef{}
+	if err := core.NewDatasetRequests(r, nil).Save(&core.SaveParams{
+		Prev:         prev,
+		Changes:      &dataset.Dataset{Commit: &dataset.Commit{Title: "add a movie"}},
+		DataFilename: "movies.csv",
+		Data:         strings.NewReader("movie_title,duration\nAvatar ,178\n"),
+	}, &movies); err != nil {
+		t.Errorf("error saving movies: %s", err.Error())
+		return
+	}
+	if err := r.PutRefVisibility(movies, repo.Visibility{Private: true}); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		local  bool
+		ref    repo.DatasetRef
+		hidden bool
+	}{
+		{true, movies, false},
+		{false, movies, true},
+		{false, repo.DatasetRef{Path: movies.Path}, true},
+		{false, repo.DatasetRef{Path: prev.Path}, true},
+		{false, repo.DatasetRef{Peername: "peer", Name: "cities", Path: prev.Path}, true},
+		{false, repo.DatasetRef{Path: "/map/QmNotAVersionOfAnyDataset"}, true},
+		{true, repo.DatasetRef{Path: prev.Path}, false},
+		{false, repo.DatasetRef{Peername: "peer", Name: "cities"}, false},
+	}
+
+	for i, c := range cases {
+		// loopback requests aren't trusted without the local token
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "127.0.0.1:2503"
+		if c.local {
+			req = req.WithContext(context.WithValue(req.Context(), LocalRequestCtxKey, true))
+		}
+		if got := hidden(r, req, c.ref); got != c.hidden {
+			t.Errorf("case %d hidden mismatch. expected: %t, got: %t", i, c.hidden, got)
+		}
+	}
+
+	cursors := []struct {
+		local  bool
+		c      core.DataCursor
+		hidden bool
+	}{
+		{true, core.DataCursor{Path: prev.Path}, false},
+		{false, core.DataCursor{Path: prev.Path}, true},
+		{false, core.DataCursor{Path: prev.Path, Ref: "peer/movies"}, true},
+		{false, core.DataCursor{Path: "/map/QmNope", Ref: "peer/cities"}, false},
+	}
+
+	for i, c := range cursors {
+		req := localRequest(c.local)
+		if got := cursorHidden(r, req, c.c); got != c.hidden {
+			t.Errorf("cursor case %d hidden mismatch. expected: %t, got: %t", i, c.hidden, got)
+		}
+	}
+}
+
+func localRequest(local bool) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	if local {
+		req = req.WithContext(context.WithValue(req.Context(), LocalRequestCtxKey, true))
+	}
+	return req
+}
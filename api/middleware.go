@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
 	"time"
 
@@ -32,10 +33,23 @@ func (s *Server) middleware(handler http.HandlerFunc) http.HandlerFunc {
 		// }
 		s.addCORSHeaders(w, r)
 
+		if s.isLocalRequest(r) {
+			r = r.WithContext(context.WithValue(r.Context(), handlers.LocalRequestCtxKey, true))
+		}
+
 		handler(w, r)
 	}
 }
 
+// isLocalRequest checks if a request carries the configured local token
+func (s *Server) isLocalRequest(r *http.Request) bool {
+	token := r.Header.Get(handlers.LocalTokenHeader)
+	if s.cfg.LocalToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.LocalToken)) == 1
+}
+
 // addCORSHeaders adds CORS header info for whitelisted servers
 func (s *Server) addCORSHeaders(w http.ResponseWriter, r *http.Request) {
 	origin := r.Header.Get("Origin")
@@ -43,7 +57,8 @@ func (s *Server) addCORSHeaders(w http.ResponseWriter, r *http.Request) {
 		if origin == o {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, POST, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,Range")
+			w.Header().Set("Access-Control-Expose-Headers", "Content-Range,Qri-Next-Cursor")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			return
 		}
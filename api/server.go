@@ -163,6 +163,14 @@ func NewServerRoutes(s *Server) *http.ServeMux {
 	m.Handle("/add/", s.middleware(dsh.AddHandler))
 	m.Handle("/rename", s.middleware(dsh.RenameHandler))
 	m.Handle("/export/", s.middleware(dsh.ZipDatasetHandler))
+	m.Handle("/data/", s.middleware(dsh.DataHandler))
+	m.Handle("/stream/", s.middleware(dsh.StreamDataHandler))
+	m.Handle("/stats/", s.middleware(dsh.StatsHandler))
+	m.Handle("/verify/", s.middleware(dsh.VerifyHandler))
+	m.Handle("/lineage/", s.middleware(dsh.LineageHandler))
+	m.Handle("/blame/", s.middleware(dsh.BlameHandler))
+	m.Handle("/catalog", s.middleware(dsh.CatalogHandler))
+	m.Handle("/catalog/", s.middleware(dsh.CatalogHandler))
 
 	hh := handlers.NewHistoryHandlers(s.log, s.qriNode.Repo)
 	// TODO - stupid hack for now.
@@ -57,9 +57,44 @@ func TestServerRoutes(t *testing.T) {
 		// {"POST", "/me", {badly formed body}, {response body}, 400},
 		{"OPTIONS", "/export/", nil, 200},
 		{"GET", "/export/", nil, 400},
+		{"GET", "/export/peer/movies?format=ndjson", nil, 200},
+		{"GET", "/export/peer/movies?format=csv&delimiter=tab&header=false", nil, 200},
+		{"GET", "/export/peer/movies?format=xml", nil, 400},
+		{"GET", "/export/peer/movies?format=csv&delimiter=ab", nil, 400},
 		// TODO: more tests for /export/ endpoint:
 		// {"GET", "/export/hash_of_dataset", {}, {proper response}, 200},
 		// {"GET", "/export/bad hash", {}, {proper response}, 400},
+		{"OPTIONS", "/data/", nil, 200},
+		{"GET", "/data/", nil, 400},
+		{"GET", "/data/peer/movies", nil, 200},
+		{"GET", "/data/peer/movies?columns=title&filter=duration>150&sort=-duration", nil, 200},
+		{"GET", "/data/peer/movies?filter=nope", nil, 400},
+		{"GET", "/data/peer/movies?columns=nope", nil, 400},
+		{"OPTIONS", "/stream/", nil, 200},
+		{"GET", "/stream/", nil, 400},
+		{"GET", "/stream/peer/movies", nil, 200},
+		{"GET", "/stream/peer/movies?format=csv&start=2&limit=5", nil, 200},
+		{"GET", "/stream/peer/movies?format=xml", nil, 400},
+		{"GET", "/stream/?cursor=bad_cursor", nil, 400},
+		{"OPTIONS", "/stats/", nil, 200},
+		{"GET", "/stats/", nil, 400},
+		{"GET", "/stats/peer/movies", nil, 200},
+		{"OPTIONS", "/verify/", nil, 200},
+		{"GET", "/verify/", nil, 400},
+		{"GET", "/verify/peer/movies?history=true", nil, 200},
+		{"GET", "/verify/peer/movies@{2099-01-01}", nil, 200},
+		{"OPTIONS", "/lineage/", nil, 200},
+		{"GET", "/lineage/", nil, 400},
+		{"GET", "/lineage/peer/movies?depth=1", nil, 200},
+		{"GET", "/lineage/peer/movies?format=dot", nil, 200},
+		{"OPTIONS", "/blame/", nil, 200},
+		{"GET", "/blame/", nil, 400},
+		{"GET", "/blame/peer/movies?limit=2", nil, 200},
+		{"OPTIONS", "/catalog", nil, 200},
+		{"GET", "/catalog", nil, 200},
+		{"GET", "/catalog?format=marc", nil, 400},
+		{"GET", "/catalog/peer/movies?format=schema.org", nil, 200},
+		{"GET", "/catalog/peer/not_a_dataset", nil, 500},
 		{"OPTIONS", "/list", nil, 200},
 		{"GET", "/list", nil, 200},
 		// TODO: more tests for /list endpoint:
@@ -155,3 +190,28 @@ func TestServerRoutes(t *testing.T) {
 		}
 	}
 }
+
+func TestServerIsLocalRequest(t *testing.T) {
+	cases := []struct {
+		cfgToken, reqToken string
+		local              bool
+	}{
+		{"", "", false},
+		{"", "secret", false},
+		{"secret", "", false},
+		{"secret", "nope", false},
+		{"secret", "secret", true},
+	}
+
+	for i, c := range cases {
+		s := &Server{cfg: &Config{LocalToken: c.cfgToken}}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "127.0.0.1:2503"
+		if c.reqToken != "" {
+			req.Header.Set("Qri-Local-Token", c.reqToken)
+		}
+		if got := s.isLocalRequest(req); got != c.local {
+			t.Errorf("case %d local mismatch. expected: %t, got: %t", i, c.local, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package catalog renders dataset descriptions as entries in standard data
+// catalog & citation formats: DCAT, schema.org, DataCite & BibTeX.
+// Entries are plain values, so catalogs can be built from any source of
+// dataset metadata
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is a catalog output format
+type Format string
+
+const (
+	// DCAT is the W3C data catalog vocabulary, encoded as JSON-LD
+	DCAT Format = "dcat"
+	// SchemaOrg is the schema.org Dataset type, encoded as JSON-LD
+	SchemaOrg Format = "schema.org"
+	// DataCite is the DataCite metadata schema, encoded as JSON
+	DataCite Format = "datacite"
+	// BibTeX is a BibTeX @misc citation
+	BibTeX Format = "bibtex"
+)
+
+// Formats lists all supported formats
+var Formats = []Format{DCAT, SchemaOrg, DataCite, BibTeX}
+
+// ParseFormat gets a format from a string
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.ToLower(s) == string(f) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported catalog format: '%s'. must be one of: dcat, schema.org, datacite, bibtex", s)
+}
+
+// ContentType gives the media type of a format
+func (f Format) ContentType() string {
+	switch f {
+	case DCAT, SchemaOrg:
+		return "application/ld+json"
+	case DataCite:
+		return "application/vnd.datacite.datacite+json"
+	case BibTeX:
+		return "application/x-bibtex"
+	}
+	return "application/octet-stream"
+}
+
+// Extension gives the file extension for a format, including the dot
+func (f Format) Extension() string {
+	switch f {
+	case DCAT, SchemaOrg:
+		return ".jsonld"
+	case DataCite:
+		return ".json"
+	case BibTeX:
+		return ".bib"
+	}
+	return ""
+}
+
+// contentTypes maps requested media types to formats for content
+// negotiation. schema.org & datacite media types follow those used for
+// DOI content negotiation
+var contentTypes = map[string]Format{
+	"application/ld+json":                    DCAT,
+	"application/vnd.schemaorg.ld+json":      SchemaOrg,
+	"application/vnd.datacite.datacite+json": DataCite,
+	"application/x-bibtex":                   BibTeX,
+	"text/x-bibliography; style=bibtex":      BibTeX,
+}
+
+// Negotiate picks a format for an http Accept header, returning def if no
+// accepted media type is supported. Quality values are ignored, the first
+// supported type wins
+func Negotiate(accept string, def Format) Format {
+	for _, mt := range strings.Split(accept, ",") {
+		mt = strings.TrimSpace(mt)
+		if f, ok := contentTypes[mt]; ok {
+			return f
+		}
+		if i := strings.Index(mt, ";"); i > 0 {
+			if f, ok := contentTypes[strings.TrimSpace(mt[:i])]; ok {
+				return f
+			}
+		}
+	}
+	return def
+}
+
+// Entry describes a single dataset in a catalog
+type Entry struct {
+	// Name is the peername/name reference for the dataset
+	Name string
+	// Path is the content-addressed path of the dataset
+	Path        string
+	Title       string
+	Description string
+	Keywords    []string
+	License     string
+	LicenseURL  string
+	// URL is a landing page for the dataset, if it has one
+	URL string
+	// DownloadURL leads directly to the dataset's data
+	DownloadURL string
+	Identifier  string
+	Version     string
+	// Authors lists dataset creators by name, first author first
+	Authors []string
+	// Modified is the time of the latest commit
+	Modified time.Time
+	// Format is the data format, eg. csv
+	Format string
+	// Size is the length of data in bytes
+	Size int
+	// Entries is the number of top-level entries in data
+	Entries int
+	// Checksum is a hash of data
+	Checksum string
+}
+
+// MediaType gives the media type for an entry's data format
+func (e *Entry) MediaType() string {
+	switch e.Format {
+	case "csv":
+		return "text/csv"
+	case "json":
+		return "application/json"
+	case "ndjson":
+		return "application/x-ndjson"
+	case "cbor":
+		return "application/cbor"
+	case "cdxj":
+		return "application/cdxj"
+	}
+	return ""
+}
+
+// title falls back to the dataset name for untitled entries
+func (e *Entry) title() string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return e.Name
+}
+
+// id gives the most specific identifier for an entry
+func (e *Entry) id() string {
+	if e.URL != "" {
+		return e.URL
+	}
+	return e.Path
+}
+
+// Encode renders a single entry in a format
+func Encode(f Format, e *Entry) ([]byte, error) {
+	switch f {
+	case DCAT:
+		return jsonld(dcatContext, dcatDataset(e))
+	case SchemaOrg:
+		return jsonld(schemaOrgContext, schemaOrgDataset(e))
+	case DataCite:
+		return indent(dataCiteResource(e))
+	case BibTeX:
+		return []byte(bibtex(e)), nil
+	}
+	return nil, fmt.Errorf("unsupported catalog format: '%s'", f)
+}
+
+// EncodeFeed renders a catalog of entries in a format. title names the
+// catalog
+func EncodeFeed(f Format, title string, entries []*Entry) ([]byte, error) {
+	switch f {
+	case DCAT:
+		datasets := make([]interface{}, len(entries))
+		for i, e := range entries {
+			datasets[i] = dcatDataset(e)
+		}
+		return jsonld(dcatContext, map[string]interface{}{
+			"@type":        "dcat:Catalog",
+			"dct:title":    title,
+			"dcat:dataset": datasets,
+		})
+	case SchemaOrg:
+		datasets := make([]interface{}, len(entries))
+		for i, e := range entries {
+			datasets[i] = schemaOrgDataset(e)
+		}
+		return jsonld(schemaOrgContext, map[string]interface{}{
+			"@type":   "DataCatalog",
+			"name":    title,
+			"dataset": datasets,
+		})
+	case DataCite:
+		resources := make([]interface{}, len(entries))
+		for i, e := range entries {
+			resources[i] = dataCiteResource(e)
+		}
+		return indent(resources)
+	case BibTeX:
+		citations := make([]string, len(entries))
+		for i, e := range entries {
+			citations[i] = bibtex(e)
+		}
+		return []byte(strings.Join(citations, "\n")), nil
+	}
+	return nil, fmt.Errorf("unsupported catalog format: '%s'", f)
+}
@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEntry = &Entry{
+	Name:        "b5/comics",
+	Path:        "/ipfs/QmComics",
+	Title:       "Comic Characters",
+	Description: "characters & their first appearances",
+	Keywords:    []string{"comics", "characters"},
+	License:     "CC-BY-4.0",
+	LicenseURL:  "https://creativecommons.org/licenses/by/4.0/",
+	URL:         "http://localhost:2503/b5/comics",
+	DownloadURL: "http://localhost:2503/data/b5/comics",
+	Authors:     []string{"b5", "ramfox"},
+	Modified:    time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC),
+	Format:      "csv",
+	Size:        2048,
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		in  string
+		f   Format
+		err string
+	}{
+		{"dcat", DCAT, ""},
+		{"Schema.org", SchemaOrg, ""},
+		{"datacite", DataCite, ""},
+		{"bibtex", BibTeX, ""},
+		{"marc", "", "unsupported catalog format: 'marc'. must be one of: dcat, schema.org, datacite, bibtex"},
+	}
+	for i, c := range cases {
+		f, err := ParseFormat(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if f != c.f {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, c.f, f)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		expect Format
+	}{
+		{"", DCAT},
+		{"text/html, */*", DCAT},
+		{"application/x-bibtex", BibTeX},
+		{"text/html, application/vnd.schemaorg.ld+json;q=0.9", SchemaOrg},
+		{"application/vnd.datacite.datacite+json", DataCite},
+		{"text/x-bibliography; style=bibtex", BibTeX},
+	}
+	for i, c := range cases {
+		if got := Negotiate(c.accept, DCAT); got != c.expect {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		f      Format
+		expect []string
+	}{
+		{DCAT, []string{`"@type": "dcat:Dataset"`, `"dct:title": "Comic Characters"`, `"dcat:mediaType": "text/csv"`, `"dct:modified": "2018-02-01T00:00:00Z"`}},
+		{SchemaOrg, []string{`"@context": "http://schema.org/"`, `"@type": "Dataset"`, `"contentSize": "2048 B"`}},
+		{DataCite, []string{`"resourceTypeGeneral": "Dataset"`, `"publicationYear": "2018"`, `"rightsUri": "https://creativecommons.org/licenses/by/4.0/"`}},
+		{BibTeX, []string{`@misc{b5_comics,`, `author = {b5 and ramfox}`, `howpublished = {\url{http://localhost:2503/b5/comics}}`, `characters \& their`}},
+	}
+	for i, c := range cases {
+		data, err := Encode(c.f, testEntry)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if c.f != BibTeX && !json.Valid(data) {
+			t.Errorf("case %d expected valid json, got:\n%s", i, string(data))
+		}
+		for _, s := range c.expect {
+			if !strings.Contains(string(data), s) {
+				t.Errorf("case %d expected output to contain %s, got:\n%s", i, s, string(data))
+			}
+		}
+	}
+
+	if _, err := Encode(Format("marc"), testEntry); err == nil {
+		t.Errorf("expected unsupported format to error")
+	}
+
+	// empty values are omitted
+	data, err := Encode(DCAT, &Entry{Name: "b5/empty", Path: "/ipfs/QmEmpty"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if strings.Contains(string(data), "dct:description") {
+		t.Errorf("expected empty description to be omitted, got:\n%s", string(data))
+	}
+}
+
+func TestEncodeFeed(t *testing.T) {
+	entries := []*Entry{testEntry, {Name: "b5/other", Path: "/ipfs/QmOther"}}
+	for _, f := range Formats {
+		data, err := EncodeFeed(f, "b5's datasets", entries)
+		if err != nil {
+			t.Errorf("%s unexpected error: %s", f, err.Error())
+			continue
+		}
+		if !strings.Contains(string(data), "Comic Characters") || !strings.Contains(string(data), "b5/other") {
+			t.Errorf("%s expected feed to contain all entries, got:\n%s", f, string(data))
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	dcatContext = map[string]string{
+		"dcat": "http://www.w3.org/ns/dcat#",
+		"dct":  "http://purl.org/dc/terms/",
+		"foaf": "http://xmlns.com/foaf/0.1/",
+	}
+	schemaOrgContext = "http://schema.org/"
+)
+
+// props is a set of json properties that skips empty values
+type props map[string]interface{}
+
+func (p props) set(key string, val interface{}) props {
+	switch v := val.(type) {
+	case string:
+		if v == "" {
+			return p
+		}
+	case int:
+		if v == 0 {
+			return p
+		}
+	case []string:
+		if len(v) == 0 {
+			return p
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return p
+		}
+	case time.Time:
+		if v.IsZero() {
+			return p
+		}
+		val = v.UTC().Format(time.RFC3339)
+	case nil:
+		return p
+	}
+	p[key] = val
+	return p
+}
+
+func jsonld(context interface{}, doc map[string]interface{}) ([]byte, error) {
+	doc["@context"] = context
+	return indent(doc)
+}
+
+func indent(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func dcatDataset(e *Entry) map[string]interface{} {
+	dist := props{"@type": "dcat:Distribution"}.
+		set("dcat:downloadURL", e.DownloadURL).
+		set("dcat:mediaType", e.MediaType()).
+		set("dct:format", e.Format).
+		set("dcat:byteSize", e.Size)
+
+	// dcat has a single publisher, use the first author
+	var publisher interface{}
+	if len(e.Authors) > 0 {
+		publisher = map[string]string{"@type": "foaf:Agent", "foaf:name": e.Authors[0]}
+	}
+
+	return props{"@type": "dcat:Dataset"}.
+		set("@id", e.id()).
+		set("dct:title", e.title()).
+		set("dct:description", e.Description).
+		set("dcat:keyword", e.Keywords).
+		set("dct:license", firstOf(e.LicenseURL, e.License)).
+		set("dct:identifier", firstOf(e.Identifier, e.Path)).
+		set("dct:modified", e.Modified).
+		set("dct:publisher", publisher).
+		set("dcat:landingPage", e.URL).
+		set("dcat:distribution", []interface{}{map[string]interface{}(dist)})
+}
+
+func schemaOrgDataset(e *Entry) map[string]interface{} {
+	creators := []interface{}{}
+	for _, a := range e.Authors {
+		creators = append(creators, map[string]string{"@type": "Person", "name": a})
+	}
+
+	dist := props{"@type": "DataDownload"}.
+		set("contentUrl", e.DownloadURL).
+		set("encodingFormat", firstOf(e.MediaType(), e.Format))
+	if e.Size > 0 {
+		dist.set("contentSize", fmt.Sprintf("%d B", e.Size))
+	}
+
+	return props{"@type": "Dataset"}.
+		set("@id", e.id()).
+		set("name", e.title()).
+		set("description", e.Description).
+		set("keywords", e.Keywords).
+		set("license", firstOf(e.LicenseURL, e.License)).
+		set("url", e.URL).
+		set("identifier", firstOf(e.Identifier, e.Path)).
+		set("version", e.Version).
+		set("dateModified", e.Modified).
+		set("creator", creators).
+		set("distribution", []interface{}{map[string]interface{}(dist)})
+}
+
+func dataCiteResource(e *Entry) map[string]interface{} {
+	creators := []interface{}{}
+	for _, a := range e.Authors {
+		creators = append(creators, map[string]string{"name": a})
+	}
+
+	identifiers := []interface{}{map[string]string{"identifier": e.Path, "identifierType": "IPFS"}}
+	if e.Identifier != "" {
+		identifiers = append([]interface{}{map[string]string{"identifier": e.Identifier, "identifierType": "Other"}}, identifiers...)
+	}
+
+	res := props{
+		"types":       map[string]string{"resourceTypeGeneral": "Dataset"},
+		"titles":      []interface{}{map[string]string{"title": e.title()}},
+		"identifiers": identifiers,
+	}.
+		set("creators", creators).
+		set("url", e.URL).
+		set("version", e.Version).
+		set("formats", nonEmpty(e.MediaType()))
+	if e.Description != "" {
+		res.set("descriptions", []interface{}{map[string]string{"description": e.Description, "descriptionType": "Abstract"}})
+	}
+	if !e.Modified.IsZero() {
+		res.set("publicationYear", strconv.Itoa(e.Modified.Year()))
+		res.set("dates", []interface{}{map[string]string{"date": e.Modified.UTC().Format("2006-01-02"), "dateType": "Updated"}})
+	}
+	if len(e.Keywords) > 0 {
+		subjects := make([]interface{}, len(e.Keywords))
+		for i, k := range e.Keywords {
+			subjects[i] = map[string]string{"subject": k}
+		}
+		res.set("subjects", subjects)
+	}
+	if e.License != "" || e.LicenseURL != "" {
+		res.set("rightsList", []interface{}{props{}.set("rights", e.License).set("rightsUri", e.LicenseURL)})
+	}
+	if e.Size > 0 {
+		res.set("sizes", []string{fmt.Sprintf("%d bytes", e.Size)})
+	}
+	return res
+}
+
+var nonKeyChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func bibtex(e *Entry) string {
+	key := nonKeyChars.ReplaceAllString(firstOf(e.Name, e.Path), "_")
+	fields := [][2]string{
+		{"title", e.title()},
+		{"author", strings.Join(e.Authors, " and ")},
+	}
+	if !e.Modified.IsZero() {
+		fields = append(fields, [2]string{"year", strconv.Itoa(e.Modified.Year())})
+	}
+	if e.URL != "" {
+		fields = append(fields, [2]string{"howpublished", `\url{` + e.URL + `}`})
+	}
+	fields = append(fields,
+		[2]string{"abstract", e.Description},
+		[2]string{"version", e.Version},
+		[2]string{"keywords", strings.Join(e.Keywords, ", ")},
+		[2]string{"note", strings.TrimSpace(fmt.Sprintf("qri dataset %s %s", e.Name, e.Path))},
+	)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "@misc{%s", key)
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		fmt.Fprintf(buf, ",\n  %s = {%s}", f[0], bibtexEscape(f[1]))
+	}
+	buf.WriteString("\n}\n")
+	return buf.String()
+}
+
+// bibtexEscape escapes characters that are special in bibtex values.
+// backslash commands like \url are left alone
+func bibtexEscape(s string) string {
+	r := strings.NewReplacer("{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`)
+	if strings.HasPrefix(s, `\url{`) {
+		return s
+	}
+	return r.Replace(s)
+}
+
+func firstOf(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func nonEmpty(vals ...string) []string {
+	res := []string{}
+	for _, v := range vals {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -19,6 +19,16 @@ var (
 	addDsURL               string
 	addDsPassive           bool
 	addDsShowValidation    bool
+	addDsPackage           string
+	addDsDir               string
+	addDsManifest          string
+	addDsWorkers           int
+	addDsRequireSigned     bool
+	addDsCoAuthors         []string
+	addDsPrivate           bool
+	addDsEncrypt           bool
+	addDsChunked           bool
+	addDsCompression       string
 )
 
 var datasetAddCmd = &cobra.Command{
@@ -42,8 +52,32 @@ changes to qri.`,
   $ qri add --data data.csv me/annual_pop
 
   create a dataset with a metadata and data file:
-  $ qri add --meta meta.json --data comics.csv me/comic_characters`,
+  $ qri add --meta meta.json --data comics.csv me/comic_characters
+
+  import a package created with qri export --zip:
+  $ qri add --package comic_characters.zip me/comic_characters
+
+  add every data file in a directory, naming datasets after files:
+  $ qri add --dir ./data
+
+  add files listed in a manifest, with per-file names, meta & structure:
+  $ qri add --manifest manifest.yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if addDsDir != "" || addDsManifest != "" {
+			if len(args) > 0 {
+				ErrExit(fmt.Errorf("adding with --dir or --manifest doesn't accept dataset name arguments"))
+			}
+			bulkAdd()
+			return
+		}
+
+		if addDsPackage != "" {
+			if len(args) > 1 {
+				ErrExit(fmt.Errorf("adding a package accepts at most 1 argument for the new dataset name"))
+			}
+			addPackage(args)
+			return
+		}
 
 		ingest := (addDsFilepath != "" || addDsMetaFilepath != "" || addDsStructureFilepath != "" || addDsURL != "")
 
@@ -69,8 +103,9 @@ changes to qri.`,
 			ExitIfErr(err)
 
 			res := repo.DatasetRef{}
-			err = req.Add(&ref, &res)
+			err = req.Add(&core.AddParams{Ref: ref, RequireSigned: addDsRequireSigned}, &res)
 			ExitIfErr(err)
+			printSignatureWarning(res)
 			printInfo("Successfully added dataset %s", ref)
 		}
 	},
@@ -98,6 +133,15 @@ func initDataset(name repo.DatasetRef) {
 		Name:         name.Name,
 		URL:          addDsURL,
 		DataFilename: filepath.Base(addDsFilepath),
+		CoAuthors:    addDsCoAuthors,
+		Private:      addDsPrivate,
+		Encrypt:      addDsEncrypt,
+		Chunked:      addDsChunked,
+		Compression:  addDsCompression,
+	}
+	// compression combines with encryption & chunking
+	if p.Compression == "" {
+		p.Compression = defaultCompression()
 	}
 
 	// this is because passing nil to interfaces is bad
@@ -135,11 +179,103 @@ func initDataset(name repo.DatasetRef) {
 	printSuccess("added new dataset %s", ref)
 }
 
+func addPackage(args []string) {
+	p := &core.AddPackageParams{Path: addDsPackage}
+	if len(args) == 1 {
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+		p.Peername = ref.Peername
+		p.Name = ref.Name
+	}
+
+	if abs, err := filepath.Abs(p.Path); err == nil {
+		p.Path = abs
+	}
+
+	req, err := datasetRequests(false)
+	ExitIfErr(err)
+
+	ref := repo.DatasetRef{}
+	err = req.AddPackage(p, &ref)
+	ExitIfErr(err)
+
+	ref.Peername = "me"
+	printSuccess("added dataset package %s", ref)
+}
+
+func bulkAdd() {
+	var (
+		m       *core.Manifest
+		journal string
+		err     error
+	)
+
+	if addDsDir != "" && addDsManifest != "" {
+		ErrExit(fmt.Errorf("please provide either --dir or --manifest, not both"))
+	} else if addDsDir != "" {
+		m, err = core.DirManifest(addDsDir)
+		ExitIfErr(err)
+		journal = filepath.Join(addDsDir, ".qri_add_journal")
+	} else {
+		m, err = core.ReadManifest(addDsManifest)
+		ExitIfErr(err)
+		journal = addDsManifest + ".journal"
+	}
+
+	if len(m.Datasets) == 0 {
+		printWarning("no data files to add")
+		return
+	}
+
+	req, err := datasetRequests(false)
+	ExitIfErr(err)
+
+	p := &core.BulkInitParams{
+		Manifest: m,
+		Workers:  addDsWorkers,
+		Journal:  journal,
+	}
+	res := []core.BulkInitResult{}
+	err = req.BulkInit(p, &res)
+	ExitIfErr(err)
+
+	failed := 0
+	for _, r := range res {
+		if r.Error != "" {
+			failed++
+			printWarning("%s: %s", r.Data, r.Error)
+			continue
+		}
+		r.Ref.Peername = "me"
+		if r.Skipped {
+			printInfo("%s: already added as %s", r.Data, r.Ref)
+			continue
+		}
+		printSuccess("%s: added %s", r.Data, r.Ref)
+	}
+
+	if failed > 0 {
+		ErrExit(fmt.Errorf("%d of %d files failed to add. re-run the same command to retry failed files", failed, len(res)))
+	}
+	// everything's been added, nothing left to resume
+	os.Remove(journal)
+}
+
 func init() {
 	datasetAddCmd.Flags().StringVarP(&addDsURL, "url", "", "", "url of file to initialize from")
 	datasetAddCmd.Flags().StringVarP(&addDsFilepath, "data", "", "", "data file to initialize from")
 	datasetAddCmd.Flags().StringVarP(&addDsStructureFilepath, "structure", "", "", "dataset structure JSON file")
 	datasetAddCmd.Flags().StringVarP(&addDsMetaFilepath, "meta", "", "", "dataset metadata JSON file")
+	datasetAddCmd.Flags().StringVarP(&addDsPackage, "package", "", "", "zip archive or directory created by export to import")
+	datasetAddCmd.Flags().StringVarP(&addDsDir, "dir", "", "", "directory of data files to add")
+	datasetAddCmd.Flags().StringVarP(&addDsManifest, "manifest", "", "", "yaml manifest of data files to add")
+	datasetAddCmd.Flags().IntVarP(&addDsWorkers, "workers", "w", core.DefaultBulkWorkers, "number of files to add in parallel with --dir or --manifest")
+	datasetAddCmd.Flags().BoolVarP(&addDsRequireSigned, "require-signed", "", false, "refuse to add datasets without a valid commit signature")
+	datasetAddCmd.Flags().StringSliceVarP(&addDsCoAuthors, "co-author", "", nil, "peername of a co-author of the dataset, repeat for more than one")
+	datasetAddCmd.Flags().BoolVarP(&addDsPrivate, "private", "", false, "hide the dataset from other peers")
+	datasetAddCmd.Flags().BoolVarP(&addDsEncrypt, "encrypt", "", false, "encrypt dataset data & components with a key only this peer holds")
+	datasetAddCmd.Flags().BoolVarP(&addDsChunked, "chunked", "", false, "store data in chunks that later versions can share")
+	datasetAddCmd.Flags().StringVarP(&addDsCompression, "compression", "", "", "compress stored data, one of gzip, zstd or none. defaults to the configured compression")
 	datasetAddCmd.Flags().BoolVarP(&addDsShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	RootCmd.AddCommand(datasetAddCmd)
 }
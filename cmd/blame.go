@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	blameCmdKey    string
+	blameCmdOffset int
+	blameCmdLimit  int
+	blameCmdFormat string
+)
+
+// blameCmd represents the blame command
+var blameCmd = &cobra.Command{
+	Use:   "blame",
+	Short: "show the version that last changed each row of a dataset",
+	Long: `
+Blame walks the history of a dataset, annotating each row of the latest 
+version with the path, commit title, author and timestamp of the version that 
+last changed it. Rows are matched across versions by position unless --key 
+names a column that identifies them. Use --offset and --limit to blame a 
+range of rows.`,
+	Example: `  show who last changed each row of b5/comics, matching rows by id:
+  $ qri blame --key id b5/comics
+
+  blame the first 10 rows as json:
+  $ qri blame --limit 10 --format json b5/comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference to blame"))
+		}
+		if blameCmdFormat != "" && blameCmdFormat != "json" {
+			ErrExit(fmt.Errorf("invalid format. currently only json or a table are supported"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.BlameParams{
+			Ref:    ref,
+			Key:    blameCmdKey,
+			Offset: blameCmdOffset,
+			Limit:  blameCmdLimit,
+		}
+		res := &core.BlameResult{}
+		err = req.Blame(p, res)
+		ExitIfErr(err)
+
+		if blameCmdFormat == "json" {
+			data, err := json.MarshalIndent(res, "", "  ")
+			ExitIfErr(err)
+			fmt.Printf("%s\n", string(data))
+			return
+		}
+		printBlame(res)
+	},
+}
+
+func init() {
+	blameCmd.Flags().StringVarP(&blameCmdKey, "key", "k", "", "column that identifies rows across versions")
+	blameCmd.Flags().IntVarP(&blameCmdOffset, "offset", "o", 0, "row to start blaming from")
+	blameCmd.Flags().IntVarP(&blameCmdLimit, "limit", "l", 0, "number of rows to blame, 0 blames all rows")
+	blameCmd.Flags().StringVarP(&blameCmdFormat, "format", "f", "", "output format [json]. default is a table")
+	RootCmd.AddCommand(blameCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// checkoutCmd represents the checkout command
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout",
+	Short: "write a dataset to a linked working directory",
+	Long: `
+Checkout writes the components of a dataset into a directory: dataset.yaml, 
+meta.json, structure.json and a data file. The directory is linked to the 
+dataset, edit the files, check what's changed with qri status, and run qri save 
+from within the directory to commit changes as a new version.`,
+	Example: `  check out b5/comics into a directory named comics:
+  $ qri checkout b5/comics comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			ErrExit(fmt.Errorf("please specify a dataset reference and a directory"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+		dir, err := filepath.Abs(args[1])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := &repo.DatasetRef{}
+		err = req.Checkout(&core.CheckoutParams{Ref: ref, Dir: dir}, res)
+		ExitIfErr(err)
+
+		printSuccess("checked out %s to %s", res, dir)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(checkoutCmd)
+}
@@ -104,11 +104,30 @@ func TestCommandsIntegration(t *testing.T) {
 		{"list"},
 		{"save", "--data=" + movies2FilePath, "-t" + "commit_1", "me/movies"},
 		{"log", "me/movies"},
+		{"verify", "--history", "me/movies"},
+		{"lineage", "--format", "dot", "me/movies"},
+		{"blame", "me/movies"},
+		{"blame", "--limit", "2", "--format", "json", "me/movies"},
 		{"diff", "me/movies", "me/movies2", "-d", "detail"},
+		{"share", "--with", "QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt", "me/movies2"},
+		{"share", "me/movies2"},
+		{"info", "me/movies@{2099-01-01}"},
 		{"export", "--dataset", "-o" + path, "me/movies"},
+		{"export", "--zip", "-o" + path, "me/movies2"},
+		{"add", "--package=" + filepath.Join(path, "movies2.zip"), "me/movies_package"},
+		{"query", "--save", "long_movies", "select movie_title from me.movies where duration > 150"},
+		{"run", "--dry-run", "me/long_movies"},
+		{"data", "-c", "movie_title", "--filter", "duration>100", "--sort", "-duration", "me/movies"},
+		{"export", "--catalog", "dcat", "-o" + path, "me/movies"},
+		{"export", "--catalog", "bibtex", "-o" + path},
 		{"rename", "me/movies", "me/movie"},
 		{"validate", "me/movie"},
+		{"checkout", "me/movies2", filepath.Join(path, "movies2")},
+		{"status", filepath.Join(path, "movies2")},
+		{"add", "--data=" + moviesFilePath, "--compression", "gzip", "me/movies_gz"},
+		{"validate", "me/movies_gz"},
 		{"remove", "me/movie"},
+		{"remove", "--all-versions", "--purge", "me/movies2"},
 	}
 
 	for i, args := range commands {
@@ -15,6 +15,7 @@ import (
 	"github.com/mr-tron/base58/base58"
 	"github.com/multiformats/go-multihash"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
@@ -181,6 +182,26 @@ type Config struct {
 	// Datastore       DatastoreCfg
 	// DefaultDatasets is a list of dataset references to grab on initially joining the network
 	DefaultDatasets []string
+	// Compression is the compression applied to data of newly added datasets
+	// when none is given, one of "gzip", "zstd" or "none"
+	Compression string
+	// RequestTimeout is how long to wait for peers to respond to requests,
+	// as a duration string like "30s". defaults to p2p.DefaultRequestTimeout
+	RequestTimeout string
+	// LocalToken is a secret the api server trusts as coming from this peer.
+	// requests sending it in the Qri-Local-Token header can see private
+	// datasets. leave empty to trust no requests
+	LocalToken string
+}
+
+// defaultCompression returns the configured data compression, falling back
+// to none when the config can't be read
+func defaultCompression() string {
+	cfg, err := readConfigFile()
+	if err != nil || cfg.Compression == "" {
+		return repo.CompressionNone
+	}
+	return cfg.Compression
 }
 
 // TODO - Is this is the right place for this?
@@ -21,6 +21,7 @@ var (
 	connectMemOnly    bool
 	connectOffline    bool
 	connectSetup      bool
+	connectWatch      []string
 )
 
 // connectCmd represents the run command
@@ -40,6 +41,9 @@ things:
 When you run connect you are connecting to the distributed web, interacting with
 peers & swapping data.
 
+Connect can also watch data files, saving new dataset versions as they change.
+Pass --watch once for each dataset_reference=file_path pair.
+
 The default port for the local API server is 2503. We call port 2503,
 “the qri port”. It’s a good port, lots of cool numbers in there. Some might even
 call it a “prime” port number.`,
@@ -68,6 +72,11 @@ call it a “prime” port number.`,
 			r = getRepo(true)
 		}
 
+		localToken := ""
+		if cfg, err := readConfigFile(); err == nil {
+			localToken = cfg.LocalToken
+		}
+
 		s, err := api.New(r, func(cfg *api.Config) {
 			cfg.Logger = log
 			cfg.Port = connectCmdPort
@@ -76,9 +85,13 @@ call it a “prime” port number.`,
 			cfg.Online = !connectOffline
 			cfg.BoostrapAddrs = viper.GetStringSlice("bootstrap")
 			cfg.PostP2POnlineHook = initializeDistributedAssets
+			cfg.LocalToken = localToken
 		})
 		ExitIfErr(err)
 
+		err = watchFiles(r, connectWatch)
+		ExitIfErr(err)
+
 		err = s.Serve()
 		ExitIfErr(err)
 	},
@@ -131,7 +144,7 @@ func initializeDistributedAssets(node *p2p.QriNode) {
 			continue
 		}
 		res := repo.DatasetRef{}
-		err = req.Add(&ref, &res)
+		err = req.Add(&core.AddParams{Ref: ref}, &res)
 		if err != nil {
 			fmt.Printf("add dataset %s error: %s\n", refstr, err.Error())
 			return
@@ -153,5 +166,8 @@ func init() {
 	connectCmd.Flags().BoolVarP(&connectSetup, "setup", "", false, "run setup if necessary, reading options from enviornment variables")
 	connectCmd.Flags().BoolVarP(&connectMemOnly, "mem-only", "", false, "run qri entirely in-memory, persisting nothing")
 	connectCmd.Flags().BoolVarP(&connectOffline, "offline", "", false, "disable networking")
+	connectCmd.Flags().StringSliceVarP(&connectWatch, "watch", "", nil, "save new versions when a file changes, in the form dataset_reference=file_path")
+	connectCmd.Flags().DurationVarP(&watchDebounce, "watch-debounce", "", core.DefaultWatchDebounce, "time to wait for watched file changes to settle before saving")
+	connectCmd.Flags().BoolVarP(&watchStrict, "watch-strict", "", false, "skip saving watched data that doesn't validate")
 	RootCmd.AddCommand(connectCmd)
 }
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/query"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dataCmdFormat   string
+	dataCmdLimit    int
+	dataCmdOffset   int
+	dataCmdAll      bool
+	dataCmdColumns  []string
+	dataCmdFilters  []string
+	dataCmdSort     []string
+	dataCmdDistinct bool
+)
+
+// dataCmd represents the data command
+var dataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "read dataset data",
+	Long: `
+Data prints the rows of a dataset. Rows can be narrowed to a set of columns,
+filtered with simple predicates, sorted & de-duplicated.
+
+Filters take the form [column][op][value], where op is one of:
+  =   equal to
+  !=  not equal to
+  <   less than, <= less than or equal to
+  >   greater than, >= greater than or equal to
+  ~   contains (case-insensitive)
+Multiple filters must all match. Limit & offset apply after filtering.`,
+	Example: `  show the first 10 rows of b5/movies:
+  $ qri data --limit 10 b5/movies
+
+  show titles of long movies, longest first:
+  $ qri data -c title --filter "duration>=150" --sort -duration b5/movies`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference"))
+		}
+
+		format, err := dataset.ParseDataFormatString(dataCmdFormat)
+		if err != nil {
+			ErrExit(fmt.Errorf("invalid data format: %s", dataCmdFormat))
+		}
+		if format != dataset.CSVDataFormat && format != dataset.JSONDataFormat {
+			ErrExit(fmt.Errorf("invalid data format. currently only csv or json are supported"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := &repo.DatasetRef{}
+		err = req.Get(&ref, res)
+		ExitIfErr(err)
+
+		p := &core.StructuredDataParams{
+			Format:   format,
+			Path:     datastore.NewKey(res.Path),
+			Limit:    dataCmdLimit,
+			Offset:   dataCmdOffset,
+			All:      dataCmdAll,
+			Columns:  dataCmdColumns,
+			Distinct: dataCmdDistinct,
+		}
+		switch format {
+		case dataset.JSONDataFormat:
+			p.FormatConfig = &dataset.JSONOptions{ArrayEntries: true}
+		case dataset.CSVDataFormat:
+			// printResults writes its own header row
+			p.FormatConfig = &dataset.CSVOptions{HeaderRow: false}
+		}
+		for _, str := range dataCmdFilters {
+			pred, err := query.ParsePredicate(str)
+			ExitIfErr(err)
+			p.Filters = append(p.Filters, pred)
+		}
+		for _, key := range dataCmdSort {
+			p.Sort = append(p.Sort, query.ParseSortKey(key))
+		}
+
+		data := &core.StructuredData{}
+		err = req.StructuredData(p, data)
+		ExitIfErr(err)
+
+		raw, ok := data.Data.(json.RawMessage)
+		if !ok {
+			ErrExit(fmt.Errorf("unexpected data response"))
+		}
+
+		if format == dataset.CSVDataFormat && len(dataCmdColumns) > 0 {
+			printCSVTable(dataCmdColumns, raw)
+			return
+		}
+		printResults(res.Dataset.Structure, raw, format)
+	},
+}
+
+func init() {
+	dataCmd.Flags().StringVarP(&dataCmdFormat, "format", "f", "csv", "set output format [csv,json]")
+	dataCmd.Flags().IntVarP(&dataCmdLimit, "limit", "l", 50, "max number of rows to return")
+	dataCmd.Flags().IntVarP(&dataCmdOffset, "offset", "o", 0, "number of rows to skip")
+	dataCmd.Flags().BoolVarP(&dataCmdAll, "all", "a", false, "return all rows, ignoring limit & offset")
+	dataCmd.Flags().StringSliceVarP(&dataCmdColumns, "columns", "c", nil, "comma-separated list of columns to select")
+	dataCmd.Flags().StringArrayVar(&dataCmdFilters, "filter", nil, "filter rows with a [column][op][value] predicate. may be repeated")
+	dataCmd.Flags().StringSliceVarP(&dataCmdSort, "sort", "s", nil, "comma-separated columns to sort by. prefix with - to sort descending")
+	dataCmd.Flags().BoolVarP(&dataCmdDistinct, "distinct", "d", false, "remove duplicate rows")
+	RootCmd.AddCommand(dataCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// dedupCmd represents the dedup command
+var dedupCmd = &cobra.Command{
+	Use:   "dedup",
+	Short: "show how much storage a dataset's versions share",
+	Long: `
+Dedup compares the size of every version of a dataset to the space the 
+versions actually take up. Datasets added or saved with --chunked store data in 
+chunks, and versions share unchanged chunks. A ratio of 2.0 means versions take 
+up half the space they would if each were stored in full.`,
+	Example: `  show storage for b5/comics:
+  $ qri dedup b5/comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := &repo.DedupStats{}
+		err = req.Dedup(&ref, res)
+		ExitIfErr(err)
+
+		printInfo("versions:    %d", res.Versions)
+		printInfo("data size:   %d bytes", res.Size)
+		printInfo("stored size: %d bytes in %d chunks", res.StoredSize, res.Chunks)
+		printSuccess("dedup ratio: %.2f", res.Ratio())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(dedupCmd)
+}
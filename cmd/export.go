@@ -10,7 +10,7 @@ import (
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
-	"github.com/qri-io/dataset/dsutil"
+	"github.com/qri-io/qri/catalog"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
@@ -24,6 +24,10 @@ var (
 	exportCmdTransform bool
 	exportCmdVis       bool
 	exportCmdAll       bool
+	exportCmdFormat    string
+	exportCmdDelimiter string
+	exportCmdHeader    bool
+	exportCmdCatalog   string
 )
 
 // exportCmd represents the export command
@@ -34,8 +38,18 @@ var exportCmd = &cobra.Command{
 Export gets datasets out of qri. By default it exports only a dataset’s data to 
 the path [current directory]/[peername]/[dataset name]/[data file]. 
 
-To export everything about a dataset, use the --dataset flag.`,
+To export everything about a dataset, use the --dataset flag.
+
+Data can be converted to csv, json, ndjson or cbor with the --format flag.
+
+To export a catalog entry describing a dataset, use --catalog with one of dcat, 
+schema.org, datacite or bibtex. Leaving out the dataset name exports a catalog 
+of all datasets.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if exportCmdCatalog != "" {
+			exportCatalog(cmd.Flag("output").Value.String(), args)
+			return
+		}
 		if len(args) == 0 {
 			fmt.Println("please specify a dataset name to export")
 			return
@@ -73,7 +87,7 @@ To export everything about a dataset, use the --dataset flag.`,
 			dst, err := os.Create(fmt.Sprintf("%s.zip", path))
 			ExitIfErr(err)
 
-			err = dsutil.WriteZipArchive(r.Store(), ds, dst)
+			err = core.WriteZipPackage(r, ds, dst)
 			ExitIfErr(err)
 			err = dst.Close()
 			ExitIfErr(err)
@@ -83,6 +97,8 @@ To export everything about a dataset, use the --dataset flag.`,
 			exportCmdDataset = true
 			exportCmdMeta = true
 			exportCmdStructure = true
+			exportCmdTransform = ds.Transform != nil
+			exportCmdVis = ds.VisConfig != nil
 		}
 
 		if path != "" {
@@ -153,14 +169,31 @@ To export everything about a dataset, use the --dataset flag.`,
 		}
 
 		if exportCmdData {
-			src, err := dsfs.LoadData(r.Store(), ds)
+			src, err := repo.LoadData(r, ds)
 			ExitIfErr(err)
 
-			dataPath := filepath.Join(path, fmt.Sprintf("data.%s", ds.Structure.Format.String()))
+			ext := ds.Structure.Format.String()
+			if exportCmdFormat != "" {
+				ext = exportCmdFormat
+			}
+			dataPath := filepath.Join(path, fmt.Sprintf("data.%s", ext))
 			dst, err := os.Create(dataPath)
 			ExitIfErr(err)
 
-			_, err = io.Copy(dst, src)
+			if exportCmdFormat != "" {
+				format, err := core.ParseExportFormat(exportCmdFormat)
+				ExitIfErr(err)
+				delim, err := core.ParseCSVDelimiter(exportCmdDelimiter)
+				ExitIfErr(err)
+
+				err = core.ConvertData(ds.Structure, src, dst, &core.ConvertParams{
+					Format:       format,
+					CSVDelimiter: delim,
+					CSVHeader:    exportCmdHeader,
+				})
+			} else {
+				_, err = io.Copy(dst, src)
+			}
 			ExitIfErr(err)
 
 			err = dst.Close()
@@ -168,6 +201,45 @@ To export everything about a dataset, use the --dataset flag.`,
 			printSuccess("exported data to: %s", dataPath)
 		}
 
+		if exportCmdTransform {
+			if ds.Transform == nil {
+				printWarning("dataset has no transform")
+			} else {
+				t := ds.Transform
+				if t.Path().String() != "" {
+					t, err = dsfs.LoadTransform(repo.NewDecryptingStore(r.Store(), r), t.Path())
+					ExitIfErr(err)
+				}
+				tpath := filepath.Join(path, "transform.json")
+				tbytes, err := json.MarshalIndent(t, "", "  ")
+				ExitIfErr(err)
+				err = ioutil.WriteFile(tpath, tbytes, os.ModePerm)
+				ExitIfErr(err)
+				printSuccess("exported transform file to: %s", tpath)
+			}
+		}
+
+		if exportCmdVis {
+			if ds.VisConfig == nil {
+				printWarning("dataset has no vis config")
+			} else {
+				vc := ds.VisConfig
+				if vc.Path().String() != "" {
+					f, err := r.Store().Get(vc.Path())
+					ExitIfErr(err)
+					vc = &dataset.VisConfig{}
+					err = json.NewDecoder(f).Decode(vc)
+					ExitIfErr(err)
+				}
+				vpath := filepath.Join(path, "vis_config.json")
+				vbytes, err := json.MarshalIndent(vc, "", "  ")
+				ExitIfErr(err)
+				err = ioutil.WriteFile(vpath, vbytes, os.ModePerm)
+				ExitIfErr(err)
+				printSuccess("exported vis config file to: %s", vpath)
+			}
+		}
+
 		if exportCmdDataset {
 			dsPath := filepath.Join(path, dsfs.PackageFileDataset.String())
 			dsbytes, err := json.MarshalIndent(ds, "", "  ")
@@ -183,19 +255,51 @@ To export everything about a dataset, use the --dataset flag.`,
 	},
 }
 
+// exportCatalog writes a catalog entry for a dataset, or a catalog of all
+// datasets if no dataset is given
+func exportCatalog(path string, args []string) {
+	format, err := catalog.ParseFormat(exportCmdCatalog)
+	ExitIfErr(err)
+
+	p := &core.CatalogParams{Format: format}
+	filename := "catalog"
+	if len(args) > 0 {
+		p.Ref, err = repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+		filename = p.Ref.Name
+	}
+
+	req, err := datasetRequests(false)
+	ExitIfErr(err)
+
+	res := []byte{}
+	err = req.Catalog(p, &res)
+	ExitIfErr(err)
+
+	if path != "" {
+		err = os.MkdirAll(path, os.ModePerm)
+		ExitIfErr(err)
+	}
+	catalogPath := filepath.Join(path, fmt.Sprintf("%s.%s%s", filename, format, format.Extension()))
+	err = ioutil.WriteFile(catalogPath, res, os.ModePerm)
+	ExitIfErr(err)
+	printSuccess("exported %s catalog to: %s", format, catalogPath)
+}
+
 func init() {
 	RootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().StringP("output", "o", "", "path to write to, default is current directory")
 	exportCmd.Flags().BoolP("zip", "z", false, "compress export as zip archive")
 	exportCmd.Flags().BoolVarP(&exportCmdAll, "all", "a", false, "export full dataset package")
 	exportCmd.Flags().BoolVarP(&exportCmdAll, "namespaced", "n", false, "export to a peer name namespaced directory")
+	exportCmd.Flags().StringVarP(&exportCmdCatalog, "catalog", "", "", "export a catalog entry [dcat,schema.org,datacite,bibtex]")
 	exportCmd.Flags().BoolVarP(&exportCmdDataset, "dataset", "", false, "export root dataset")
 	exportCmd.Flags().BoolVarP(&exportCmdMeta, "meta", "m", false, "export dataset metadata file")
 	exportCmd.Flags().BoolVarP(&exportCmdStructure, "structure", "s", false, "export dataset structure file")
 	exportCmd.Flags().BoolVarP(&exportCmdData, "data", "d", true, "export dataset data file")
-	// exportCmd.Flags().BoolVarP(&exportCmdTransform, "transform", "t", false, "export dataset transform file")
-	// exportCmd.Flags().BoolVarP(&exportCmdVis, "vis-conf", "c", false, "export viz config file")
-
-	// TODO - get format conversion up & running
-	// exportCmd.Flags().StringP("format", "f", "csv", "set output format [csv,json]")
+	exportCmd.Flags().BoolVarP(&exportCmdTransform, "transform", "t", false, "export dataset transform file")
+	exportCmd.Flags().BoolVarP(&exportCmdVis, "vis-conf", "c", false, "export viz config file")
+	exportCmd.Flags().StringVarP(&exportCmdFormat, "format", "f", "", "convert data to format [csv,json,ndjson,cbor]. default is stored format")
+	exportCmd.Flags().StringVarP(&exportCmdDelimiter, "delimiter", "", ",", "field delimiter for csv data. use \\t for tabs")
+	exportCmd.Flags().BoolVarP(&exportCmdHeader, "header", "", true, "include a header row in csv data")
 }
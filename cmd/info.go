@@ -8,9 +8,15 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/stats"
 	"github.com/spf13/cobra"
 )
 
+var (
+	infoCmdStats         bool
+	infoCmdRequireSigned bool
+)
+
 // infoCmd represents the info command
 var infoCmd = &cobra.Command{
 	Use:     "info",
@@ -22,7 +28,10 @@ var infoCmd = &cobra.Command{
   $ qri info b5/comics
 
   get info for a dataset at a specific version:
-  $ qri info QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn`,
+  $ qri info QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn
+
+  show column statistics for b5/comics:
+  $ qri info --stats b5/comics`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			// ErrExit(fmt.Errorf("please specify a dataset path or name to get the info of"))
@@ -59,6 +68,7 @@ var infoCmd = &cobra.Command{
 
 		req, err := datasetRequests(online)
 		ExitIfErr(err)
+		req.RequireSigned = infoCmdRequireSigned
 
 		for i, arg := range args {
 			ref, err := repo.ParseDatasetRef(arg)
@@ -79,10 +89,24 @@ var infoCmd = &cobra.Command{
 					ExitIfErr(err)
 					fmt.Printf("%s", string(data))
 				}
+			} else if infoCmdStats {
+				res := &stats.Stats{}
+				err = req.Stats(&ref, res)
+				ExitIfErr(err)
+
+				if outformat == "" {
+					printInfo("%s", ref)
+					printStats(res)
+				} else {
+					data, err := json.MarshalIndent(res, "", "  ")
+					ExitIfErr(err)
+					fmt.Printf("%s", string(data))
+				}
 			} else {
 				res := repo.DatasetRef{}
 				err = req.Get(&ref, &res)
 				ExitIfErr(err)
+				printSignatureWarning(res)
 
 				if outformat == "" {
 					printDatasetRefInfo(i, res)
@@ -99,4 +123,6 @@ var infoCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(infoCmd)
 	infoCmd.Flags().StringP("format", "f", "", "set output format [json]")
+	infoCmd.Flags().BoolVarP(&infoCmdStats, "stats", "", false, "show column statistics for datasets")
+	infoCmd.Flags().BoolVarP(&infoCmdRequireSigned, "require-signed", "", false, "error if a dataset doesn't have a valid commit signature")
 }
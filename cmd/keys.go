@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var keysSharePubKey string
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "share & import keys for encrypted datasets",
+	Long: `
+Datasets added or saved with --encrypt store their data, metadata, structure, 
+commit & transform encrypted with a key only this peer holds. To let another peer read an encrypted dataset, share 
+its key with that peer, then have them import the output of keys share.`,
+}
+
+var keysShareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "share the key of an encrypted dataset with a peer",
+	Example: `  share the key for b5/comics with a connected peer:
+  $ qri keys share QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt b5/comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 && !(len(args) == 1 && keysSharePubKey != "") {
+			ErrExit(fmt.Errorf("please specify a peer id and a dataset reference"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[len(args)-1])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.ShareKeyParams{
+			Ref:    ref,
+			PubKey: keysSharePubKey,
+		}
+		if len(args) == 2 {
+			p.PeerID = args[0]
+		}
+		res := ""
+		err = req.ShareKey(p, &res)
+		ExitIfErr(err)
+
+		printSuccess(res)
+	},
+}
+
+var keysImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import a key another peer shared",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a shared key to import"))
+		}
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		id := ""
+		err = req.ImportKey(&args[0], &id)
+		ExitIfErr(err)
+
+		printSuccess("imported key %s", id)
+	},
+}
+
+func init() {
+	keysShareCmd.Flags().StringVarP(&keysSharePubKey, "pubkey", "", "", "base64-encoded public key to share with, in place of a peer id")
+	keysCmd.AddCommand(keysShareCmd)
+	keysCmd.AddCommand(keysImportCmd)
+	RootCmd.AddCommand(keysCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lineageCmdDepth  int
+	lineageCmdFormat string
+)
+
+// lineageCmd represents the lineage command
+var lineageCmd = &cobra.Command{
+	Use:   "lineage",
+	Short: "show datasets a dataset was derived from & datasets derived from it",
+	Long: `
+Lineage follows the transforms that connect datasets, showing the upstream 
+inputs a dataset was created from and the downstream datasets that were 
+created from it. Use --depth to limit how many transforms are followed in 
+each direction, and --format to export the graph as graphviz dot, json or 
+mermaid.`,
+	Example: `  show lineage of b5/comics:
+  $ qri lineage b5/comics
+
+  render direct inputs & dependents of b5/comics with graphviz:
+  $ qri lineage --depth 1 --format dot b5/comics | dot -Tpng > comics.png`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference to show lineage for"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.LineageParams{Ref: ref, Depth: lineageCmdDepth}
+		res := &repo.LineageGraph{}
+		err = req.Lineage(p, res)
+		ExitIfErr(err)
+
+		if lineageCmdFormat != "" {
+			data, err := res.Encode(lineageCmdFormat)
+			ExitIfErr(err)
+			fmt.Printf("%s", string(data))
+			return
+		}
+
+		for _, n := range res.Nodes {
+			switch {
+			case n.Depth < 0:
+				printInfo("upstream %d\t%s", -n.Depth, n.Label())
+			case n.Depth > 0:
+				printInfo("downstream %d\t%s", n.Depth, n.Label())
+			default:
+				printSuccess("dataset\t\t%s", n.Label())
+			}
+		}
+	},
+}
+
+func init() {
+	lineageCmd.Flags().IntVarP(&lineageCmdDepth, "depth", "d", 0, "number of transforms to follow in each direction, 0 follows all")
+	lineageCmd.Flags().StringVarP(&lineageCmdFormat, "format", "f", "", "export format [dot,json,mermaid]")
+	RootCmd.AddCommand(lineageCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	// "encoding/json"
 	// "fmt"
 	// "github.com/qri-io/dataset"
@@ -13,6 +14,8 @@ import (
 var (
 	dsLogLimit, dsLogOffset int
 	dsLogName               string
+	dsLogRequireSigned      bool
+	dsLogAuthor             string
 )
 
 var datasetLogCmd = &cobra.Command{
@@ -27,7 +30,8 @@ about how that dataset looked at at that point in time.
 We call these snapshots versions. Each version has an author (the peer that 
 created the version) and a message explaining what changed. Log prints these 
 details in order of occurrence, starting with the most recent known version, 
-working backwards in time.`,
+working backwards in time. Versions without a valid commit signature are 
+flagged, use --require-signed to refuse to show them.`,
 	Example: `  show log for the dataset b5/precip:
 	$ qri log b5/precip`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -53,11 +57,13 @@ working backwards in time.`,
 		// TODO - add limit & offset params
 		hr, err := historyRequests(online)
 		ExitIfErr(err)
+		hr.RequireSigned = dsLogRequireSigned
 
 		p := &core.LogParams{
 			// Limit:  dsLogLimit,
 			// Offset: dsLogOffset,
-			Ref: ref,
+			Ref:    ref,
+			Author: dsLogAuthor,
 			ListParams: core.ListParams{
 				Peername: ref.Peername,
 			},
@@ -68,7 +74,13 @@ working backwards in time.`,
 		ExitIfErr(err)
 
 		for _, ref := range refs {
-			printSuccess("%s - %s\n\t%s\n", ref.Dataset.Commit.Timestamp.Format("Jan _2 15:04:05"), ref.Path, ref.Dataset.Commit.Title)
+			commit := ref.Dataset.Commit
+			authors := strings.Join(core.CommitAuthors(commit), ", ")
+			if ref.SignatureStatus != repo.SignatureValid {
+				printWarning("%s - %s (%s)\n\t%s\n\t%s\n", commit.Timestamp.Format("Jan _2 15:04:05"), ref.Path, ref.SignatureStatus, authors, commit.Title)
+				continue
+			}
+			printSuccess("%s - %s\n\t%s\n\t%s\n", commit.Timestamp.Format("Jan _2 15:04:05"), ref.Path, authors, commit.Title)
 		}
 
 		// outformat := cmd.Flag("format").Value.String()
@@ -93,5 +105,7 @@ func init() {
 	// datasetLogCmd.Flags().StringP("format", "f", "", "set output format [json]")
 	datasetLogCmd.Flags().IntVarP(&dsLogLimit, "limit", "l", 25, "limit results, default 25")
 	datasetLogCmd.Flags().IntVarP(&dsLogOffset, "offset", "o", 0, "offset results, default 0")
+	datasetLogCmd.Flags().BoolVarP(&dsLogRequireSigned, "require-signed", "", false, "error if any version doesn't have a valid commit signature")
+	datasetLogCmd.Flags().StringVarP(&dsLogAuthor, "author", "a", "", "only show versions authored or co-authored by a peername or peer id")
 	datasetLogCmd.Flags().StringVarP(&dsLogName, "name", "n", "", "name of dataset to get logs for")
 }
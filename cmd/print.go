@@ -15,6 +15,8 @@ import (
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/stats"
 	"github.com/spf13/cobra"
 )
 
@@ -55,13 +57,24 @@ func printNotYetFinished(cmd *cobra.Command) {
 // 	}
 // }
 
+// printSignatureWarning warns when a dataset commit isn't validly signed
+func printSignatureWarning(ref repo.DatasetRef) {
+	if ref.SignatureStatus != "" && ref.SignatureStatus != repo.SignatureValid {
+		printWarning("warning: %s has an %s commit signature", ref.Path, ref.SignatureStatus)
+	}
+}
+
 func printDatasetRefInfo(i int, ref repo.DatasetRef) {
 	white := color.New(color.FgWhite).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 	blue := color.New(color.FgBlue).SprintFunc()
 	ds := ref.Dataset
 
-	fmt.Printf("%s  %s\n", cyan(i), white(ref.Name))
+	if ref.Visibility != "" && ref.Visibility != repo.VisibilityPublic {
+		fmt.Printf("%s  %s (%s)\n", cyan(i), white(ref.Name), ref.Visibility)
+	} else {
+		fmt.Printf("%s  %s\n", cyan(i), white(ref.Name))
+	}
 	fmt.Printf("    %s\n", blue(ref.Path))
 	if ds != nil && ds.Meta != nil {
 		if ds.Meta.Title != "" {
@@ -141,29 +154,95 @@ func printResults(r *dataset.Structure, data []byte, format dataset.DataFormat)
 	case dataset.JSONDataFormat:
 		fmt.Println(string(data))
 	case dataset.CSVDataFormat:
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-		table.SetCenterSeparator("|")
-		hr, err := terribleHackToGetHeaderRow(r)
-		if err == nil {
-			table.SetHeader(hr)
+		hr, _ := terribleHackToGetHeaderRow(r)
+		printCSVTable(hr, data)
+	}
+}
+
+// printCSVTable renders csv data as a table, header is optional
+func printCSVTable(header []string, data []byte) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	if header != nil {
+		table.SetHeader(header)
+	}
+	r := csv.NewReader(bytes.NewBuffer(data))
+	for {
+		rec, err := r.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			fmt.Println(err.Error())
+			os.Exit(1)
 		}
-		r := csv.NewReader(bytes.NewBuffer(data))
-		for {
-			rec, err := r.Read()
-			if err != nil {
-				if err.Error() == "EOF" {
-					break
-				}
-				fmt.Println(err.Error())
-				os.Exit(1)
+
+		table.Append(rec)
+	}
+
+	table.Render()
+}
+
+// printStats renders column stats as a table, one row per column
+func printStats(s *stats.Stats) {
+	fmt.Printf("%d rows\n", s.Rows)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetHeader([]string{"column", "type", "count", "nulls", "distinct", "min", "max", "mean", "top values"})
+	for _, c := range s.Columns {
+		mean := ""
+		if c.Mean != nil {
+			mean = fmt.Sprintf("%g", *c.Mean)
+		}
+		top := make([]string, 0, 3)
+		for i, vc := range c.TopK {
+			if i == 3 {
+				break
 			}
+			top = append(top, fmt.Sprintf("%v (%d)", vc.Value, vc.Count))
+		}
+		table.Append([]string{
+			c.Name,
+			c.Type,
+			fmt.Sprintf("%d", c.Count),
+			fmt.Sprintf("%d", c.Nulls),
+			fmt.Sprintf("~%d", c.Distinct),
+			statsValueString(c.Min),
+			statsValueString(c.Max),
+			mean,
+			strings.Join(top, ", "),
+		})
+	}
+	table.Render()
+}
 
-			table.Append(rec)
+// printBlame renders blamed rows as a table, one row per dataset row
+func printBlame(res *core.BlameResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetHeader(append([]string{"row", "path", "title", "author", "timestamp"}, res.Columns...))
+	for _, r := range res.Rows {
+		ts := ""
+		if !r.Timestamp.IsZero() {
+			ts = r.Timestamp.Format(time.RFC3339)
+		}
+		rec := []string{fmt.Sprintf("%d", r.Row), r.Path, r.Title, r.Author, ts}
+		for _, v := range r.Values {
+			rec = append(rec, statsValueString(v))
 		}
+		table.Append(rec)
+	}
+	table.Render()
+}
 
-		table.Render()
+func statsValueString(v interface{}) string {
+	if v == nil {
+		return ""
 	}
+	return fmt.Sprintf("%v", v)
 }
 
 // TODO - holy shit dis so bad. fix
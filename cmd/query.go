@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryCmdSave   string
+	queryCmdFormat string
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:     "query",
+	Aliases: []string{"sql"},
+	Short:   "run a SQL query on one or more datasets",
+	Long: `
+Query runs a SQL SELECT statement against datasets, producing a new dataset.
+Datasets are referred to as tables in the form peername.dataset_name.
+Supported clauses are JOIN (inner & left), WHERE, GROUP BY, HAVING, ORDER BY,
+LIMIT & OFFSET, along with the aggregate functions COUNT, SUM, AVG, MIN & MAX.
+
+Every query result is a dataset with a transform that records the statement
+and the exact version of each input dataset. Use --save to give the result
+a name.`,
+	Example: `  average movie duration:
+  $ qri query "SELECT AVG(duration) FROM me.movies"
+
+  join two datasets, saving the results as b5/comic_movies:
+  $ qri query --save comic_movies "SELECT c.title, m.duration
+    FROM b5.comics c JOIN b5.movies m ON c.title = m.movie_title"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide exactly one query statement"))
+		}
+
+		format, err := dataset.ParseDataFormatString(queryCmdFormat)
+		if err != nil {
+			ErrExit(fmt.Errorf("invalid data format: %s", queryCmdFormat))
+		}
+		if format != dataset.CSVDataFormat && format != dataset.JSONDataFormat {
+			ErrExit(fmt.Errorf("invalid data format. currently only csv or json are supported"))
+		}
+
+		r, cli, err := repoOrClient(false)
+		ExitIfErr(err)
+		req := core.NewQueryRequests(r, cli)
+
+		res := &repo.DatasetRef{}
+		err = req.Query(&core.QueryParams{Statement: args[0], Name: queryCmdSave}, res)
+		ExitIfErr(err)
+
+		dsr := core.NewDatasetRequests(r, cli)
+
+		p := &core.StructuredDataParams{
+			Format: format,
+			Path:   datastore.NewKey(res.Path),
+			All:    true,
+		}
+		switch format {
+		case dataset.JSONDataFormat:
+			p.FormatConfig = &dataset.JSONOptions{ArrayEntries: true}
+		case dataset.CSVDataFormat:
+			// printResults writes its own header row
+			p.FormatConfig = &dataset.CSVOptions{HeaderRow: false}
+		}
+
+		data := &core.StructuredData{}
+		err = dsr.StructuredData(p, data)
+		ExitIfErr(err)
+
+		raw, ok := data.Data.(json.RawMessage)
+		if !ok {
+			ErrExit(fmt.Errorf("unexpected query result data"))
+		}
+		printResults(res.Dataset.Structure, raw, format)
+
+		if queryCmdSave != "" {
+			printSuccess("query results saved as: %s/%s", res.Peername, res.Name)
+		}
+		printInfo(res.Path)
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&queryCmdSave, "save", "s", "", "save query results to a dataset with this name")
+	queryCmd.Flags().StringVarP(&queryCmdFormat, "format", "f", "csv", "set output format [csv,json]")
+	RootCmd.AddCommand(queryCmd)
+}
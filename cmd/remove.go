@@ -3,10 +3,17 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 )
 
+var (
+	removeVersion     string
+	removeAllVersions bool
+	removePurge       bool
+)
+
 var datasetRemoveCmd = &cobra.Command{
 	Use:     "remove",
 	Aliases: []string{"rm"},
@@ -22,8 +29,17 @@ be sooner.
 Keep in mind that by default your IPFS repo is capped at 10GB in size, if you
 adjust this cap using IPFS, qri will respect it.
 
-In the future we’ll add a flag that’ll force immediate removal of a dataset from
-both qri & IPFS. Promise.`,
+To remove a single bad version pass its path with --version. Versions after the 
+removed one are rewritten to skip it, so their paths change. To get rid of a 
+dataset entirely, use --all-versions --purge, which unpins every version & 
+deletes everything only that dataset references right away.
+
+Peers you’ve listed the dataset to are told it’s been removed.`,
+	Example: `  remove a single version of b5/comics:
+  $ qri remove --version /ipfs/QmYCvbfNbCwFR45HiNP45rwJgvatpiW38D961L5qAhUM5Y b5/comics
+
+  remove every trace of b5/comics:
+  $ qri remove --all-versions --purge b5/comics`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			ErrExit(fmt.Errorf("please specify a dataset path or name to get the info of"))
@@ -36,14 +52,27 @@ both qri & IPFS. Promise.`,
 			ref, err := repo.ParseDatasetRef(arg)
 			ExitIfErr(err)
 
+			p := &core.RemoveParams{
+				Ref:         ref,
+				Version:     removeVersion,
+				AllVersions: removeAllVersions,
+				Purge:       removePurge,
+			}
 			res := false
-			err = req.Remove(&ref, &res)
+			err = req.Remove(p, &res)
 			ExitIfErr(err)
+			if removeVersion != "" {
+				printSuccess("removed version %s of %s", removeVersion, ref)
+				continue
+			}
 			printSuccess("removed dataset %s", ref)
 		}
 	},
 }
 
 func init() {
+	datasetRemoveCmd.Flags().StringVarP(&removeVersion, "version", "", "", "path of a single version to remove")
+	datasetRemoveCmd.Flags().BoolVarP(&removeAllVersions, "all-versions", "", false, "unpin every version of the dataset")
+	datasetRemoveCmd.Flags().BoolVarP(&removePurge, "purge", "", false, "delete everything only this dataset references, requires --all-versions")
 	RootCmd.AddCommand(datasetRemoveCmd)
 }
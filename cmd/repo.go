@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/rpc"
 	"strings"
+	"time"
 
 	ipfs "github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/qri/core"
@@ -202,10 +203,18 @@ func qriNode(online bool) (node *p2p.QriNode, err error) {
 
 	r.SetPrivateKey(pk)
 
+	timeout := p2p.DefaultRequestTimeout
+	if cfg.RequestTimeout != "" {
+		if timeout, err = time.ParseDuration(cfg.RequestTimeout); err != nil {
+			return nil, fmt.Errorf("invalid request timeout: %s", err.Error())
+		}
+	}
+
 	node, err = p2p.NewQriNode(r, func(ncfg *p2p.NodeCfg) {
 		ncfg.Logger = log
 		ncfg.Online = online
 		ncfg.QriBootstrapAddrs = cfg.Bootstrap
+		ncfg.RequestTimeout = timeout
 	})
 	if err != nil {
 		return
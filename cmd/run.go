@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runCmdDryRun bool
+	runCmdPinned bool
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "re-run a dataset's transform",
+	Long: `
+Run re-executes the transform that produced a dataset. By default each input
+is resolved to the latest version in your repo. Use --pinned to execute
+against the exact input versions the transform last recorded.
+
+A new version of the dataset is saved only if the output changed. Run lists
+which inputs moved to new versions since the transform last ran.`,
+	Example: `  re-run the transform for me/big_cities:
+  $ qri run me/big_cities
+
+  check if me/big_cities is out of date without saving:
+  $ qri run --dry-run me/big_cities`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference to run"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		r, cli, err := repoOrClient(false)
+		ExitIfErr(err)
+		req := core.NewQueryRequests(r, cli)
+
+		res := &core.RunResult{}
+		err = req.Run(&core.RunParams{Ref: ref, Pinned: runCmdPinned, DryRun: runCmdDryRun}, res)
+		ExitIfErr(err)
+
+		for _, in := range res.Inputs {
+			if in.Moved() {
+				printWarning("%s moved:\n\t%s -> %s", in.Name, in.PrevPath, in.Path)
+			} else {
+				printInfo("%s unchanged:\n\t%s", in.Name, in.Path)
+			}
+		}
+
+		switch {
+		case !res.Changed:
+			printInfo("output unchanged, nothing to save")
+		case res.Saved:
+			printSuccess("saved new version of %s/%s:\n\t%s", res.Ref.Peername, res.Ref.Name, res.Ref.Path)
+		default:
+			printWarning("output changed. dry run, not saving")
+		}
+	},
+}
+
+func init() {
+	runCmd.Flags().BoolVarP(&runCmdDryRun, "dry-run", "n", false, "execute the transform without saving results")
+	runCmd.Flags().BoolVarP(&runCmdPinned, "pinned", "p", false, "use input versions recorded in the transform")
+	RootCmd.AddCommand(runCmd)
+}
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
@@ -23,6 +22,11 @@ var (
 	savePassive        bool
 	saveRescursive     bool
 	saveShowValidation bool
+	saveCoAuthors      []string
+	savePrivate        bool
+	saveEncrypt        bool
+	saveChunked        bool
+	saveCompression    string
 )
 
 // saveCmd represents the save command
@@ -36,6 +40,9 @@ structure. Every time you run save, an entry is added to your dataset’s log
 provide a message about what you changed and why. If you don’t provide a message 
 we’ll automatically generate one for you.
 
+Running save with no arguments inside a directory created with qri checkout 
+saves the files that have changed in that directory.
+
 Currently you can only save changes to datasets that you control. Tools for 
 collaboration are in the works. Sit tight sportsfans.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -45,6 +52,13 @@ collaboration are in the works. Sit tight sportsfans.`,
 		)
 
 		if len(args) < 1 {
+			// inside a linked working directory save commits changed files
+			if wd, err := os.Getwd(); err == nil {
+				if _, err := core.ReadWorkingDirLink(wd); err == nil {
+					saveWorkingDir(wd)
+					return
+				}
+			}
 			ErrExit(fmt.Errorf("please provide the name of an existing dataset so save updates to"))
 		}
 		if saveMetaFile == "" && saveDataFile == "" && saveStructureFile == "" {
@@ -56,7 +70,12 @@ collaboration are in the works. Sit tight sportsfans.`,
 
 		req := core.NewDatasetRequests(getRepo(false), nil)
 		save := &core.SaveParams{
-			Prev: ref,
+			Prev:        ref,
+			CoAuthors:   saveCoAuthors,
+			Private:     savePrivate,
+			Encrypt:     saveEncrypt,
+			Chunked:     saveChunked,
+			Compression: saveCompression,
 			Changes: &dataset.Dataset{
 				Commit: &dataset.Commit{
 					Title:   saveTitle,
@@ -105,7 +124,7 @@ collaboration are in the works. Sit tight sportsfans.`,
 			err = req.Get(&ref, &res)
 			ExitIfErr(err)
 
-			df, err := dsfs.LoadData(r.Store(), res.Dataset)
+			df, err := repo.LoadData(r, res.Dataset)
 			ExitIfErr(err)
 			save.Data = df
 		}
@@ -129,12 +148,31 @@ collaboration are in the works. Sit tight sportsfans.`,
 	},
 }
 
+// saveWorkingDir saves changes in a linked working directory
+func saveWorkingDir(dir string) {
+	req := core.NewDatasetRequests(getRepo(false), nil)
+	res := &repo.DatasetRef{}
+	err := req.SaveWorkingDir(&core.SaveWorkingDirParams{
+		Dir:       dir,
+		Title:     saveTitle,
+		Message:   saveMessage,
+		CoAuthors: saveCoAuthors,
+	}, res)
+	ExitIfErr(err)
+	printSuccess("dataset saved: %s", res)
+}
+
 func init() {
 	saveCmd.Flags().StringVarP(&saveDataFile, "data", "", "", "data file that forms the dataset")
 	saveCmd.Flags().StringVarP(&saveMetaFile, "meta", "", "", "metadata.json file")
 	saveCmd.Flags().StringVarP(&saveStructureFile, "structure", "", "", "structure.json file")
 	saveCmd.Flags().StringVarP(&saveTitle, "title", "t", "", "title of commit message for save")
 	saveCmd.Flags().StringVarP(&saveMessage, "message", "m", "", "commit message for save")
+	saveCmd.Flags().StringSliceVarP(&saveCoAuthors, "co-author", "", nil, "peername of a co-author of this version, repeat for more than one")
+	saveCmd.Flags().BoolVarP(&savePrivate, "private", "", false, "hide the dataset from other peers")
+	saveCmd.Flags().BoolVarP(&saveEncrypt, "encrypt", "", false, "encrypt dataset data & components with a key only this peer holds")
+	saveCmd.Flags().BoolVarP(&saveChunked, "chunked", "", false, "store data in chunks that later versions can share")
+	saveCmd.Flags().StringVarP(&saveCompression, "compression", "", "", "compress stored data, one of gzip, zstd or none. defaults to the compression of the previous version")
 	saveCmd.Flags().BoolVarP(&saveShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	RootCmd.AddCommand(saveCmd)
 }
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareCmdPublic  bool
+	shareCmdPrivate bool
+	shareCmdWith    []string
+	shareCmdRevoke  []string
+)
+
+// shareCmd represents the share command
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "control which peers can see a dataset",
+	Long: `
+Share sets the visibility of a dataset. Public datasets are listed & 
+searchable by all connected peers. Private datasets are hidden from other 
+peers, unless they're shared with specific peer IDs using --with. Visibility 
+applies to all versions of a dataset. Without flags share shows the current 
+visibility of a dataset.`,
+	Example: `  hide b5/comics from other peers:
+  $ qri share --private b5/comics
+
+  share b5/comics with a single peer:
+  $ qri share --with QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt b5/comics
+
+  make b5/comics public again:
+  $ qri share --public b5/comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference to share"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.ShareParams{
+			Ref:     ref,
+			Public:  shareCmdPublic,
+			Private: shareCmdPrivate,
+			With:    shareCmdWith,
+			Revoke:  shareCmdRevoke,
+		}
+		res := &repo.Visibility{}
+		err = req.Share(p, res)
+		ExitIfErr(err)
+
+		if len(res.SharedWith) > 0 {
+			printSuccess("%s is %s with: %s", ref, res.String(), strings.Join(res.SharedWith, ", "))
+			return
+		}
+		printSuccess("%s is %s", ref, res.String())
+	},
+}
+
+func init() {
+	shareCmd.Flags().BoolVarP(&shareCmdPublic, "public", "", false, "make the dataset visible to all peers")
+	shareCmd.Flags().BoolVarP(&shareCmdPrivate, "private", "", false, "hide the dataset from all peers, removing shares")
+	shareCmd.Flags().StringSliceVarP(&shareCmdWith, "with", "", nil, "id of a peer to share the dataset with, repeat for more than one")
+	shareCmd.Flags().StringSliceVarP(&shareCmdRevoke, "revoke", "", nil, "id of a peer to stop sharing the dataset with")
+	RootCmd.AddCommand(shareCmd)
+}
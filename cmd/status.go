@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/qri-io/qri/core"
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show changes in a linked working directory",
+	Long: `
+Status compares the files in a directory created with qri checkout to the 
+latest version of the dataset it's linked to, listing which components have 
+changed. Status checks the current directory unless another is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := os.Getwd()
+		ExitIfErr(err)
+		if len(args) > 0 {
+			dir, err = filepath.Abs(args[0])
+			ExitIfErr(err)
+		}
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		link, err := core.ReadWorkingDirLink(dir)
+		ExitIfErr(err)
+
+		res := []core.ComponentStatus{}
+		err = req.Status(&dir, &res)
+		ExitIfErr(err)
+
+		printInfo("linked to %s/%s", link.Ref.Peername, link.Ref.Name)
+		clean := true
+		for _, s := range res {
+			if s.Status == core.StatusUnmodified {
+				continue
+			}
+			printWarning("  %s: %s", s.Status, s.Filename)
+			clean = false
+		}
+		if clean {
+			printSuccess("no changes")
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
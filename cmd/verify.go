@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmdHistory bool
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "check dataset integrity",
+	Long: `
+Verify recomputes the checksum, length and entry count of a dataset's data and 
+compares them to the values recorded in the dataset structure, reporting any 
+component that doesn't match. Use --history to check every version of a 
+dataset.`,
+	Example: `  verify the latest version of b5/comics:
+  $ qri verify b5/comics
+
+  verify every version of b5/comics:
+  $ qri verify --history b5/comics`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please specify a dataset reference to verify"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.VerifyParams{Ref: ref, History: verifyCmdHistory}
+		res := []core.VerifyResult{}
+		err = req.Verify(p, &res)
+		ExitIfErr(err)
+
+		failed := 0
+		for _, vr := range res {
+			if vr.OK() {
+				printSuccess("%s: ok", vr.Path)
+				continue
+			}
+			failed++
+			printWarning("%s:", vr.Path)
+			for _, problem := range vr.Problems {
+				printWarning("  %s", problem)
+			}
+		}
+
+		if failed > 0 {
+			ErrExit(fmt.Errorf("%d of %d versions failed verification", failed, len(res)))
+		}
+	},
+}
+
+func init() {
+	verifyCmd.Flags().BoolVarP(&verifyCmdHistory, "history", "", false, "verify all previous versions")
+	RootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchDebounce time.Duration
+	watchStrict   bool
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "save a new dataset version whenever a file changes",
+	Long: `
+Watch keeps an eye on a data file, saving a new version of a dataset each time
+the file changes. Saves wait for changes to settle, and are skipped if the 
+file content is the same as the last version. Each version gets a commit title
+& a summary of rows added & removed. With --strict, data that doesn't 
+validate against the dataset’s schema isn't saved.
+
+Watch runs until it's stopped with ctrl+c. To watch files in the background 
+use qri connect --watch.`,
+	Example: `  save b5/comics every time comics.csv changes:
+  $ qri watch b5/comics comics.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			ErrExit(fmt.Errorf("please specify a dataset reference and a file to watch"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		done := make(chan bool)
+		go func() {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt)
+			<-sig
+			close(done)
+		}()
+
+		printInfo("watching %s for changes", args[1])
+		err = req.Watch(&core.WatchParams{
+			Ref:      ref,
+			Path:     args[1],
+			Debounce: watchDebounce,
+			Strict:   watchStrict,
+		}, done, printWatchEvent)
+		ExitIfErr(err)
+	},
+}
+
+// watchFiles starts watching files for qri connect. each watch is a
+// "ref=path" pair
+func watchFiles(r repo.Repo, watches []string) error {
+	for _, w := range watches {
+		parts := strings.SplitN(w, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid watch '%s', watches take the form dataset_reference=file_path", w)
+		}
+		ref, err := repo.ParseDatasetRef(parts[0])
+		if err != nil {
+			return err
+		}
+
+		p := &core.WatchParams{Ref: ref, Path: parts[1], Debounce: watchDebounce, Strict: watchStrict}
+		go func() {
+			req := core.NewDatasetRequests(r, nil)
+			if err := req.Watch(p, nil, printWatchEvent); err != nil {
+				printErr(fmt.Errorf("error watching %s: %s", p.Path, err.Error()))
+			}
+		}()
+		printInfo("watching %s for changes to %s", parts[1], ref)
+	}
+	return nil
+}
+
+func printWatchEvent(e core.WatchEvent) {
+	switch {
+	case e.Err != nil:
+		printErr(e.Err)
+	case e.Skipped != "":
+		printWarning("skipped save: %s", e.Skipped)
+	default:
+		printSuccess("saved %s: %s", e.Ref, e.Ref.Dataset.Commit.Message)
+	}
+}
+
+func init() {
+	watchCmd.Flags().DurationVarP(&watchDebounce, "debounce", "", core.DefaultWatchDebounce, "time to wait for changes to settle before saving")
+	watchCmd.Flags().BoolVarP(&watchStrict, "strict", "", false, "skip saving data that doesn't validate against the dataset's schema")
+	RootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+)
+
+// coAuthorTrailer prefixes commit message lines that name co-authors,
+// following the git convention for co-authored commits
+const coAuthorTrailer = "Co-authored-by: "
+
+// setCommitAuthor records the repo profile as the author of a commit,
+// stamping it with the current time. Commit authors hold the peer id as
+// ID and peername as Fullname. coAuthors are peernames added as message
+// trailers
+func setCommitAuthor(r repo.Repo, c *dataset.Commit, coAuthors []string) error {
+	pro, err := r.Profile()
+	if err != nil {
+		return fmt.Errorf("error getting profile: %s", err.Error())
+	}
+
+	c.Author = &dataset.User{
+		ID:       pro.ID,
+		Fullname: pro.Peername,
+		Email:    pro.Email,
+	}
+	c.Timestamp = time.Now()
+
+	if len(coAuthors) > 0 {
+		lines := make([]string, len(coAuthors))
+		for i, name := range coAuthors {
+			lines[i] = coAuthorTrailer + name
+		}
+		c.Message = strings.TrimSpace(c.Message + "\n\n" + strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// CommitAuthors lists the author & co-authors of a commit. The author is
+// listed first, by peername if known, otherwise peer id
+func CommitAuthors(c *dataset.Commit) (authors []string) {
+	if c == nil {
+		return nil
+	}
+	if c.Author != nil {
+		if c.Author.Fullname != "" {
+			authors = append(authors, c.Author.Fullname)
+		} else if c.Author.ID != "" {
+			authors = append(authors, c.Author.ID)
+		}
+	}
+	for _, line := range strings.Split(c.Message, "\n") {
+		if name := strings.TrimPrefix(strings.TrimSpace(line), coAuthorTrailer); name != strings.TrimSpace(line) && name != "" {
+			authors = append(authors, name)
+		}
+	}
+	return
+}
+
+// authoredBy checks if author is the peer id, peername or a co-author of
+// a dataset commit
+func authoredBy(ds *dataset.Dataset, author string) bool {
+	if ds == nil || ds.Commit == nil {
+		return false
+	}
+	if ds.Commit.Author != nil && ds.Commit.Author.ID == author {
+		return true
+	}
+	for _, name := range CommitAuthors(ds.Commit) {
+		if name == author {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestCommitAuthors(t *testing.T) {
+	cases := []struct {
+		commit *dataset.Commit
+		expect []string
+	}{
+		{nil, nil},
+		{&dataset.Commit{}, nil},
+		{&dataset.Commit{Author: &dataset.User{ID: "QmId"}}, []string{"QmId"}},
+		{&dataset.Commit{Author: &dataset.User{ID: "QmId", Fullname: "b5"}}, []string{"b5"}},
+		{&dataset.Commit{Author: &dataset.User{Fullname: "b5"}, Message: "fix typos\n\nCo-authored-by: ramfox\nCo-authored-by: osterbit"}, []string{"b5", "ramfox", "osterbit"}},
+	}
+
+	for i, c := range cases {
+		got := CommitAuthors(c.commit)
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d length mismatch. expected: %v, got: %v", i, c.expect, got)
+			continue
+		}
+		for j, name := range c.expect {
+			if got[j] != name {
+				t.Errorf("case %d author %d mismatch. expected: %s, got: %s", i, j, name, got[j])
+			}
+		}
+	}
+}
+
+func TestCommitAuthorship(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	ref := &repo.DatasetRef{}
+	p := &InitParams{
+		Peername:     "me",
+		Name:         "authored",
+		DataFilename: "authored.csv",
+		Data:         memfs.NewMemfileBytes("authored.csv", []byte("a,b\n1,2\n")),
+		CoAuthors:    []string{"ramfox"},
+	}
+	if err := req.Init(p, ref); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	commit := ref.Dataset.Commit
+	if commit.Author == nil || commit.Author.Fullname != "peer" || commit.Author.ID == "" {
+		t.Errorf("expected commit author to be recorded from profile, got: %v", commit.Author)
+	}
+	if commit.Timestamp.IsZero() {
+		t.Errorf("expected commit to have a timestamp")
+	}
+
+	hr := NewHistoryRequests(mr, nil)
+	cases := []struct {
+		author string
+		count  int
+	}{
+		{"", 1},
+		{"peer", 1},
+		{"ramfox", 1},
+		{commit.Author.ID, 1},
+		{"nobody", 0},
+	}
+	for i, c := range cases {
+		log := []repo.DatasetRef{}
+		lp := &LogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "authored"}, Author: c.author}
+		if err := hr.Log(lp, &log); err != nil {
+			t.Errorf("case %d error getting log: %s", i, err.Error())
+			continue
+		}
+		if len(log) != c.count {
+			t.Errorf("case %d log length mismatch. expected: %d, got: %d", i, c.count, len(log))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/query"
+	"github.com/qri-io/qri/repo"
+)
+
+// BlameParams defines parameters for row-level blame
+type BlameParams struct {
+	Ref repo.DatasetRef
+	// Key is the column that identifies rows across versions. Without a
+	// key rows are matched by position
+	Key string
+	// Offset & Limit select a range of rows to blame, a limit of zero
+	// blames all rows from offset
+	Offset, Limit int
+}
+
+// BlameRow annotates a row with the version that last changed it
+type BlameRow struct {
+	// Row is the index of the row in the current version
+	Row       int           `json:"row"`
+	Values    []interface{} `json:"values"`
+	Path      string        `json:"path"`
+	Title     string        `json:"title"`
+	Author    string        `json:"author"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// BlameResult is the result of a blame request
+type BlameResult struct {
+	Columns []string   `json:"columns"`
+	Rows    []BlameRow `json:"rows"`
+}
+
+// Blame walks the history of a dataset, finding the version that last
+// changed each row of the current version. A row is unchanged in a previous
+// version if that version has a row with the same key & values
+func (r *DatasetRequests) Blame(p *BlameParams, res *BlameResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Blame", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	if p.Ref.Path == "" {
+		ref, err := r.repo.GetRef(p.Ref)
+		if err != nil {
+			return fmt.Errorf("error getting dataset: %s", err.Error())
+		}
+		p.Ref.Path = ref.Path
+	}
+
+	t, err := loadQueryTable(r.repo, datastore.NewKey(p.Ref.Path))
+	if err != nil {
+		return fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	// pad short rows so they compare equal to aligned previous versions
+	current := alignTable(t, t.Columns)
+	keyIdx := -1
+	if p.Key != "" {
+		if keyIdx = indexOf(current.Columns, p.Key); keyIdx < 0 {
+			return fmt.Errorf("key column '%s' not found", p.Key)
+		}
+	}
+
+	start, end := p.Offset, len(current.Rows)
+	if start > end {
+		start = end
+	}
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+	}
+
+	result := BlameResult{Columns: current.Columns, Rows: make([]BlameRow, end-start)}
+	// pending maps row keys to the result rows still being traced, with the
+	// encoded values they must match to be unchanged
+	pending := map[string]int{}
+	values := map[string]string{}
+	keys := blameKeys(current, keyIdx)
+	for i := start; i < end; i++ {
+		result.Rows[i-start] = BlameRow{Row: i, Values: current.Rows[i]}
+		pending[keys[i]] = i - start
+		values[keys[i]] = rowString(current.Rows[i])
+	}
+
+	path := p.Ref.Path
+	for len(pending) > 0 && path != "" && path != "/" {
+		ds, err := repo.LoadDataset(r.repo, datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error loading dataset '%s': %s", path, err.Error())
+		}
+		t, err := loadQueryTable(r.repo, datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error loading data for '%s': %s", path, err.Error())
+		}
+
+		// align this version's rows to the current columns, so versions
+		// with different column orders compare equal
+		aligned := alignTable(t, current.Columns)
+		versionKeys := blameKeys(aligned, keyIdx)
+		found := map[string]bool{}
+		for i, row := range aligned.Rows {
+			key := versionKeys[i]
+			if _, ok := pending[key]; ok && rowString(row) == values[key] {
+				found[key] = true
+			}
+		}
+
+		// rows unchanged in this version are attributed to it, rows that
+		// changed or are missing were last changed by the version after it
+		for key, idx := range pending {
+			if !found[key] {
+				delete(pending, key)
+				continue
+			}
+			row := &result.Rows[idx]
+			row.Path = path
+			if ds.Commit != nil {
+				row.Title = ds.Commit.Title
+				row.Author = strings.Join(CommitAuthors(ds.Commit), ", ")
+				row.Timestamp = ds.Commit.Timestamp
+			}
+		}
+		path = ds.PreviousPath
+	}
+
+	*res = result
+	return nil
+}
+
+// blameKeys gives an identifying key for each row of a table. rows with
+// duplicate keys are numbered in order of occurrence
+func blameKeys(t *query.Table, keyIdx int) []string {
+	keys := make([]string, len(t.Rows))
+	seen := map[string]int{}
+	for i, row := range t.Rows {
+		if keyIdx < 0 {
+			keys[i] = strconv.Itoa(i)
+			continue
+		}
+		var v interface{}
+		if keyIdx < len(row) {
+			v = row[keyIdx]
+		}
+		key := rowString([]interface{}{v})
+		keys[i] = key + "#" + strconv.Itoa(seen[key])
+		seen[key]++
+	}
+	return keys
+}
+
+// alignTable reorders table rows to match a set of columns. columns the
+// table doesn't have are null
+func alignTable(t *query.Table, columns []string) *query.Table {
+	idx := make([]int, len(columns))
+	for i, col := range columns {
+		idx[i] = indexOf(t.Columns, col)
+	}
+	aligned := &query.Table{Columns: columns, Rows: make([][]interface{}, len(t.Rows))}
+	for i, row := range t.Rows {
+		a := make([]interface{}, len(columns))
+		for j, k := range idx {
+			if k >= 0 && k < len(row) {
+				a[j] = row[k]
+			}
+		}
+		aligned.Rows[i] = a
+	}
+	return aligned
+}
+
+func rowString(row []interface{}) string {
+	data, _ := json.Marshal(row)
+	return string(data)
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsBlame(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	first := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n"),
+	}, first); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	second := &repo.DatasetRef{}
+	if err := req.Save(&SaveParams{
+		Prev:         repo.DatasetRef{Peername: "me", Name: "letters"},
+		Changes:      &dataset.Dataset{Commit: &dataset.Commit{Title: "change b, add d"}},
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,c\n3,d\n"),
+	}, second); err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p     *BlameParams
+		paths []string
+		err   string
+	}{
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "not_a_dataset"}}, nil, "error getting dataset: repo: not found"},
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Key: "nope"}, nil, "key column 'nope' not found"},
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Key: "id"}, []string{first.Path, second.Path, second.Path}, ""},
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}}, []string{first.Path, second.Path, second.Path}, ""},
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Key: "id", Offset: 1, Limit: 1}, []string{second.Path}, ""},
+		{&BlameParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Offset: 10}, []string{}, ""},
+	}
+
+	for i, c := range cases {
+		got := &BlameResult{}
+		err := req.Blame(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if len(got.Rows) != len(c.paths) {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, len(c.paths), len(got.Rows))
+			continue
+		}
+		for j, row := range got.Rows {
+			if row.Path != c.paths[j] {
+				t.Errorf("case %d row %d path mismatch. expected: %s, got: %s", i, j, c.paths[j], row.Path)
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultBulkWorkers is the number of files imported concurrently when
+// BulkInitParams doesn't specify a worker count
+const DefaultBulkWorkers = 4
+
+// Manifest lists data files to import in bulk. Manifests are YAML (or JSON)
+// documents in the form:
+//     peername: me
+//     datasets:
+//       - data: cities.csv
+//         name: cities
+//         meta: cities_meta.json
+//         structure: cities_structure.json
+// only data is required for each entry. relative paths are resolved against
+// the directory containing the manifest
+type Manifest struct {
+	Peername string           `json:"peername,omitempty" yaml:"peername,omitempty"`
+	Datasets []*ManifestEntry `json:"datasets" yaml:"datasets"`
+}
+
+// ManifestEntry describes a single data file to import, with optional
+// name, metadata & structure overrides
+type ManifestEntry struct {
+	Data      string `json:"data" yaml:"data"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	Meta      string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Structure string `json:"structure,omitempty" yaml:"structure,omitempty"`
+}
+
+// ReadManifest loads a manifest file, resolving all entry paths
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %s", err.Error())
+	}
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %s", err.Error())
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	for i, e := range m.Datasets {
+		if e == nil || e.Data == "" {
+			return nil, fmt.Errorf("manifest entry %d: data is required", i)
+		}
+		e.Data = resolve(e.Data)
+		e.Meta = resolve(e.Meta)
+		e.Structure = resolve(e.Structure)
+	}
+	return m, nil
+}
+
+// DirManifest creates a manifest that lists every data file in a directory.
+// files with unsupported extensions & hidden files are skipped
+func DirManifest(dir string) (*Manifest, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %s", err.Error())
+	}
+
+	m := &Manifest{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, err := dataset.ParseDataFormatString(strings.TrimPrefix(filepath.Ext(name), ".")); err != nil {
+			continue
+		}
+		m.Datasets = append(m.Datasets, &ManifestEntry{Data: filepath.Join(dir, name)})
+	}
+	return m, nil
+}
+
+// BulkInitParams defines parameters for importing many data files
+type BulkInitParams struct {
+	Manifest *Manifest
+	// Workers is the number of files to import concurrently
+	Workers int
+	// Journal is the path to a file that records completed imports. Entries
+	// listed in the journal are skipped, so interrupted imports can be
+	// resumed by running the same import again. optional.
+	Journal string
+}
+
+// BulkInitResult reports the outcome of importing a single manifest entry
+type BulkInitResult struct {
+	Data    string
+	Ref     repo.DatasetRef
+	Skipped bool
+	Error   string
+}
+
+// BulkInit creates datasets for each entry in a manifest. Files are read &
+// checked in parallel, writes to the repo happen one at a time. A failed
+// entry doesn't stop the import, check each result for errors
+func (r *DatasetRequests) BulkInit(p *BulkInitParams, res *[]BulkInitResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.BulkInit", p, res)
+	}
+
+	if p.Manifest == nil {
+		return fmt.Errorf("manifest is required")
+	}
+	workers := p.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+
+	done, err := readBulkJournal(p.Journal)
+	if err != nil {
+		return err
+	}
+	var journal *os.File
+	if p.Journal != "" {
+		if journal, err = os.OpenFile(p.Journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+			return fmt.Errorf("error opening journal: %s", err.Error())
+		}
+		defer journal.Close()
+	}
+
+	// walk the repo graph once up front instead of once per file
+	paths := map[string]bool{}
+	nodes, err := r.repo.Graph()
+	if err != nil && !strings.Contains(err.Error(), repo.ErrRepoEmpty.Error()) {
+		return fmt.Errorf("error getting repo graph: %s", err.Error())
+	}
+	for path := range nodes {
+		paths[path] = true
+	}
+
+	var (
+		results = make([]BulkInitResult, len(p.Manifest.Datasets))
+		entries = make(chan int)
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range entries {
+				e := p.Manifest.Datasets[i]
+				result := BulkInitResult{Data: e.Data}
+
+				if ref, ok := done[e.Data]; ok {
+					result.Ref = ref
+					result.Skipped = true
+					results[i] = result
+					continue
+				}
+
+				prep, err := r.prepareManifestEntry(p.Manifest.Peername, e)
+				if err != nil {
+					result.Error = err.Error()
+					results[i] = result
+					continue
+				}
+
+				mu.Lock()
+				var datakey datastore.Key
+				err = r.createInit(prep, func(key datastore.Key) (bool, error) {
+					datakey = key
+					return paths[key.String()], nil
+				}, &result.Ref)
+				if err == nil {
+					paths[datakey.String()] = true
+					err = writeBulkJournal(journal, e.Data, result.Ref)
+				}
+				mu.Unlock()
+
+				if err != nil {
+					result.Error = err.Error()
+				}
+				result.Ref.Dataset = nil
+				results[i] = result
+			}
+		}()
+	}
+
+	for i := range p.Manifest.Datasets {
+		entries <- i
+	}
+	close(entries)
+	wg.Wait()
+
+	*res = results
+	return nil
+}
+
+// prepareManifestEntry opens the files listed in a manifest entry & prepares
+// a dataset from them
+func (r *DatasetRequests) prepareManifestEntry(peername string, e *ManifestEntry) (*initDataset, error) {
+	if peername == "" {
+		peername = "me"
+	}
+	p := &InitParams{
+		Peername:     peername,
+		Name:         e.Name,
+		DataFilename: filepath.Base(e.Data),
+	}
+
+	data, err := os.Open(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error opening data file: %s", err.Error())
+	}
+	defer data.Close()
+	p.Data = data
+
+	if e.Meta != "" {
+		meta, err := os.Open(e.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("error opening metadata file: %s", err.Error())
+		}
+		defer meta.Close()
+		p.MetadataFilename = filepath.Base(e.Meta)
+		p.Metadata = meta
+	}
+	if e.Structure != "" {
+		st, err := os.Open(e.Structure)
+		if err != nil {
+			return nil, fmt.Errorf("error opening structure file: %s", err.Error())
+		}
+		defer st.Close()
+		p.StructureFilename = filepath.Base(e.Structure)
+		p.Structure = st
+	}
+
+	return r.prepareInit(p)
+}
+
+// bulkJournalEntry is a single line in a bulk import journal
+type bulkJournalEntry struct {
+	Data string `json:"data"`
+	Ref  string `json:"ref"`
+}
+
+// readBulkJournal reads completed imports from a journal file, keyed by
+// data file path. a missing journal isn't an error
+func readBulkJournal(path string) (map[string]repo.DatasetRef, error) {
+	done := map[string]repo.DatasetRef{}
+	if path == "" {
+		return done, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error opening journal: %s", err.Error())
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		e := bulkJournalEntry{}
+		// a partially-written last line from an interrupted import is ignored
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		ref, err := repo.ParseDatasetRef(e.Ref)
+		if err != nil {
+			continue
+		}
+		done[e.Data] = ref
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal: %s", err.Error())
+	}
+	return done, nil
+}
+
+// writeBulkJournal records a completed import
+func writeBulkJournal(f *os.File, data string, ref repo.DatasetRef) error {
+	if f == nil {
+		return nil
+	}
+	line, err := json.Marshal(bulkJournalEntry{Data: data, Ref: ref.String()})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing journal: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsBulkInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_bulk_init")
+	if err != nil {
+		t.Errorf("error creating temp dir: %s", err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"animals.csv":      "name,legs\ncat,4\nbird,2\n",
+		"animals_copy.csv": "name,legs\ncat,4\nbird,2\n",
+		"empty.csv":        "",
+		"plants.csv":       "name,height\noak,20\nfern,1\n",
+		"notes.txt":        "not a data file",
+		"manifest.yaml":    "datasets:\n  - data: plants.csv\n    name: my_plants\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), os.ModePerm); err != nil {
+			t.Errorf("error writing test file: %s", err.Error())
+			return
+		}
+	}
+
+	m, err := DirManifest(dir)
+	if err != nil {
+		t.Errorf("error creating dir manifest: %s", err.Error())
+		return
+	}
+	if len(m.Datasets) != 4 {
+		t.Errorf("expected dir manifest to have 4 entries, got: %d", len(m.Datasets))
+		return
+	}
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	journal := filepath.Join(dir, ".journal")
+
+	// a single worker keeps the duplicate check deterministic
+	p := &BulkInitParams{Manifest: m, Workers: 1, Journal: journal}
+	res := []BulkInitResult{}
+	if err := req.BulkInit(p, &res); err != nil {
+		t.Errorf("error running bulk init: %s", err.Error())
+		return
+	}
+
+	expect := []struct {
+		failed, skipped bool
+	}{
+		{false, false},
+		{true, false},
+		{true, false},
+		{false, false},
+	}
+	for i, e := range expect {
+		r := res[i]
+		if (r.Error != "") != e.failed {
+			t.Errorf("case %d (%s) failure mismatch. expected: %t, got error: '%s'", i, filepath.Base(r.Data), e.failed, r.Error)
+		}
+		if r.Skipped != e.skipped {
+			t.Errorf("case %d (%s) skipped mismatch. expected: %t, got: %t", i, filepath.Base(r.Data), e.skipped, r.Skipped)
+		}
+	}
+	if res[1].Error != "this data already exists" {
+		t.Errorf("expected duplicate data error, got: '%s'", res[1].Error)
+	}
+
+	// re-running resumes from the journal, skipping completed files
+	res = []BulkInitResult{}
+	if err := req.BulkInit(p, &res); err != nil {
+		t.Errorf("error resuming bulk init: %s", err.Error())
+		return
+	}
+	for _, i := range []int{0, 3} {
+		if !res[i].Skipped {
+			t.Errorf("expected %s to be skipped on resume", filepath.Base(res[i].Data))
+		}
+		if res[i].Ref.Path == "" {
+			t.Errorf("expected skipped result %s to have a path", filepath.Base(res[i].Data))
+		}
+	}
+
+	m, err = ReadManifest(filepath.Join(dir, "manifest.yaml"))
+	if err != nil {
+		t.Errorf("error reading manifest: %s", err.Error())
+		return
+	}
+	if m.Datasets[0].Data != filepath.Join(dir, "plants.csv") {
+		t.Errorf("expected manifest data path to be resolved, got: %s", m.Datasets[0].Data)
+	}
+
+	mr, err = testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req = NewDatasetRequests(mr, nil)
+	res = []BulkInitResult{}
+	if err := req.BulkInit(&BulkInitParams{Manifest: m}, &res); err != nil {
+		t.Errorf("error running manifest bulk init: %s", err.Error())
+		return
+	}
+	if res[0].Error != "" {
+		t.Errorf("unexpected manifest entry error: %s", res[0].Error)
+	}
+	if res[0].Ref.Name != "my_plants" {
+		t.Errorf("expected manifest name override 'my_plants', got: '%s'", res[0].Ref.Name)
+	}
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/catalog"
+	"github.com/qri-io/qri/repo"
+)
+
+// CatalogParams defines parameters for catalog entries
+type CatalogParams struct {
+	// Ref is the dataset to describe. An empty ref gives a catalog feed of
+	// all datasets in the repo
+	Ref    repo.DatasetRef
+	Format catalog.Format
+	// BaseURL is the address of an api server datasets can be reached at,
+	// used to add landing page & download urls to entries. optional
+	BaseURL string
+}
+
+// Catalog renders dataset metadata, structure & commit details as a data
+// catalog entry
+func (r *DatasetRequests) Catalog(p *CatalogParams, res *[]byte) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Catalog", p, res)
+	}
+
+	if p.Ref.IsEmpty() {
+		return r.catalogFeed(p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	// fill in missing names & paths from the repo
+	if got, err := r.repo.GetRef(p.Ref); err == nil {
+		p.Ref = got
+	} else if p.Ref.Path == "" {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+
+	ref := repo.DatasetRef{}
+	if err := r.Get(&p.Ref, &ref); err != nil {
+		return err
+	}
+
+	data, err := catalog.Encode(p.Format, catalogEntry(ref, p.BaseURL))
+	if err != nil {
+		return err
+	}
+	*res = data
+	return nil
+}
+
+// catalogFeed renders all public datasets in the repo as a catalog. feeds
+// are published, so private, shared & encrypted datasets are never listed
+func (r *DatasetRequests) catalogFeed(p *CatalogParams, res *[]byte) error {
+	count, err := r.repo.RefCount()
+	if err != nil {
+		return fmt.Errorf("error getting reference count: %s", err.Error())
+	}
+	refs, err := r.repo.References(count, 0)
+	if err != nil {
+		return fmt.Errorf("error getting references: %s", err.Error())
+	}
+	if refs, err = repo.VisibleRefs(r.repo, refs, ""); err != nil {
+		return fmt.Errorf("error checking visibility: %s", err.Error())
+	}
+
+	entries := make([]*catalog.Entry, 0, len(refs))
+	for _, ref := range refs {
+		path := datastore.NewKey(ref.Path)
+		encrypted, err := repo.IsEncrypted(r.repo.Store(), path)
+		if err != nil {
+			return fmt.Errorf("error loading dataset %s: %s", ref, err.Error())
+		}
+		if encrypted {
+			continue
+		}
+		ref.Dataset, err = repo.LoadDataset(r.repo, path)
+		if err != nil {
+			return fmt.Errorf("error loading dataset %s: %s", ref, err.Error())
+		}
+		entries = append(entries, catalogEntry(ref, p.BaseURL))
+	}
+
+	title := "qri datasets"
+	if pro, err := r.repo.Profile(); err == nil && pro.Peername != "" {
+		title = pro.Peername + "'s datasets"
+	}
+
+	data, err := catalog.EncodeFeed(p.Format, title, entries)
+	if err != nil {
+		return err
+	}
+	*res = data
+	return nil
+}
+
+// catalogEntry describes a dataset reference as a catalog entry
+func catalogEntry(ref repo.DatasetRef, baseURL string) *catalog.Entry {
+	e := &catalog.Entry{Path: ref.Path}
+	if ref.Name != "" {
+		e.Name = ref.Peername + "/" + ref.Name
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL != "" && e.Name != "" {
+		e.URL = baseURL + "/" + e.Name
+		e.DownloadURL = baseURL + "/data/" + e.Name
+	}
+
+	ds := ref.Dataset
+	if ds == nil {
+		return e
+	}
+	if md := ds.Meta; md != nil {
+		e.Title = md.Title
+		e.Description = md.Description
+		e.Keywords = md.Keywords
+		e.Identifier = md.Identifier
+		e.Version = md.Version
+		if md.DownloadPath != "" {
+			e.DownloadURL = md.DownloadPath
+		}
+		if md.License != nil {
+			e.License = md.License.Type
+			e.LicenseURL = md.License.URL
+		}
+	}
+	if ds.Commit != nil {
+		e.Authors = CommitAuthors(ds.Commit)
+		e.Modified = ds.Commit.Timestamp
+	}
+	if st := ds.Structure; st != nil {
+		e.Format = st.Format.String()
+		e.Size = st.Length
+		e.Entries = st.Entries
+		e.Checksum = st.Checksum
+	}
+	return e
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/catalog"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsCatalog(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		p      *CatalogParams
+		expect []string
+		err    string
+	}{
+		{&CatalogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}, Format: catalog.DCAT}, nil, "error getting dataset: repo: not found"},
+		{&CatalogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, Format: catalog.Format("marc")}, nil, "unsupported catalog format: 'marc'"},
+		{&CatalogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, Format: catalog.DCAT}, []string{`"dct:title": "example movie data"`}, ""},
+		{&CatalogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, Format: catalog.SchemaOrg, BaseURL: "http://localhost:2503/"}, []string{`"url": "http://localhost:2503/peer/movies"`, `"contentUrl": "http://localhost:2503/data/peer/movies"`}, ""},
+		{&CatalogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, Format: catalog.BibTeX}, []string{"@misc{peer_movies"}, ""},
+		{&CatalogParams{Format: catalog.DataCite}, []string{"example movie data", "peer/cities", "peer/counter"}, ""},
+	}
+
+	for i, c := range cases {
+		got := []byte{}
+		err := req.Catalog(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		for _, s := range c.expect {
+			if !strings.Contains(string(got), s) {
+				t.Errorf("case %d expected output to contain %s, got:\n%s", i, s, string(got))
+			}
+		}
+	}
+}
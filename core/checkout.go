@@ -0,0 +1,407 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"gopkg.in/yaml.v2"
+)
+
+// LinkFilename is the hidden file that links a working directory to a
+// dataset
+const LinkFilename = ".qri-ref"
+
+// WorkingDirDatasetFilename holds dataset fields that aren't in a component
+// file of their own
+const WorkingDirDatasetFilename = "dataset.yaml"
+
+const (
+	// StatusUnmodified is a component that matches the dataset head
+	StatusUnmodified = "unmodified"
+	// StatusModified is a component that differs from the dataset head
+	StatusModified = "modified"
+	// StatusAdded is a component the dataset head doesn't have
+	StatusAdded = "added"
+	// StatusRemoved is a component file that's been deleted
+	StatusRemoved = "removed"
+)
+
+// WorkingDirLink is the contents of a working directory's link file
+type WorkingDirLink struct {
+	Ref repo.DatasetRef `json:"ref"`
+	// DataFilename is the name of the checked out data file
+	DataFilename string `json:"dataFilename"`
+}
+
+// ReadWorkingDirLink reads the link file of a working directory
+func ReadWorkingDirLink(dir string) (*WorkingDirLink, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, LinkFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s isn't a linked dataset directory, use qri checkout to create one", dir)
+		}
+		return nil, err
+	}
+	link := &WorkingDirLink{}
+	if err := json.Unmarshal(data, link); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", LinkFilename, err.Error())
+	}
+	return link, nil
+}
+
+// CheckoutParams defines parameters for Checkout
+type CheckoutParams struct {
+	Ref repo.DatasetRef
+	// Dir is the directory to write to. it's created if it doesn't exist
+	Dir string
+}
+
+// Checkout writes a dataset's components & data into a directory, linking
+// the directory to the dataset. Changes made to files in the directory can
+// be saved with SaveWorkingDir
+func (r *DatasetRequests) Checkout(p *CheckoutParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Checkout", p, res)
+	}
+
+	if p.Dir == "" {
+		return fmt.Errorf("a directory is required")
+	}
+	if _, err := os.Stat(filepath.Join(p.Dir, LinkFilename)); err == nil {
+		return fmt.Errorf("%s is already linked to a dataset", p.Dir)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&p.Ref, ref); err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+
+	files, err := r.componentFiles(ref.Dataset)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory: %s", err.Error())
+	}
+	if err := writeComponentFiles(p.Dir, files); err != nil {
+		return err
+	}
+
+	link := &WorkingDirLink{
+		Ref:          repo.DatasetRef{Peername: ref.Peername, Name: ref.Name, Path: ref.Path},
+		DataFilename: files[len(files)-1].filename,
+	}
+	if err := writeWorkingDirLink(p.Dir, link); err != nil {
+		return err
+	}
+
+	*res = *ref
+	return nil
+}
+
+// ComponentStatus describes a component file of a working directory
+type ComponentStatus struct {
+	Component string `json:"component"`
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+}
+
+// Status compares the files of a working directory to the head of the
+// dataset the directory is linked to
+func (r *DatasetRequests) Status(dir *string, res *[]ComponentStatus) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Status", dir, res)
+	}
+
+	_, _, statuses, err := r.workingDirStatus(*dir)
+	if err != nil {
+		return err
+	}
+	*res = statuses
+	return nil
+}
+
+// SaveWorkingDirParams defines parameters for SaveWorkingDir
+type SaveWorkingDirParams struct {
+	Dir       string
+	Title     string
+	Message   string
+	CoAuthors []string
+}
+
+// SaveWorkingDir saves components changed in a working directory as a new
+// version of the dataset it's linked to
+func (r *DatasetRequests) SaveWorkingDir(p *SaveWorkingDirParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.SaveWorkingDir", p, res)
+	}
+
+	link, head, statuses, err := r.workingDirStatus(p.Dir)
+	if err != nil {
+		return err
+	}
+
+	changes := &dataset.Dataset{}
+	changed := false
+	dataChanged := false
+	for _, s := range statuses {
+		if s.Status == StatusUnmodified {
+			continue
+		}
+		if s.Status == StatusRemoved {
+			return fmt.Errorf("%s was removed, removing components isn't supported", s.Filename)
+		}
+		changed = true
+
+		data, err := ioutil.ReadFile(filepath.Join(p.Dir, s.Filename))
+		if err != nil {
+			return fmt.Errorf("error reading %s: %s", s.Filename, err.Error())
+		}
+		switch s.Component {
+		case "dataset":
+			if changes, err = readDatasetYAML(data); err != nil {
+				return err
+			}
+		case "meta":
+			changes.Meta = &dataset.Meta{}
+			if err := json.Unmarshal(data, changes.Meta); err != nil {
+				return fmt.Errorf("error parsing %s: %s", s.Filename, err.Error())
+			}
+		case "structure":
+			changes.Structure = &dataset.Structure{}
+			if err := json.Unmarshal(data, changes.Structure); err != nil {
+				return fmt.Errorf("error parsing %s: %s", s.Filename, err.Error())
+			}
+		case "data":
+			dataChanged = true
+		}
+	}
+	if !changed {
+		return fmt.Errorf("no changes to save")
+	}
+	changes.Commit = &dataset.Commit{Title: p.Title, Message: p.Message}
+
+	save := &SaveParams{
+		Prev:      repo.DatasetRef{Peername: link.Ref.Peername, Name: link.Ref.Name},
+		Changes:   changes,
+		CoAuthors: p.CoAuthors,
+	}
+	if dataChanged {
+		f, err := os.Open(filepath.Join(p.Dir, link.DataFilename))
+		if err != nil {
+			return fmt.Errorf("error opening data: %s", err.Error())
+		}
+		defer f.Close()
+		save.DataFilename = link.DataFilename
+		save.Data = f
+	} else {
+		// unchanged data is carried over from the head
+		f, err := repo.LoadData(r.repo, head.Dataset)
+		if err != nil {
+			return fmt.Errorf("error loading data: %s", err.Error())
+		}
+		save.Data = f
+	}
+
+	if err := r.Save(save, res); err != nil {
+		return err
+	}
+
+	// saving fills in details like structure checksums, rewrite component
+	// files so the directory matches the new head. data is left alone
+	saved := &repo.DatasetRef{}
+	if err := r.Get(&repo.DatasetRef{Peername: res.Peername, Name: res.Name, Path: res.Path}, saved); err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	files, err := r.componentFiles(saved.Dataset)
+	if err != nil {
+		return err
+	}
+	if err := writeComponentFiles(p.Dir, files[:len(files)-1]); err != nil {
+		return err
+	}
+
+	link.Ref.Path = res.Path
+	return writeWorkingDirLink(p.Dir, link)
+}
+
+// workingDirStatus reads a working directory's link & compares its files
+// to the dataset head
+func (r *DatasetRequests) workingDirStatus(dir string) (*WorkingDirLink, *repo.DatasetRef, []ComponentStatus, error) {
+	link, err := ReadWorkingDirLink(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	head := &repo.DatasetRef{}
+	if err := r.Get(&repo.DatasetRef{Peername: link.Ref.Peername, Name: link.Ref.Name}, head); err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	files, err := r.componentFiles(head.Dataset)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// compare against the data file that was checked out, the head format
+	// may have changed since
+	files[len(files)-1].filename = link.DataFilename
+
+	statuses := make([]ComponentStatus, 0, len(files))
+	for _, f := range files {
+		s := ComponentStatus{Component: f.component, Filename: f.filename}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.filename))
+		switch {
+		case os.IsNotExist(err) && f.data == nil:
+			continue
+		case os.IsNotExist(err):
+			s.Status = StatusRemoved
+		case err != nil:
+			return nil, nil, nil, fmt.Errorf("error reading %s: %s", f.filename, err.Error())
+		case f.data == nil:
+			s.Status = StatusAdded
+		case bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(f.data)):
+			s.Status = StatusUnmodified
+		default:
+			s.Status = StatusModified
+		}
+		statuses = append(statuses, s)
+	}
+	return link, head, statuses, nil
+}
+
+// componentFile is a dataset component as it's written to a working dir
+type componentFile struct {
+	component string
+	filename  string
+	// data is nil for components the dataset doesn't have
+	data []byte
+}
+
+// componentFiles serializes a dataset into working directory files. data
+// is always the last file
+func (r *DatasetRequests) componentFiles(ds *dataset.Dataset) ([]*componentFile, error) {
+	dsyaml, err := datasetYAML(ds)
+	if err != nil {
+		return nil, err
+	}
+	files := []*componentFile{
+		{component: "dataset", filename: WorkingDirDatasetFilename, data: dsyaml},
+		{component: "meta", filename: dsfs.PackageFileMeta.Filename()},
+		{component: "structure", filename: dsfs.PackageFileStructure.Filename()},
+	}
+	if ds.Meta != nil {
+		if files[1].data, err = json.MarshalIndent(ds.Meta, "", "  "); err != nil {
+			return nil, fmt.Errorf("error encoding meta: %s", err.Error())
+		}
+	}
+	if ds.Structure != nil {
+		if files[2].data, err = json.MarshalIndent(ds.Structure, "", "  "); err != nil {
+			return nil, fmt.Errorf("error encoding structure: %s", err.Error())
+		}
+	}
+
+	f, err := repo.LoadData(r.repo, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	format := "csv"
+	if ds.Structure != nil {
+		format = ds.Structure.Format.String()
+	}
+	files = append(files, &componentFile{component: "data", filename: "data." + format, data: data})
+	return files, nil
+}
+
+// datasetYAML encodes the fields of a dataset that don't have a component
+// file of their own. commits are written by saving, not edited
+func datasetYAML(ds *dataset.Dataset) ([]byte, error) {
+	rest := &dataset.Dataset{
+		Abstract:          ds.Abstract,
+		Transform:         ds.Transform,
+		AbstractTransform: ds.AbstractTransform,
+		VisConfig:         ds.VisConfig,
+	}
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	return yaml.Marshal(v)
+}
+
+// readDatasetYAML decodes a dataset written by datasetYAML
+func readDatasetYAML(data []byte) (*dataset.Dataset, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", WorkingDirDatasetFilename, err.Error())
+	}
+	jsondata, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", WorkingDirDatasetFilename, err.Error())
+	}
+	ds := &dataset.Dataset{}
+	if err := json.Unmarshal(jsondata, ds); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", WorkingDirDatasetFilename, err.Error())
+	}
+	return ds, nil
+}
+
+// jsonValue converts the map[interface{}]interface{} values yaml decodes
+// to into values encoding/json can marshal
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range x {
+			m[fmt.Sprintf("%v", k)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range x {
+			x[i] = jsonValue(val)
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+func writeComponentFiles(dir string, files []*componentFile) error {
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, f.filename), f.data, os.ModePerm); err != nil {
+			return fmt.Errorf("error writing %s: %s", f.filename, err.Error())
+		}
+	}
+	return nil
+}
+
+func writeWorkingDirLink(dir string, link *WorkingDirLink) error {
+	data, err := json.MarshalIndent(link, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LinkFilename), data, os.ModePerm); err != nil {
+		return fmt.Errorf("error writing %s: %s", LinkFilename, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsCheckout(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n"),
+	}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	tmp, err := ioutil.TempDir("", "qri_checkout")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "letters")
+
+	ref := &repo.DatasetRef{}
+	if err := req.Checkout(&CheckoutParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Dir: dir}, ref); err != nil {
+		t.Errorf("error checking out dataset: %s", err.Error())
+		return
+	}
+	err = req.Checkout(&CheckoutParams{Ref: repo.DatasetRef{Peername: "me", Name: "letters"}, Dir: dir}, ref)
+	if expect := fmt.Sprintf("%s is already linked to a dataset", dir); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%s'", expect, err)
+	}
+
+	expectStatus := func(label string, expect map[string]string) {
+		statuses := []ComponentStatus{}
+		if err := req.Status(&dir, &statuses); err != nil {
+			t.Errorf("%s: error getting status: %s", label, err.Error())
+			return
+		}
+		got := map[string]string{}
+		for _, s := range statuses {
+			got[s.Component] = s.Status
+		}
+		for component, status := range expect {
+			if got[component] != status {
+				t.Errorf("%s: %s status mismatch. expected: %s, got: %s", label, component, status, got[component])
+			}
+		}
+	}
+	expectStatus("checkout", map[string]string{"dataset": StatusUnmodified, "structure": StatusUnmodified, "data": StatusUnmodified})
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "data.csv"), []byte("id,name\n1,a\n2,b\n"), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "meta.json"), []byte(`{"title":"letters"}`), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	expectStatus("edit", map[string]string{"dataset": StatusUnmodified, "meta": StatusAdded, "data": StatusModified})
+
+	saved := &repo.DatasetRef{}
+	if err := req.SaveWorkingDir(&SaveWorkingDirParams{Dir: dir, Title: "add b"}, saved); err != nil {
+		t.Errorf("error saving working dir: %s", err.Error())
+		return
+	}
+	if saved.Dataset.Meta == nil || saved.Dataset.Meta.Title != "letters" {
+		t.Errorf("expected saved meta title to be 'letters'")
+	}
+	expectStatus("save", map[string]string{"dataset": StatusUnmodified, "meta": StatusUnmodified, "structure": StatusUnmodified, "data": StatusUnmodified})
+
+	link, err := ReadWorkingDirLink(dir)
+	if err != nil {
+		t.Errorf("error reading link: %s", err.Error())
+		return
+	}
+	if link.Ref.Path != saved.Path {
+		t.Errorf("link path mismatch. expected: %s, got: %s", saved.Path, link.Ref.Path)
+	}
+
+	err = req.SaveWorkingDir(&SaveWorkingDirParams{Dir: dir}, saved)
+	if err == nil || err.Error() != "no changes to save" {
+		t.Errorf("expected no changes error, got: %s", err)
+	}
+
+	statuses := []ComponentStatus{}
+	err = req.Status(&tmp, &statuses)
+	if expect := fmt.Sprintf("%s isn't a linked dataset directory, use qri checkout to create one", tmp); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%s'", expect, err)
+	}
+}
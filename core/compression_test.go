@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsCompression(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	initCases := []struct {
+		p   *InitParams
+		err string
+	}{
+		{&InitParams{Peername: "me", Name: "bad", Compression: "lz4"}, "unsupported compression 'lz4', must be one of none, gzip or zstd"},
+	}
+	for i, c := range initCases {
+		err := req.Init(c.p, &repo.DatasetRef{})
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+
+	raw := "id,name\n1,a\n2,b\n"
+	created := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader(raw),
+		Compression:  repo.CompressionGzip,
+	}, created); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	f, err := mr.Store().Get(datastore.NewKey(created.Dataset.DataPath))
+	if err != nil {
+		t.Errorf("error getting stored data: %s", err.Error())
+		return
+	}
+	// stored data is a plain gzip stream
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Errorf("expected data to be stored gzipped: %s", err.Error())
+		return
+	}
+	if stored, _ := ioutil.ReadAll(gz); string(stored) != raw {
+		t.Errorf("expected gzipped data to be the uncompressed data")
+	}
+	if created.Dataset.Structure.Compression != compression.Gzip {
+		t.Errorf("expected structure to record gzip compression, got: %v", created.Dataset.Structure.Compression)
+	}
+	checksum, _ := repo.DataChecksum([]byte(raw))
+	if created.Dataset.Structure.Checksum != checksum {
+		t.Errorf("expected checksum of uncompressed data. expected: %s, got: %s", checksum, created.Dataset.Structure.Checksum)
+	}
+
+	got := &StructuredData{}
+	if err := req.StructuredData(&StructuredDataParams{
+		Format:       dataset.JSONDataFormat,
+		FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		Path:         datastore.NewKey(created.Path),
+		Limit:        1,
+		Offset:       1,
+	}, got); err != nil {
+		t.Errorf("error reading structured data: %s", err.Error())
+		return
+	}
+	if data, ok := got.Data.(json.RawMessage); !ok || !bytes.Contains(data, []byte(`"b"`)) || bytes.Contains(data, []byte(`"a"`)) {
+		t.Errorf("expected compressed data to be paged, got: %v", got.Data)
+	}
+
+	// saves keep the previous compression unless another is given
+	raw2 := raw + "3,c\n"
+	checksum2, _ := repo.DataChecksum([]byte(raw2))
+	saveCases := []struct {
+		data        string
+		compression string
+		expect      string
+		checksum    string
+	}{
+		{raw2, "", repo.CompressionGzip, checksum2},
+		{"", repo.CompressionZstd, repo.CompressionZstd, checksum2},
+		{"", repo.CompressionNone, repo.CompressionNone, checksum2},
+	}
+	for i, c := range saveCases {
+		p := &SaveParams{
+			Prev:        repo.DatasetRef{Peername: "me", Name: "letters"},
+			Changes:     &dataset.Dataset{Commit: &dataset.Commit{Title: "change compression"}},
+			Compression: c.compression,
+		}
+		if c.data != "" {
+			p.DataFilename = "letters.csv"
+			p.Data = strings.NewReader(c.data)
+		}
+		saved := &repo.DatasetRef{}
+		if err := req.Save(p, saved); err != nil {
+			t.Errorf("case %d error saving dataset: %s", i, err.Error())
+			continue
+		}
+		comp, err := repo.DataCompression(mr, saved.Dataset)
+		if err != nil {
+			t.Errorf("case %d error reading compression: %s", i, err.Error())
+			continue
+		}
+		if comp != c.expect {
+			t.Errorf("case %d compression mismatch. expected: %s, got: %s", i, c.expect, comp)
+		}
+		if saved.Dataset.Structure.Checksum != c.checksum {
+			t.Errorf("case %d expected checksum to be unchanged by compression", i)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+// ExportFormat names a data encoding datasets can be converted to
+type ExportFormat string
+
+const (
+	// ExportFormatCSV is comma-separated values
+	ExportFormatCSV ExportFormat = "csv"
+	// ExportFormatJSON is a single JSON array of entries
+	ExportFormatJSON ExportFormat = "json"
+	// ExportFormatNDJSON is newline-delimited JSON, one entry per line
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatCBOR is a CBOR array of entries
+	ExportFormatCBOR ExportFormat = "cbor"
+)
+
+// ParseExportFormat reads an export format string
+func ParseExportFormat(str string) (ExportFormat, error) {
+	switch f := ExportFormat(str); f {
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatNDJSON, ExportFormatCBOR:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid export format: '%s'. must be one of: csv, json, ndjson, cbor", str)
+}
+
+// ConvertParams configures data conversion
+type ConvertParams struct {
+	Format ExportFormat
+	// CSVDelimiter is the field separator for csv output. defaults to ','
+	CSVDelimiter rune
+	// CSVHeader includes a header row of column names in csv output
+	CSVHeader bool
+}
+
+// ParseCSVDelimiter reads a single-character delimiter. "\t" and "tab"
+// are accepted for tab-separated output
+func ParseCSVDelimiter(str string) (rune, error) {
+	switch str {
+	case "":
+		return ',', nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(str)
+	if size != len(str) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid csv delimiter: '%s'", str)
+	}
+	return r, nil
+}
+
+// ConvertData reads data in the format described by a structure, writing
+// it to w in the requested format
+func ConvertData(st *dataset.Structure, src io.Reader, w io.Writer, p *ConvertParams) error {
+	rr, err := dsio.NewValueReader(st, src)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+
+	switch p.Format {
+	case ExportFormatJSON, ExportFormatCBOR:
+		return convertWithBuffer(st, rr, w, p.Format)
+	case ExportFormatNDJSON:
+		bw := bufio.NewWriter(w)
+		err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			_, err = bw.Write(append(data, '\n'))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("row iteration error: %s", err.Error())
+		}
+		return bw.Flush()
+	case ExportFormatCSV:
+		return convertCSV(st, rr, w, p)
+	}
+	return fmt.Errorf("invalid export format: '%s'", p.Format)
+}
+
+// convertWithBuffer re-encodes data using a dsio value buffer
+func convertWithBuffer(st *dataset.Structure, rr dsio.ValueReader, w io.Writer, format ExportFormat) error {
+	out := &dataset.Structure{}
+	conv := &dataset.Structure{Format: dataset.CBORDataFormat}
+	if format == ExportFormatJSON {
+		conv = &dataset.Structure{
+			Format:       dataset.JSONDataFormat,
+			FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		}
+	}
+	out.Assign(st, conv)
+
+	buf, err := dsio.NewValueBuffer(out)
+	if err != nil {
+		return fmt.Errorf("error allocating result buffer: %s", err.Error())
+	}
+	if err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+		return buf.WriteValue(val)
+	}); err != nil {
+		return fmt.Errorf("row iteration error: %s", err.Error())
+	}
+	if err := buf.Close(); err != nil {
+		return fmt.Errorf("error closing row buffer: %s", err.Error())
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// convertCSV writes entries as csv records. Object entries are written
+// with one column per key
+func convertCSV(st *dataset.Structure, rr dsio.ValueReader, w io.Writer, p *ConvertParams) error {
+	cw := csv.NewWriter(w)
+	if p.CSVDelimiter != 0 {
+		cw.Comma = p.CSVDelimiter
+	}
+
+	header := schemaColumnTitles(st)
+	wroteHeader := false
+
+	err := dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+		v, err := rowValue(val)
+		if err != nil {
+			return err
+		}
+
+		var row []interface{}
+		switch x := v.(type) {
+		case []interface{}:
+			row = x
+		case map[string]interface{}:
+			if len(header) == 0 {
+				for key := range x {
+					header = append(header, key)
+				}
+				sort.Strings(header)
+			}
+			row = make([]interface{}, len(header))
+			for i, key := range header {
+				row[i] = x[key]
+			}
+		default:
+			row = []interface{}{x}
+		}
+
+		if p.CSVHeader && !wroteHeader && len(header) > 0 {
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		wroteHeader = true
+
+		rec, err := csvRecord(row)
+		if err != nil {
+			return err
+		}
+		return cw.Write(rec)
+	})
+	if err != nil {
+		return fmt.Errorf("row iteration error: %s", err.Error())
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
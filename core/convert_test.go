@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestParseCSVDelimiter(t *testing.T) {
+	cases := []struct {
+		str    string
+		expect rune
+		err    string
+	}{
+		{"", ',', ""},
+		{";", ';', ""},
+		{"|", '|', ""},
+		{`\t`, '\t', ""},
+		{"tab", '\t', ""},
+		{"ab", 0, "invalid csv delimiter: 'ab'"},
+		{`"`, 0, `invalid csv delimiter: '"'`},
+	}
+
+	for i, c := range cases {
+		got, err := ParseCSVDelimiter(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d mismatch. expected: %q, got: %q", i, c.expect, got)
+		}
+	}
+}
+
+func TestConvertData(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	ref, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "counter"})
+	if err != nil {
+		t.Errorf("error getting counter ref: %s", err.Error())
+		return
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+
+	var ndjson, csvData string
+	for i := 1; i <= 20; i++ {
+		ndjson += fmt.Sprintf("[%d]\n", i)
+		csvData += fmt.Sprintf("%d\n", i)
+	}
+
+	cases := []struct {
+		p      *ConvertParams
+		expect string
+	}{
+		{&ConvertParams{Format: ExportFormatNDJSON}, ndjson},
+		{&ConvertParams{Format: ExportFormatCSV, CSVHeader: true}, "count\n" + csvData},
+		{&ConvertParams{Format: ExportFormatCSV}, csvData},
+	}
+
+	for i, c := range cases {
+		src, err := dsfs.LoadData(mr.Store(), ds)
+		if err != nil {
+			t.Errorf("case %d error loading data: %s", i, err.Error())
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err := ConvertData(ds.Structure, src, buf, c.p); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if buf.String() != c.expect {
+			t.Errorf("case %d output mismatch. expected:\n%s\ngot:\n%s", i, c.expect, buf.String())
+		}
+	}
+}
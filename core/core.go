@@ -32,5 +32,6 @@ func Receivers(node *p2p.QriNode) []Requests {
 		NewPeerRequests(node, nil),
 		NewProfileRequests(r, nil),
 		NewSearchRequests(r, nil),
+		NewQueryRequests(r, nil),
 	}
 }
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,6 +25,7 @@ import (
 	"github.com/qri-io/datasetDiffer"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/query"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/varName"
 )
@@ -34,6 +36,9 @@ type DatasetRequests struct {
 	repo repo.Repo
 	cli  *rpc.Client
 	Node *p2p.QriNode
+	// RequireSigned rejects datasets without a valid commit signature
+	// from Get & Add
+	RequireSigned bool
 }
 
 // Repo exposes the DatasetRequest's repo
@@ -70,7 +75,7 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 	}
 
 	if p.Peername != "" && r.Node != nil {
-		replies, err := r.Node.RequestDatasetsList(p.Peername)
+		replies, err := r.Node.RequestDatasetsList(context.Background(), p.Peername)
 		*res = replies
 		return err
 	} else if p.Peername != "" {
@@ -83,7 +88,6 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 		}
 	}
 
-	store := r.repo.Store()
 	// ensure valid limit value
 	if p.Limit <= 0 {
 		p.Limit = 25
@@ -92,9 +96,9 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 	if p.Offset < 0 {
 		p.Offset = 0
 	}
-	replies, err := r.repo.References(p.Limit, p.Offset)
+	replies, err := r.references(p)
 	if err != nil {
-		return fmt.Errorf("error getting namespace: %s", err.Error())
+		return err
 	}
 
 	for i, ref := range replies {
@@ -102,11 +106,17 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 			break
 		}
 
-		ds, err := dsfs.LoadDataset(store, datastore.NewKey(ref.Path))
+		v, err := r.repo.RefVisibility(ref)
+		if err != nil {
+			return fmt.Errorf("error getting visibility: %s", err.Error())
+		}
+		replies[i].Visibility = v.String()
+
+		ds, err := repo.LoadDataset(r.repo, datastore.NewKey(ref.Path))
 		if err != nil {
 			// try one extra time...
 			// TODO - remove this horrible hack
-			ds, err = dsfs.LoadDataset(store, datastore.NewKey(ref.Path))
+			ds, err = repo.LoadDataset(r.repo, datastore.NewKey(ref.Path))
 			if err != nil {
 				return fmt.Errorf("error loading path: %s, err: %s", ref.Path, err.Error())
 			}
@@ -118,10 +128,45 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 	return nil
 }
 
+// references gets a page of references for List. private references are
+// dropped before paging when only public datasets are requested
+func (r *DatasetRequests) references(p *ListParams) ([]repo.DatasetRef, error) {
+	if !p.PublicOnly {
+		refs, err := r.repo.References(p.Limit, p.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("error getting namespace: %s", err.Error())
+		}
+		return refs, nil
+	}
+
+	count, err := r.repo.RefCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace: %s", err.Error())
+	}
+	refs, err := r.repo.References(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace: %s", err.Error())
+	}
+	if refs, err = repo.VisibleRefs(r.repo, refs, ""); err != nil {
+		return nil, err
+	}
+	if p.Offset > len(refs) {
+		p.Offset = len(refs)
+	}
+	refs = refs[p.Offset:]
+	if len(refs) > p.Limit {
+		refs = refs[:p.Limit]
+	}
+	return refs, nil
+}
+
 // Get a dataset
 func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err error) {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.Get", p, res)
+		if err := r.cli.Call("DatasetRequests.Get", p, res); err != nil {
+			return err
+		}
+		return checkSignature(res, r.RequireSigned)
 	}
 
 	err = repo.CanonicalizeDatasetRef(r.repo, p)
@@ -131,13 +176,18 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 
 	getRemote := func(err error) error {
 		if r.Node != nil {
-			ref, err := r.Node.RequestDatasetInfo(p)
+			ref, err := r.Node.RequestDatasetInfo(context.Background(), p)
 			if ref != nil {
 				ds := ref.Dataset
 				// TODO - this is really stupid, p2p.RequestDatasetInfo should return an error here
-				if ds.IsEmpty() {
+				if ds == nil || ds.IsEmpty() {
 					return fmt.Errorf("dataset not found")
 				}
+				// signatures cover the whole dataset, check them before it's
+				// trimmed below. remote data isn't available to check
+				if err := checkSignature(ref, r.RequireSigned); err != nil {
+					return err
+				}
 				st := ds.Structure
 				// TODO - it seems that jsonschema.RootSchema and encoding/gob
 				// really don't like each other (no surprise there, thanks to validators being an interface)
@@ -149,8 +199,9 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 					Name:     p.Name,
 					Path:     ref.Path,
 					Dataset: &dataset.Dataset{
-						Commit: ds.Commit,
-						Meta:   ds.Meta,
+						Commit:       ds.Commit,
+						Meta:         ds.Meta,
+						PreviousPath: ds.PreviousPath,
 						Structure: &dataset.Structure{
 							Checksum:     st.Checksum,
 							Compression:  st.Compression,
@@ -163,15 +214,16 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 							Qri:          st.Qri,
 						},
 					},
+					SignatureStatus: ref.SignatureStatus,
 				}
+				return nil
 			}
 			return err
 		}
 		return err
 	}
 
-	store := r.repo.Store()
-	ds, err := dsfs.LoadDataset(store, datastore.NewKey(p.Path))
+	ds, err := repo.LoadDataset(r.repo, datastore.NewKey(p.Path))
 	if err != nil {
 		return getRemote(err)
 	}
@@ -182,7 +234,7 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 		Path:     p.Path,
 		Dataset:  ds,
 	}
-	return nil
+	return checkSignature(res, r.RequireSigned)
 }
 
 // InitParams encapsulates arguments to Init
@@ -196,6 +248,11 @@ type InitParams struct {
 	Metadata          io.Reader // reader of json-formatted metadata
 	StructureFilename string    // filename of metadata file. optional.
 	Structure         io.Reader // reader of json-formatted metadata
+	CoAuthors         []string  // peernames of co-authors. optional.
+	Private           bool      // hide the dataset from other peers. optional.
+	Encrypt           bool      // encrypt data & components at rest with a new data key. optional.
+	Chunked           bool      // store data as content-defined chunks. optional.
+	Compression       string    // compress stored data, one of "gzip", "zstd" or "none". optional.
 }
 
 // Init creates a new qri dataset from a source of data
@@ -204,20 +261,53 @@ func (r *DatasetRequests) Init(p *InitParams, res *repo.DatasetRef) error {
 		return r.cli.Call("DatasetRequests.Init", p, res)
 	}
 
+	prep, err := r.prepareInit(p)
+	if err != nil {
+		return err
+	}
+
+	return r.createInit(prep, func(key datastore.Key) (bool, error) {
+		exists, err := repo.HasPath(r.repo, key)
+		if err != nil && strings.Contains(err.Error(), repo.ErrRepoEmpty.Error()) {
+			return false, nil
+		}
+		return exists, err
+	}, res)
+}
+
+// initDataset is a dataset that's been read & validated, ready to be written
+type initDataset struct {
+	peername, name string
+	ds             *dataset.Dataset
+	data           []byte
+	private        bool
+	encrypt        bool
+	chunked        bool
+	compression    string
+}
+
+// prepareInit reads & validates InitParams without writing to the repo, so
+// multiple datasets can be prepared concurrently
+func (r *DatasetRequests) prepareInit(p *InitParams) (*initDataset, error) {
 	var (
 		rdr      io.Reader
-		store    = r.repo.Store()
 		filename = p.DataFilename
 	)
 
 	if err := repo.CanonicalizePeername(r.repo, &p.Peername); err != nil {
-		return fmt.Errorf("error canonicalizing peername: %s", err.Error())
+		return nil, fmt.Errorf("error canonicalizing peername: %s", err.Error())
+	}
+	if p.Compression == "" {
+		p.Compression = repo.CompressionNone
+	}
+	if err := repo.ValidCompression(p.Compression); err != nil {
+		return nil, err
 	}
 
 	if p.URL != "" {
 		res, err := http.Get(p.URL)
 		if err != nil {
-			return fmt.Errorf("error fetching url: %s", err.Error())
+			return nil, fmt.Errorf("error fetching url: %s", err.Error())
 		}
 		filename = filepath.Base(p.URL)
 		defer res.Body.Close()
@@ -225,37 +315,37 @@ func (r *DatasetRequests) Init(p *InitParams, res *repo.DatasetRef) error {
 	} else if p.Data != nil {
 		rdr = p.Data
 	} else {
-		return fmt.Errorf("either a file or a url is required to create a dataset")
+		return nil, fmt.Errorf("either a file or a url is required to create a dataset")
 	}
 
 	if p.Name != "" {
 		if err := validate.ValidName(p.Name); err != nil {
-			return fmt.Errorf("invalid name: %s", err.Error())
+			return nil, fmt.Errorf("invalid name: %s", err.Error())
 		}
 	}
 
 	// TODO - need a better strategy for huge files
 	data, err := ioutil.ReadAll(rdr)
 	if err != nil {
-		return fmt.Errorf("error reading file: %s", err.Error())
+		return nil, fmt.Errorf("error reading file: %s", err.Error())
 	}
 
 	// read structure from InitParams, or detect from data
 	st := &dataset.Structure{}
 	if p.Structure != nil {
 		if err := json.NewDecoder(p.Structure).Decode(st); err != nil {
-			return fmt.Errorf("error parsing structure json: %s", err.Error())
+			return nil, fmt.Errorf("error parsing structure json: %s", err.Error())
 		}
 	} else {
 		st, err = detect.FromReader(filename, bytes.NewReader(data))
 		if err != nil {
-			return fmt.Errorf("error determining dataset schema: %s", err.Error())
+			return nil, fmt.Errorf("error determining dataset schema: %s", err.Error())
 		}
 	}
 
 	// Ensure that dataset contains valid field names
 	if err = validate.Structure(st); err != nil {
-		return fmt.Errorf("invalid structure: %s", err.Error())
+		return nil, fmt.Errorf("invalid structure: %s", err.Error())
 	}
 
 	// TODO - restore
@@ -265,19 +355,6 @@ func (r *DatasetRequests) Init(p *InitParams, res *repo.DatasetRef) error {
 
 	// TODO - check for errors in dataset and warn user if errors exist
 
-	datakey, err := store.Put(memfs.NewMemfileBytes("data."+st.Format.String(), data), false)
-	if err != nil {
-		return fmt.Errorf("error putting data file in store: %s", err.Error())
-	}
-
-	dataexists, err := repo.HasPath(r.repo, datakey)
-	if err != nil && !strings.Contains(err.Error(), repo.ErrRepoEmpty.Error()) {
-		return fmt.Errorf("error checking repo for already-existing data: %s", err.Error())
-	}
-	if dataexists {
-		return fmt.Errorf("this data already exists")
-	}
-
 	name := p.Name
 	if name == "" && filename != "" {
 		name = varName.CreateVarNameFromString(filename)
@@ -288,9 +365,12 @@ func (r *DatasetRequests) Init(p *InitParams, res *repo.DatasetRef) error {
 		Commit:    &dataset.Commit{Title: "initial commit"},
 		Structure: st,
 	}
+	if err := setCommitAuthor(r.repo, ds.Commit, p.CoAuthors); err != nil {
+		return nil, err
+	}
 	if p.Metadata != nil {
 		if err := json.NewDecoder(p.Metadata).Decode(ds.Meta); err != nil {
-			return fmt.Errorf("error parsing metadata json: %s", err.Error())
+			return nil, fmt.Errorf("error parsing metadata json: %s", err.Error())
 		}
 	}
 	if p.URL != "" {
@@ -301,14 +381,65 @@ func (r *DatasetRequests) Init(p *InitParams, res *repo.DatasetRef) error {
 		ds.Meta.AccrualPeriodicity = "R/P1W"
 	}
 
+	return &initDataset{peername: p.Peername, name: name, ds: ds, data: data, private: p.Private, encrypt: p.Encrypt, chunked: p.Chunked, compression: p.Compression}, nil
+}
+
+// createInit writes a prepared dataset to the repo. dataExists checks
+// if data is already in the repo
+func (r *DatasetRequests) createInit(prep *initDataset, dataExists func(datastore.Key) (bool, error), res *repo.DatasetRef) error {
+	ds, data := prep.ds, prep.data
+	st := ds.Structure
+
+	// encrypted data is never written to the store in the clear, & never
+	// matches existing data. chunked & compressed data is never written
+	// as one raw blob
+	if !prep.encrypt && !prep.chunked && prep.compression == repo.CompressionNone {
+		datakey, err := r.repo.Store().Put(memfs.NewMemfileBytes("data."+st.Format.String(), data), false)
+		if err != nil {
+			return fmt.Errorf("error putting data file in store: %s", err.Error())
+		}
+
+		dataexists, err := dataExists(datakey)
+		if err != nil {
+			return fmt.Errorf("error checking repo for already-existing data: %s", err.Error())
+		}
+		if dataexists {
+			return fmt.Errorf("this data already exists")
+		}
+	}
+
+	var (
+		keyID string
+		err   error
+	)
+	opts := []repo.StoreOption{repo.Compressed(prep.compression)}
+	if prep.encrypt {
+		if keyID, err = r.repo.NewDataKey(); err != nil {
+			return fmt.Errorf("error generating data key: %s", err.Error())
+		}
+		opts = append(opts, repo.Encrypted(keyID))
+	}
+	if prep.chunked {
+		opts = append(opts, repo.Chunked())
+	}
 	dataf := memfs.NewMemfileBytes("data."+st.Format.String(), data)
-	dskey, err := r.repo.CreateDataset(ds, dataf, true)
+	dskey, err := r.repo.CreateDataset(ds, dataf, true, opts...)
 	if err != nil {
 		fmt.Printf("error creating dataset: %s\n", err.Error())
 		return err
 	}
 
-	ref := repo.DatasetRef{Peername: p.Peername, Name: name, Path: dskey.String(), Dataset: ds}
+	// stats are derived from data. a dataset that can't be read row-by-row is
+	// still created, requesting its stats will report the read error
+	writeDataStats(r.repo, dskey, st, data, keyID)
+
+	ref := repo.DatasetRef{Peername: prep.peername, Name: prep.name, Path: dskey.String(), Dataset: ds}
+	// visibility is set before the ref is added so private refs are never indexed
+	if prep.private {
+		if err = r.repo.PutRefVisibility(ref, repo.Visibility{Private: true}); err != nil {
+			return fmt.Errorf("error setting dataset visibility: %s", err.Error())
+		}
+	}
 	if err = r.repo.PutRef(ref); err != nil {
 		return fmt.Errorf("error adding dataset name to repo: %s", err.Error())
 	}
@@ -328,6 +459,11 @@ type SaveParams struct {
 	Changes      *dataset.Dataset // all dataset changes. required.
 	DataFilename string           // filename for new data. optional.
 	Data         io.Reader        // stream of complete dataset update. optional.
+	CoAuthors    []string         // peernames of co-authors. optional.
+	Private      bool             // make the dataset private. optional, saves keep existing visibility.
+	Encrypt      bool             // encrypt data & components at rest. optional, versions of encrypted datasets are always encrypted.
+	Chunked      bool             // store data as content-defined chunks. optional, versions of chunked datasets are always chunked.
+	Compression  string           // compress stored data, one of "gzip", "zstd" or "none". optional, defaults to the compression of the previous version.
 }
 
 // Save adds a history entry, updating a dataset
@@ -375,22 +511,90 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 
 	ds.Commit.Title = commitTitle
 	ds.Commit.Message = commitMessage
+	if err := setCommitAuthor(r.repo, ds.Commit, p.CoAuthors); err != nil {
+		return err
+	}
 
 	if p.Data != nil {
 		dataf = memfs.NewMemfileReader(p.DataFilename, p.Data)
 	}
 
-	dspath, err := r.repo.CreateDataset(ds, dataf, true)
+	// new versions keep the data key of the previous version
+	keyID, err := repo.DataKeyID(r.repo.Store(), prev.Dataset)
+	if err != nil {
+		return err
+	}
+	if keyID == "" && p.Encrypt {
+		if keyID, err = r.repo.NewDataKey(); err != nil {
+			return fmt.Errorf("error generating data key: %s", err.Error())
+		}
+		// unchanged data is carried over from the previous version, which
+		// needs to be re-written to be encrypted
+		if dataf == nil {
+			if dataf, err = repo.LoadData(r.repo, prev.Dataset); err != nil {
+				return fmt.Errorf("error loading previous data: %s", err.Error())
+			}
+		}
+	}
+
+	// like encryption, chunking carries over from the previous version
+	chunked, err := repo.IsChunked(r.repo.Store(), prev.Dataset)
+	if err != nil {
+		return err
+	}
+	if !chunked && p.Chunked {
+		chunked = true
+		if dataf == nil {
+			if dataf, err = repo.LoadData(r.repo, prev.Dataset); err != nil {
+				return fmt.Errorf("error loading previous data: %s", err.Error())
+			}
+		}
+	}
+
+	prevCompression, err := repo.DataCompression(r.repo, prev.Dataset)
+	if err != nil {
+		return err
+	}
+	comp := p.Compression
+	if comp == "" {
+		comp = prevCompression
+	}
+	if err := repo.ValidCompression(comp); err != nil {
+		return err
+	}
+	// re-write unchanged data when compression changes
+	if comp != prevCompression && dataf == nil {
+		if dataf, err = repo.LoadData(r.repo, prev.Dataset); err != nil {
+			return fmt.Errorf("error loading previous data: %s", err.Error())
+		}
+	}
+
+	opts := []repo.StoreOption{repo.Compressed(comp)}
+	if keyID != "" {
+		opts = append(opts, repo.Encrypted(keyID))
+	}
+	if chunked {
+		opts = append(opts, repo.Chunked())
+	}
+	dspath, err := r.repo.CreateDataset(ds, dataf, true, opts...)
 	if err != nil {
 		fmt.Println("create ds error: %s", err.Error())
 		return err
 	}
 
+	// stats failures don't fail the save, see createInit
+	writeStats(r.repo, dspath)
+
 	if p.Prev.Name != "" {
 		if err := r.repo.DeleteRef(p.Prev); err != nil {
 			return err
 		}
 		p.Prev.Path = dspath.String()
+		if p.Private {
+			if err := setPrivate(r.repo, p.Prev); err != nil {
+				return err
+			}
+		}
 		if err := r.repo.PutRef(p.Prev); err != nil {
 			return err
 		}
@@ -444,12 +648,15 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *repo.DatasetRef) (err err
 	if err := r.repo.DeleteRef(p.Current); err != nil {
 		return err
 	}
+	if err := moveVisibility(r.repo, p.Current, p.New); err != nil {
+		return err
+	}
 
 	if err := r.repo.PutRef(p.New); err != nil {
 		return err
 	}
 
-	ds, err := dsfs.LoadDataset(r.repo.Store(), datastore.NewKey(p.Current.Path))
+	ds, err := repo.LoadDataset(r.repo, datastore.NewKey(p.Current.Path))
 	if err != nil {
 		return err
 	}
@@ -463,36 +670,98 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *repo.DatasetRef) (err err
 	return nil
 }
 
-// Remove a dataset
-func (r *DatasetRequests) Remove(p *repo.DatasetRef, ok *bool) (err error) {
+// RemoveParams defines parameters for removing datasets
+type RemoveParams struct {
+	Ref repo.DatasetRef
+	// Version is the path of a single version to splice out of the
+	// dataset's history. optional
+	Version string
+	// AllVersions unpins every version of the dataset, not just the latest
+	AllVersions bool
+	// Purge deletes every block only the dataset's history references.
+	// requires AllVersions
+	Purge bool
+}
+
+// Remove a dataset, or a single version of a dataset
+func (r *DatasetRequests) Remove(p *RemoveParams, ok *bool) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Remove", p, ok)
 	}
 
-	if err := repo.CanonicalizeDatasetRef(r.repo, p); err != nil {
+	if p.Version != "" && p.AllVersions {
+		return fmt.Errorf("can't remove a single version and all versions at once")
+	}
+	if p.Purge && !p.AllVersions {
+		return fmt.Errorf("purging a dataset requires removing all versions")
+	}
+
+	ref := &p.Ref
+	if err := repo.CanonicalizeDatasetRef(r.repo, ref); err != nil {
 		return fmt.Errorf("error canonicalizing new reference: %s", err.Error())
 	}
 
-	if p.Path == "" && (p.Peername == "" && p.Name == "") {
+	if ref.Path == "" && (ref.Peername == "" && ref.Name == "") {
 		return fmt.Errorf("either peername/name or path is required")
 	}
 
-	*p, err = r.repo.GetRef(*p)
+	*ref, err = r.repo.GetRef(*ref)
+	if err != nil {
+		return
+	}
+
+	if p.Version != "" {
+		head, removed, err := r.removeVersion(*ref, p.Version)
+		if err != nil {
+			return err
+		}
+		r.announceRemoved(head, removed)
+		*ok = true
+		return nil
+	}
+
+	history, err := historyVersions(r.repo.Store(), ref.Path)
 	if err != nil {
 		return
 	}
+	// versions are removed, earlier versions stay pinned unless all are
+	versions, kept := history[:1], history[1:]
+	var blocks []string
+	if p.AllVersions {
+		versions, kept = history, nil
+	}
+	if p.Purge {
+		if blocks, err = r.unsharedBlocks(*ref, versions); err != nil {
+			return
+		}
+	}
 
 	if pinner, ok := r.repo.Store().(cafs.Pinner); ok {
 		// path := datastore.NewKey(strings.TrimSuffix(p.Path, "/"+dsfs.PackageFileDataset.String()))
-		if err = pinner.Unpin(datastore.NewKey(p.Path), true); err != nil {
+		if err = pinner.Unpin(datastore.NewKey(ref.Path), true); err != nil {
+			return
+		}
+		unpinVersions(pinner, versions[1:])
+		if err = r.unpinChunks(pinner, *ref, versions, kept); err != nil {
 			return
 		}
 	}
 
-	if err = r.repo.DeleteRef(*p); err != nil {
+	if err = r.repo.DeleteRef(*ref); err != nil {
+		return
+	}
+	// forget visibility so a new dataset with this name starts public
+	if err = r.repo.PutRefVisibility(*ref, repo.Visibility{}); err != nil {
 		return
 	}
 
+	if p.Purge {
+		if err = r.purge(versions, blocks); err != nil {
+			return
+		}
+	}
+
+	r.announceRemoved(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}, versions)
 	*ok = true
 	return nil
 }
@@ -505,6 +774,14 @@ type StructuredDataParams struct {
 	Path          datastore.Key
 	Limit, Offset int
 	All           bool
+	// Columns restricts results to the named columns, in the order given. optional.
+	Columns []string
+	// Filters restricts results to rows that match all predicates. optional.
+	Filters []*query.Predicate
+	// Sort orders results by one or more columns. optional.
+	Sort []query.SortKey
+	// Distinct removes duplicate rows from results
+	Distinct bool
 }
 
 // StructuredData combines data with it's hashed path
@@ -529,13 +806,40 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 		return fmt.Errorf("invalid limit / offset settings")
 	}
 
-	ds, err := dsfs.LoadDataset(store, p.Path)
+	ds, err := repo.LoadDataset(r.repo, p.Path)
+	if err != nil {
+		return err
+	}
+
+	rf, err := newRowFilter(ds.Structure, p)
 	if err != nil {
 		return err
 	}
 
-	if p.All {
-		file, err = dsfs.LoadData(store, ds)
+	keyID, err := repo.DataKeyID(store, ds)
+	if err != nil {
+		return err
+	}
+	chunked, err := repo.IsChunked(store, ds)
+	if err != nil {
+		return err
+	}
+	// filtered results apply limit & offset after filtering, which
+	// requires reading all rows. encrypted, chunked & compressed data can't
+	// be read by row range, they're streamed & paged the same way
+	wholeRead := keyID != "" || chunked
+	if !wholeRead {
+		comp, err := repo.DataCompression(r.repo, ds)
+		if err != nil {
+			return err
+		}
+		wholeRead = comp != repo.CompressionNone
+	}
+	if wholeRead && !p.All && !rf.filtering() {
+		rf.limit, rf.offset = p.Limit, p.Offset
+	}
+	if p.All || rf.filtering() || wholeRead {
+		file, err = repo.LoadData(r.repo, ds)
 	} else {
 		d, err = dsfs.LoadRows(store, ds, p.Limit, p.Offset)
 		file = memfs.NewMemfileBytes("data", d)
@@ -549,6 +853,7 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 	st.Assign(ds.Structure, &dataset.Structure{
 		Format:       p.Format,
 		FormatConfig: p.FormatConfig,
+		Schema:       rf.schema,
 	})
 
 	buf, err := dsio.NewValueBuffer(st)
@@ -563,10 +868,20 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 		if err != nil {
 			return err
 		}
-		return buf.WriteValue(val)
-	}); err != nil {
+		if err := rf.write(val, buf.WriteValue); err != nil {
+			return err
+		}
+		// stop reading once limit rows are collected
+		if rf.done() {
+			return errRowsDone
+		}
+		return nil
+	}); err != nil && err != errRowsDone {
 		return fmt.Errorf("row iteration error: %s", err.Error())
 	}
+	if err = rf.flush(buf.WriteValue); err != nil {
+		return fmt.Errorf("error sorting rows: %s", err.Error())
+	}
 
 	if err := buf.Close(); err != nil {
 		return fmt.Errorf("error closing row buffer: %s", err.Error())
@@ -579,18 +894,28 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 	return nil
 }
 
+// AddParams defines parameters for adding a dataset
+type AddParams struct {
+	Ref repo.DatasetRef
+	// RequireSigned refuses datasets without a valid commit signature before
+	// they're pinned. It's sent with the request so repos serving RPC check it
+	RequireSigned bool
+}
+
 // Add adds an existing dataset to a peer's repository
-func (r *DatasetRequests) Add(ref *repo.DatasetRef, res *repo.DatasetRef) (err error) {
+func (r *DatasetRequests) Add(p *AddParams, res *repo.DatasetRef) (err error) {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.Add", ref, res)
+		p.RequireSigned = p.RequireSigned || r.RequireSigned
+		return r.cli.Call("DatasetRequests.Add", p, res)
 	}
 
+	ref := &p.Ref
 	if err := repo.CanonicalizeDatasetRef(r.repo, ref); err != nil {
 		return fmt.Errorf("error canonicalizing new reference: %s", err.Error())
 	}
 
 	if ref.Path == "" && r.Node != nil {
-		res, err := r.Node.RequestDatasetInfo(ref)
+		res, err := r.Node.RequestDatasetInfo(context.Background(), ref)
 		if err != nil {
 			return err
 		}
@@ -609,27 +934,73 @@ func (r *DatasetRequests) Add(ref *repo.DatasetRef, res *repo.DatasetRef) (err e
 		return fmt.Errorf("error fetching file: %s", err.Error())
 	}
 
-	err = fs.Pin(key, true)
+	// check peer data matches its recorded structure before keeping it
+	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
+	// encrypted datasets can be added without their key, they just can't be
+	// read or checked. signatures are checked below
+	vr, _ := verifyVersion(r.repo, path)
+	if !vr.Locked {
+		for _, problem := range vr.Problems {
+			if problem.Component != ComponentCommit {
+				return fmt.Errorf("fetched dataset failed verification: %s", problem.String())
+			}
+		}
+	}
+
+	var ds *dataset.Dataset
+	if vr.Locked {
+		ds, err = repo.LoadDatasetDocument(fs, path)
+	} else {
+		ds, err = repo.LoadDataset(r.repo, path)
+	}
 	if err != nil {
-		return fmt.Errorf("error pinning root key: %s", err.Error())
+		return fmt.Errorf("error loading fetched dataset path: %s", path.String())
+	}
+	added := repo.DatasetRef{
+		Name:    ref.Name,
+		Path:    path.String(),
+		Dataset: ds,
+	}
+	// a relayed dataset could have been forged, check its author signed it.
+	// data was checked against the recorded checksum the signature covers.
+	// the commit of a locked dataset is encrypted, its signature can't be
+	// checked
+	if vr.Locked && (p.RequireSigned || r.RequireSigned) {
+		return fmt.Errorf("rejecting commit %s: can't check the signature of an encrypted dataset without its key", path.String())
+	}
+	if !vr.Locked {
+		if err := checkSignature(&added, p.RequireSigned || r.RequireSigned); err != nil {
+			return err
+		}
 	}
 
-	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
-	err = r.repo.PutRef(*ref)
+	// chunks are stored apart from the dataset package, fetch & pin each
+	chunks, err := repo.DataChunks(fs, ds)
 	if err != nil {
-		return fmt.Errorf("error putting dataset name in repo: %s", err.Error())
+		return fmt.Errorf("error reading data chunks: %s", err.Error())
+	}
+	for _, c := range chunks {
+		if _, err := fs.Fetch(cafs.SourceAny, datastore.NewKey(c)); err != nil {
+			return fmt.Errorf("error fetching chunk %s: %s", c, err.Error())
+		}
 	}
 
-	ds, err := dsfs.LoadDataset(fs, path)
+	err = fs.Pin(key, true)
 	if err != nil {
-		return fmt.Errorf("error loading newly saved dataset path: %s", path.String())
+		return fmt.Errorf("error pinning root key: %s", err.Error())
+	}
+	for _, c := range chunks {
+		if err := fs.Pin(datastore.NewKey(c), true); err != nil {
+			return fmt.Errorf("error pinning chunk %s: %s", c, err.Error())
+		}
 	}
 
-	*res = repo.DatasetRef{
-		Name:    ref.Name,
-		Path:    path.String(),
-		Dataset: ds,
+	err = r.repo.PutRef(*ref)
+	if err != nil {
+		return fmt.Errorf("error putting dataset name in repo: %s", err.Error())
 	}
+
+	*res = added
 	return
 }
 
@@ -705,7 +1076,7 @@ func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]jsonschem
 	}
 
 	if data == nil && ref.Dataset != nil {
-		f, e := dsfs.LoadData(r.repo.Store(), ref.Dataset)
+		f, e := repo.LoadData(r.repo, ref.Dataset)
 		if e != nil {
 			return fmt.Errorf("error loading dataset data: %s", e.Error())
 		}
@@ -12,6 +12,7 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/datasetDiffer"
+	"github.com/qri-io/qri/query"
 	"github.com/qri-io/qri/repo"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
@@ -266,13 +267,16 @@ func TestDatasetRequestsRemove(t *testing.T) {
 	}
 
 	cases := []struct {
-		p   *repo.DatasetRef
+		p   *RemoveParams
 		res *dataset.Dataset
 		err string
 	}{
-		{&repo.DatasetRef{}, nil, "either peername/name or path is required"},
-		{&repo.DatasetRef{Path: "abc", Name: "ABC"}, nil, "repo: not found"},
-		{&ref, nil, ""},
+		{&RemoveParams{}, nil, "either peername/name or path is required"},
+		{&RemoveParams{Ref: repo.DatasetRef{Path: "abc", Name: "ABC"}}, nil, "repo: not found"},
+		{&RemoveParams{Ref: ref, Purge: true}, nil, "purging a dataset requires removing all versions"},
+		{&RemoveParams{Ref: ref, Version: "/map/abc", AllVersions: true}, nil, "can't remove a single version and all versions at once"},
+		{&RemoveParams{Ref: ref, Version: ref.Path}, nil, "can't remove the only version of a dataset, remove the dataset instead"},
+		{&RemoveParams{Ref: ref}, nil, ""},
 	}
 
 	req := NewDatasetRequests(mr, nil)
@@ -350,13 +354,79 @@ func TestDatasetRequestsStructuredData(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsStructuredDataFilters(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesRef, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Errorf("error getting cities ref: %s", err.Error())
+		return
+	}
+	path := datastore.NewKey(citiesRef.Path)
+
+	gt, err := query.ParsePredicate("pop>1000000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	contains, err := query.ParsePredicate("city~ch")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		p      *StructuredDataParams
+		expect string
+		err    string
+	}{
+		{&StructuredDataParams{Path: path, All: true, Columns: []string{"city"}, Filters: []*query.Predicate{gt}}, `[["toronto"],["new york"]]`, ""},
+		{&StructuredDataParams{Path: path, Limit: 2, Columns: []string{"city"}, Sort: []query.SortKey{{Column: "pop", Desc: true}}}, `[["toronto"],["new york"]]`, ""},
+		{&StructuredDataParams{Path: path, All: true, Columns: []string{"avg_age"}, Sort: []query.SortKey{{Column: "avg_age"}}, Distinct: true}, `[[44.4],[50.65],[55.5],[65.25]]`, ""},
+		{&StructuredDataParams{Path: path, Limit: 1, Offset: 1, Columns: []string{"city"}, Filters: []*query.Predicate{contains}}, `[["chatham"]]`, ""},
+		{&StructuredDataParams{Path: path, All: true, Columns: []string{"nope"}}, "", "unknown column: nope"},
+		{&StructuredDataParams{Path: path, All: true, Sort: []query.SortKey{{Column: "nope"}}}, "", "unknown column: nope"},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		c.p.Format = dataset.JSONDataFormat
+		c.p.FormatConfig = &dataset.JSONOptions{ArrayEntries: true}
+
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		rows := [][]interface{}{}
+		if err := json.Unmarshal(got.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing response data: %s", i, err.Error())
+			continue
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			t.Errorf("case %d error encoding response data: %s", i, err.Error())
+			continue
+		}
+		if string(data) != c.expect {
+			t.Errorf("case %d data mismatch. expected: %s, got: %s", i, c.expect, string(data))
+		}
+	}
+}
+
 func TestDatasetRequestsAdd(t *testing.T) {
 	cases := []struct {
-		p   *repo.DatasetRef
+		p   *AddParams
 		res *repo.DatasetRef
 		err string
 	}{
-		{&repo.DatasetRef{Name: "abc", Path: "hash###"}, nil, "can only add datasets when running an IPFS filestore"},
+		{&AddParams{Ref: repo.DatasetRef{Name: "abc", Path: "hash###"}}, nil, "can only add datasets when running an IPFS filestore"},
 	}
 
 	mr, err := testrepo.NewTestRepo()
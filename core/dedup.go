@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+)
+
+// Dedup reports how much data is shared between the versions of a
+// dataset. Versions of chunked datasets share unchanged chunks
+func (r *DatasetRequests) Dedup(p *repo.DatasetRef, res *repo.DedupStats) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Dedup", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, p); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	ref, err := r.repo.GetRef(*p)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+
+	store := r.repo.Store()
+	paths, err := historyVersions(store, ref.Path)
+	if err != nil {
+		return err
+	}
+	versions := make([]*dataset.Dataset, len(paths))
+	for i, path := range paths {
+		if versions[i], err = repo.LoadDatasetDocument(store, datastore.NewKey(path)); err != nil {
+			return fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+	}
+
+	stats, err := repo.Dedup(store, versions)
+	if err != nil {
+		return err
+	}
+	*res = *stats
+	return nil
+}
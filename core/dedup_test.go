@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsDedup(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n"),
+		Chunked:      true,
+	}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	saved := &repo.DatasetRef{}
+	if err := req.Save(&SaveParams{
+		Prev:         repo.DatasetRef{Peername: "me", Name: "letters"},
+		Changes:      &dataset.Dataset{Commit: &dataset.Commit{Title: "add c"}},
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n3,c\n"),
+	}, saved); err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+
+	if chunked, err := repo.IsChunked(mr.Store(), saved.Dataset); err != nil || !chunked {
+		t.Errorf("expected saved version to stay chunked")
+	}
+
+	got := &StructuredData{}
+	if err := req.StructuredData(&StructuredDataParams{
+		Format:       dataset.JSONDataFormat,
+		FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		Path:         datastore.NewKey(saved.Path),
+		Limit:        1,
+		Offset:       2,
+	}, got); err != nil {
+		t.Errorf("error reading structured data: %s", err.Error())
+		return
+	}
+	if data, ok := got.Data.(json.RawMessage); !ok || !bytes.Contains(data, []byte(`"c"`)) || bytes.Contains(data, []byte(`"a"`)) {
+		t.Errorf("expected chunked data to be paged, got: %v", got.Data)
+	}
+
+	stats := &repo.DedupStats{}
+	if err := req.Dedup(&repo.DatasetRef{Peername: "me", Name: "letters"}, stats); err != nil {
+		t.Errorf("error getting dedup stats: %s", err.Error())
+		return
+	}
+	if stats.Versions != 2 {
+		t.Errorf("versions mismatch. expected: 2, got: %d", stats.Versions)
+	}
+	if stats.Size != len("id,name\n1,a\n2,b\n")+len("id,name\n1,a\n2,b\n3,c\n") {
+		t.Errorf("size mismatch, got: %d", stats.Size)
+	}
+
+	// chunking composes with encryption & compression
+	all := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "all",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n"),
+		Encrypt:      true,
+		Chunked:      true,
+		Compression:  repo.CompressionZstd,
+	}, all); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	if chunked, err := repo.IsChunked(mr.Store(), all.Dataset); err != nil || !chunked {
+		t.Errorf("expected dataset to be chunked")
+	}
+	if keyID, err := repo.DataKeyID(mr.Store(), all.Dataset); err != nil || keyID == "" {
+		t.Errorf("expected dataset to be encrypted")
+	}
+	if comp, err := repo.DataCompression(mr, all.Dataset); err != nil || comp != repo.CompressionZstd {
+		t.Errorf("expected dataset to be compressed with zstd, got: %s", comp)
+	}
+	got = &StructuredData{}
+	if err := req.StructuredData(&StructuredDataParams{
+		Format:       dataset.JSONDataFormat,
+		FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		Path:         datastore.NewKey(all.Path),
+		Limit:        1,
+		Offset:       1,
+	}, got); err != nil {
+		t.Errorf("error reading structured data: %s", err.Error())
+		return
+	}
+	if data, ok := got.Data.(json.RawMessage); !ok || !bytes.Contains(data, []byte(`"b"`)) || bytes.Contains(data, []byte(`"a"`)) {
+		t.Errorf("expected compressed, encrypted & chunked data to be paged, got: %v", got.Data)
+	}
+}
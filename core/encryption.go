@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+// ShareKeyParams defines parameters for sharing the data key of an
+// encrypted dataset
+type ShareKeyParams struct {
+	Ref repo.DatasetRef
+	// PeerID is the peer to share with. The peer's public key is looked up
+	// from peers this node has connected to
+	PeerID string
+	// PubKey is a base64-encoded public key to share with, used in place
+	// of looking up PeerID
+	PubKey string
+}
+
+// ShareKey wraps the data key of an encrypted dataset to a peer's public
+// key. The result can only be imported by that peer, with ImportKey
+func (r *DatasetRequests) ShareKey(p *ShareKeyParams, res *string) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ShareKey", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&p.Ref, ref); err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	keyID, err := repo.DataKeyID(r.repo.Store(), ref.Dataset)
+	if err != nil {
+		return err
+	}
+	if keyID == "" {
+		return fmt.Errorf("dataset %s isn't encrypted", p.Ref)
+	}
+
+	pub, err := r.peerPubKey(p)
+	if err != nil {
+		return err
+	}
+	shared, err := r.repo.ShareDataKey(keyID, pub)
+	if err != nil {
+		return err
+	}
+	*res = shared
+	return nil
+}
+
+// peerPubKey gets the public key to share a data key with
+func (r *DatasetRequests) peerPubKey(p *ShareKeyParams) (crypto.PubKey, error) {
+	var data []byte
+	if p.PubKey != "" {
+		b, err := base64.StdEncoding.DecodeString(p.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding public key: %s", err.Error())
+		}
+		data = b
+	} else if p.PeerID != "" {
+		if r.Node == nil || r.Node.Host == nil {
+			return nil, fmt.Errorf("looking up a peer's public key requires a p2p connection, provide a public key instead")
+		}
+		id, err := peer.IDB58Decode(p.PeerID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer id: %s", err.Error())
+		}
+		pub := r.Node.Host.Peerstore().PubKey(id)
+		if pub == nil {
+			return nil, fmt.Errorf("public key for peer %s is unknown, connect to the peer first", p.PeerID)
+		}
+		if data, err = pub.Bytes(); err != nil {
+			return nil, fmt.Errorf("error encoding public key: %s", err.Error())
+		}
+	} else {
+		return nil, fmt.Errorf("a peer id or public key is required to share a key")
+	}
+
+	pub, err := crypto.UnmarshalPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key: %s", err.Error())
+	}
+	return pub, nil
+}
+
+// ImportKey stores a data key another peer shared with this peer, so
+// datasets encrypted with it can be read
+func (r *DatasetRequests) ImportKey(shared *string, id *string) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ImportKey", shared, id)
+	}
+
+	keyID, err := r.repo.ImportDataKey(*shared)
+	if err != nil {
+		return err
+	}
+	*id = keyID
+	return nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/stats"
+)
+
+func TestDatasetRequestsEncrypt(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	ref := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "secrets",
+		DataFilename: "secrets.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n"),
+		Encrypt:      true,
+	}, ref); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	// the dataset document is stored in the clear, components are encrypted
+	doc, err := repo.LoadDatasetDocument(mr.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Errorf("error loading dataset document: %s", err.Error())
+		return
+	}
+	components := map[string]datastore.Key{"commit": doc.Commit.Path(), "structure": doc.Structure.Path()}
+	for name, path := range components {
+		f, err := mr.Store().Get(path)
+		if err != nil {
+			t.Errorf("error getting %s: %s", name, err.Error())
+			continue
+		}
+		stored, _ := ioutil.ReadAll(f)
+		if _, ok := repo.EnvelopeKeyID(stored); !ok {
+			t.Errorf("expected stored %s to be encrypted", name)
+		}
+	}
+	if _, err := dsfs.LoadDataset(mr.Store(), datastore.NewKey(ref.Path)); err == nil {
+		t.Errorf("expected loading encrypted components without decrypting to fail")
+	}
+
+	ds, err := repo.LoadDataset(mr, datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	if ds.Commit == nil || ds.Commit.Title == "" || ds.Structure == nil || ds.Structure.Format != dataset.CSVDataFormat {
+		t.Errorf("expected decrypted components")
+	}
+	f, err := dsfs.LoadData(mr.Store(), ds)
+	if err != nil {
+		t.Errorf("error loading data: %s", err.Error())
+		return
+	}
+	raw, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Errorf("error reading data: %s", err.Error())
+		return
+	}
+	if _, ok := repo.EnvelopeKeyID(raw); !ok || bytes.Contains(raw, []byte("1,a")) {
+		t.Errorf("expected stored data to be encrypted")
+	}
+
+	got := &StructuredData{}
+	if err := req.StructuredData(&StructuredDataParams{
+		Format:       dataset.JSONDataFormat,
+		FormatConfig: &dataset.JSONOptions{ArrayEntries: true},
+		Path:         datastore.NewKey(ref.Path),
+		Limit:        1,
+		Offset:       1,
+	}, got); err != nil {
+		t.Errorf("error reading structured data: %s", err.Error())
+		return
+	}
+	if data, ok := got.Data.(json.RawMessage); !ok || !bytes.Contains(data, []byte(`"b"`)) || bytes.Contains(data, []byte(`"a"`)) {
+		t.Errorf("expected decrypted data, got: %v", got.Data)
+	}
+
+	s := &stats.Stats{}
+	if err := req.Stats(&repo.DatasetRef{Peername: "me", Name: "secrets"}, s); err != nil {
+		t.Errorf("error getting stats: %s", err.Error())
+		return
+	}
+	if s.Rows != 2 {
+		t.Errorf("stats row count mismatch. expected: 2, got: %d", s.Rows)
+	}
+
+	shared := ""
+	err = req.ShareKey(&ShareKeyParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, PubKey: "nope"}, &shared)
+	if err == nil || err.Error() != "dataset peer/movies isn't encrypted" {
+		t.Errorf("expected unencrypted dataset error, got: %s", err)
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"net/rpc"
 
@@ -15,6 +16,9 @@ type HistoryRequests struct {
 	repo repo.Repo
 	cli  *rpc.Client
 	Node *p2p.QriNode
+	// RequireSigned rejects logs that contain commits without a valid
+	// signature
+	RequireSigned bool
 }
 
 // CoreRequestsName implements the Requets interface
@@ -37,12 +41,18 @@ type LogParams struct {
 	ListParams
 	// Reference to data to fetch history for
 	Ref repo.DatasetRef
+	// Author limits the log to versions authored or co-authored by a
+	// peername or peer id. optional
+	Author string
 }
 
 // Log returns the history of changes for a given dataset
 func (d *HistoryRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err error) {
 	if d.cli != nil {
-		return d.cli.Call("HistoryRequests.Log", params, res)
+		if err := d.cli.Call("HistoryRequests.Log", params, res); err != nil {
+			return err
+		}
+		return checkSignatures(*res, d.RequireSigned)
 	}
 
 	ref := params.Ref
@@ -54,13 +64,13 @@ func (d *HistoryRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err er
 
 	getRemote := func(err error) error {
 		if d.Node != nil {
-			log, err := d.Node.RequestDatasetLog(ref)
+			log, err := d.Node.RequestDatasetLog(context.Background(), ref)
 			if err != nil {
 				return err
 			}
 
-			*res = *log
-			return nil
+			*res = filterAuthor(*log, params.Author)
+			return checkSignatures(*res, d.RequireSigned)
 		}
 		return err
 	}
@@ -80,9 +90,11 @@ func (d *HistoryRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err er
 		if err != nil {
 			return fmt.Errorf("error adding datasets to log: %s", err.Error())
 		}
-		log = append(log, ref)
+		if params.Author == "" || authoredBy(ref.Dataset, params.Author) {
+			log = append(log, ref)
+			limit--
+		}
 
-		limit--
 		if limit == 0 || ref.Dataset.PreviousPath == "" {
 			break
 		}
@@ -94,5 +106,30 @@ func (d *HistoryRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err er
 	}
 
 	*res = log
+	return checkSignatures(log, d.RequireSigned)
+}
+
+// filterAuthor removes refs not authored by author from a log, an empty
+// author keeps all refs
+func filterAuthor(log []repo.DatasetRef, author string) []repo.DatasetRef {
+	if author == "" {
+		return log
+	}
+	filtered := []repo.DatasetRef{}
+	for _, ref := range log {
+		if authoredBy(ref.Dataset, author) {
+			filtered = append(filtered, ref)
+		}
+	}
+	return filtered
+}
+
+// checkSignatures verifies the commit signature of each dataset in a log
+func checkSignatures(log []repo.DatasetRef, required bool) error {
+	for i := range log {
+		if err := checkSignature(&log[i], required); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// LineageParams defines parameters for dataset lineage
+type LineageParams struct {
+	Ref repo.DatasetRef
+	// Depth limits the number of transforms followed upstream & downstream,
+	// zero follows all of them
+	Depth int
+}
+
+// Lineage gets the graph of datasets a dataset was derived from, and
+// datasets derived from it
+func (r *DatasetRequests) Lineage(p *LineageParams, res *repo.LineageGraph) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Lineage", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	if p.Ref.Path == "" {
+		ref, err := r.repo.GetRef(p.Ref)
+		if err != nil {
+			return fmt.Errorf("error getting dataset: %s", err.Error())
+		}
+		p.Ref.Path = ref.Path
+	}
+
+	g, err := repo.Lineage(r.repo, p.Ref.Path, p.Depth)
+	if err != nil {
+		return fmt.Errorf("error calculating lineage: %s", err.Error())
+	}
+	*res = *g
+	return nil
+}
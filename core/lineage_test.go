@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsLineage(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		p     *LineageParams
+		nodes int
+		err   string
+	}{
+		{&LineageParams{Ref: repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}}, 0, "error getting dataset: repo: not found"},
+		{&LineageParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}}, 1, ""},
+		{&LineageParams{Ref: repo.DatasetRef{Peername: "peer", Name: "cities"}, Depth: 1}, 1, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.LineageGraph{}
+		err := req.Lineage(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if len(got.Nodes) != c.nodes {
+			t.Errorf("case %d node count mismatch. expected: %d, got: %d", i, c.nodes, len(got.Nodes))
+		}
+		if got.Root != c.p.Ref.Path {
+			t.Errorf("case %d root mismatch. expected: %s, got: %s", i, c.p.Ref.Path, got.Root)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/varName"
+)
+
+// AddPackageParams defines parameters for importing a dataset package, as
+// written by export. Either Path or Zip is required
+type AddPackageParams struct {
+	// Peername to add the dataset under. defaults to "me"
+	Peername string
+	// Name for the imported dataset. defaults to the package filename
+	Name string
+	// Path to a zip archive or package directory on the local filesystem
+	Path string
+	// Zip is the raw bytes of a zip archive package. ignored if Path is set
+	Zip []byte
+	// ZipFilename is used to name the dataset if Name isn't provided
+	ZipFilename string
+}
+
+// AddPackage imports a dataset package. Commit details & the previous path
+// of the packaged dataset are written as-is, so history is preserved across
+// exchanges. Data is checked against the structure checksum before anything
+// is written
+func (r *DatasetRequests) AddPackage(p *AddPackageParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.AddPackage", p, res)
+	}
+
+	if p.Peername == "" {
+		p.Peername = "me"
+	}
+	if err := repo.CanonicalizePeername(r.repo, &p.Peername); err != nil {
+		return fmt.Errorf("error canonicalizing peername: %s", err.Error())
+	}
+
+	var (
+		files    packageFiles
+		filename string
+		err      error
+	)
+	switch {
+	case p.Path != "":
+		filename = filepath.Base(p.Path)
+		files, err = readPackagePath(p.Path)
+	case p.Zip != nil:
+		filename = p.ZipFilename
+		files, err = readZipPackage(p.Zip)
+	default:
+		return fmt.Errorf("a package path or zip archive is required")
+	}
+	if err != nil {
+		return fmt.Errorf("error reading package: %s", err.Error())
+	}
+
+	name := p.Name
+	if name == "" && filename != "" {
+		name = varName.CreateVarNameFromString(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	}
+	if err := validate.ValidName(name); err != nil {
+		return fmt.Errorf("invalid name: %s", err.Error())
+	}
+
+	ds, dataFilename, data, err := files.dataset()
+	if err != nil {
+		return err
+	}
+	if err := verifyPackageData(ds.Structure, data); err != nil {
+		return err
+	}
+
+	ref := repo.DatasetRef{Peername: p.Peername, Name: name}
+	existing, err := r.repo.GetRef(ref)
+	if err == nil {
+		// a package that continues the history of an existing dataset
+		// moves the reference forward, anything else is a name collision
+		if ds.PreviousPath == "" || existing.Path != ds.PreviousPath {
+			return fmt.Errorf("dataset '%s/%s' already exists", ref.Peername, ref.Name)
+		}
+	} else if err != repo.ErrNotFound {
+		return fmt.Errorf("error checking for existing dataset: %s", err.Error())
+	}
+
+	file := memfs.NewMemfileBytes(dataFilename, data)
+	store := r.repo.Store()
+	if ds.Structure.Compression == compression.Gzip {
+		// packages hold uncompressed data, store it compressed as the
+		// structure records
+		if store, err = repo.DataStore(store, r.repo, ds, file, repo.Compressed(repo.CompressionGzip)); err != nil {
+			return fmt.Errorf("error preparing data store: %s", err.Error())
+		}
+	}
+
+	dskey, err := dsfs.WriteDataset(store, ds, file, true)
+	if err != nil {
+		return fmt.Errorf("error writing dataset: %s", err.Error())
+	}
+	if err := r.repo.PutDataset(dskey, ds); err != nil {
+		return fmt.Errorf("error adding dataset to repo: %s", err.Error())
+	}
+
+	if existing.Path != "" {
+		if err := r.repo.DeleteRef(existing); err != nil {
+			return err
+		}
+	}
+	ref.Path = dskey.String()
+	if err := r.repo.PutRef(ref); err != nil {
+		return fmt.Errorf("error adding dataset name to repo: %s", err.Error())
+	}
+
+	ref.Dataset = ds
+	*res = ref
+	return nil
+}
+
+// WriteZipPackage writes a dataset & its data to w as a zip archive package
+// AddPackage can import. Data is read with repo.LoadData, so packages hold
+// uncompressed, unencrypted & unchunked data
+func WriteZipPackage(r repo.Repo, ds *dataset.Dataset, w io.Writer) error {
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset has no structure")
+	}
+	zw := zip.NewWriter(w)
+
+	dsf, err := zw.Create(dsfs.PackageFileDataset.String())
+	if err != nil {
+		return err
+	}
+	dsdata, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := dsf.Write(dsdata); err != nil {
+		return err
+	}
+
+	datadst, err := zw.Create(fmt.Sprintf("data.%s", ds.Structure.Format.String()))
+	if err != nil {
+		return err
+	}
+	datasrc, err := repo.LoadData(r, ds)
+	if err != nil {
+		return fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer datasrc.Close()
+	if _, err := io.Copy(datadst, datasrc); err != nil {
+		return fmt.Errorf("error writing data: %s", err.Error())
+	}
+	return zw.Close()
+}
+
+// packageFiles maps package filenames to file contents
+type packageFiles map[string][]byte
+
+// readPackagePath reads a package from either a zip archive or a directory
+func readPackagePath(p string) (packageFiles, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return readZipPackage(data)
+	}
+
+	infos, err := ioutil.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	files := packageFiles{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(p, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[info.Name()] = data
+	}
+	return files, nil
+}
+
+// readZipPackage reads the files of a zip archive. archives that wrap files
+// in a single directory are flattened
+func readZipPackage(data []byte) (packageFiles, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := packageFiles{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[path.Base(f.Name)] = contents
+	}
+	return files, nil
+}
+
+// dataset reads the packaged dataset & its data file. standalone
+// component files fill in any components missing from dataset.json
+func (files packageFiles) dataset() (ds *dataset.Dataset, dataFilename string, data []byte, err error) {
+	ds = &dataset.Dataset{}
+	dsdata, ok := files[dsfs.PackageFileDataset.String()]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("package is missing %s", dsfs.PackageFileDataset.String())
+	}
+	if err = json.Unmarshal(dsdata, ds); err != nil {
+		return nil, "", nil, fmt.Errorf("error parsing %s: %s", dsfs.PackageFileDataset.String(), err.Error())
+	}
+
+	if ds.Meta == nil {
+		if md, ok := files[dsfs.PackageFileMeta.Filename()]; ok {
+			ds.Meta = &dataset.Meta{}
+			if err = json.Unmarshal(md, ds.Meta); err != nil {
+				return nil, "", nil, fmt.Errorf("error parsing %s: %s", dsfs.PackageFileMeta.Filename(), err.Error())
+			}
+		}
+	}
+	if ds.Structure == nil {
+		if st, ok := files[dsfs.PackageFileStructure.Filename()]; ok {
+			ds.Structure = &dataset.Structure{}
+			if err = json.Unmarshal(st, ds.Structure); err != nil {
+				return nil, "", nil, fmt.Errorf("error parsing %s: %s", dsfs.PackageFileStructure.Filename(), err.Error())
+			}
+		}
+	}
+	if ds.Structure == nil {
+		return nil, "", nil, fmt.Errorf("package dataset has no structure")
+	}
+
+	dataFilename = "data." + ds.Structure.Format.String()
+	if data, ok = files[dataFilename]; !ok {
+		return nil, "", nil, fmt.Errorf("package is missing data file %s", dataFilename)
+	}
+
+	if ds.Commit == nil {
+		ds.Commit = &dataset.Commit{Title: "imported package"}
+	}
+	return ds, dataFilename, data, nil
+}
+
+// verifyPackageData checks data against the length, checksum & entry count
+// recorded in a structure, returning the first problem found
+func verifyPackageData(st *dataset.Structure, data []byte) error {
+	if problems := checkData(st, data); len(problems) > 0 {
+		return fmt.Errorf("package %s", problems[0].String())
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsAddPackage(t *testing.T) {
+	src, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	ref, err := src.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+	ds, err := dsfs.LoadDataset(src.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+
+	pkg := &bytes.Buffer{}
+	if err := WriteZipPackage(src, ds, pkg); err != nil {
+		t.Errorf("error writing zip archive: %s", err.Error())
+		return
+	}
+
+	dsdata, err := json.Marshal(ds)
+	if err != nil {
+		t.Errorf("error marshaling dataset: %s", err.Error())
+		return
+	}
+
+	// a package with data that doesn't match its structure
+	tampered := &dataset.Dataset{}
+	if err := json.Unmarshal(dsdata, tampered); err != nil {
+		t.Errorf("error unmarshaling dataset: %s", err.Error())
+		return
+	}
+	badData := []byte("title,duration\nfake,100\n")
+	badSum, err := repo.DataChecksum(badData)
+	if err != nil {
+		t.Errorf("error calculating checksum: %s", err.Error())
+		return
+	}
+	tampered.Structure.Length = 0
+	tampered.Structure.Entries = 0
+	tamperedData, err := json.Marshal(tampered)
+	if err != nil {
+		t.Errorf("error marshaling dataset: %s", err.Error())
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "qri_test_add_package")
+	if err != nil {
+		t.Errorf("error creating temp dir: %s", err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+	zr, err := zip.NewReader(bytes.NewReader(pkg.Bytes()), int64(pkg.Len()))
+	if err != nil {
+		t.Errorf("error reading zip archive: %s", err.Error())
+		return
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Errorf("error opening zip file: %s", err.Error())
+			return
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Errorf("error reading zip file: %s", err.Error())
+			return
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(f.Name)), data, os.ModePerm); err != nil {
+			t.Errorf("error writing package file: %s", err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		p   *AddPackageParams
+		err string
+	}{
+		{&AddPackageParams{}, "a package path or zip archive is required"},
+		{&AddPackageParams{Zip: []byte("not a zip"), Name: "foo"}, "error reading package: zip: not a valid zip file"},
+		{&AddPackageParams{Zip: pkg.Bytes(), Name: "foo bar"}, "invalid name: error: illegal name 'foo bar', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
+		{&AddPackageParams{Zip: testZip(t, map[string][]byte{"dataset.json": dsdata}), Name: "foo"}, "package is missing data file data.csv"},
+		{&AddPackageParams{Zip: testZip(t, map[string][]byte{"dataset.json": tamperedData, "data.csv": badData}), Name: "foo"},
+			"package data: checksum mismatch. structure records " + tampered.Structure.Checksum + ", data hashes to " + badSum},
+		{&AddPackageParams{Zip: pkg.Bytes(), ZipFilename: "movies.zip"}, "dataset 'peer/movies' already exists"},
+		{&AddPackageParams{Zip: pkg.Bytes(), ZipFilename: "movies_package.zip"}, ""},
+		{&AddPackageParams{Path: dir, Name: "movies_dir"}, ""},
+	}
+
+	for i, c := range cases {
+		mr, err := testrepo.NewTestRepo()
+		if err != nil {
+			t.Errorf("error allocating test repo: %s", err.Error())
+			return
+		}
+
+		req := NewDatasetRequests(mr, nil)
+		got := &repo.DatasetRef{}
+		err = req.AddPackage(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Dataset.Commit.Title != ds.Commit.Title {
+			t.Errorf("case %d commit title mismatch. expected: '%s', got: '%s'", i, ds.Commit.Title, got.Dataset.Commit.Title)
+		}
+		if !got.Dataset.Commit.Timestamp.Equal(ds.Commit.Timestamp) {
+			t.Errorf("case %d commit timestamp mismatch. expected: %s, got: %s", i, ds.Commit.Timestamp, got.Dataset.Commit.Timestamp)
+		}
+		if got.Dataset.PreviousPath != ds.PreviousPath {
+			t.Errorf("case %d previous path mismatch. expected: '%s', got: '%s'", i, ds.PreviousPath, got.Dataset.PreviousPath)
+		}
+		if _, err := mr.GetRef(repo.DatasetRef{Peername: got.Peername, Name: got.Name}); err != nil {
+			t.Errorf("case %d error getting added ref: %s", i, err.Error())
+		}
+	}
+}
+
+func TestWriteZipPackage(t *testing.T) {
+	src, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(src, nil)
+	raw := "id,name\n1,a\n2,b\n"
+
+	cases := []struct {
+		name        string
+		compression string
+		chunked     bool
+		encrypt     bool
+		structure   compression.Type
+	}{
+		{"plain", repo.CompressionNone, false, false, compression.None},
+		{"gzipped", repo.CompressionGzip, false, false, compression.Gzip},
+		{"chunked", repo.CompressionNone, true, false, compression.None},
+		{"gzipped_chunks", repo.CompressionGzip, true, false, compression.Gzip},
+		{"zstd_encrypted_chunks", repo.CompressionZstd, true, true, compression.None},
+	}
+
+	for i, c := range cases {
+		created := &repo.DatasetRef{}
+		if err := req.Init(&InitParams{
+			Peername:     "me",
+			Name:         c.name,
+			DataFilename: "letters.csv",
+			Data:         strings.NewReader(raw),
+			Compression:  c.compression,
+			Chunked:      c.chunked,
+			Encrypt:      c.encrypt,
+		}, created); err != nil {
+			t.Errorf("case %d error initializing dataset: %s", i, err.Error())
+			continue
+		}
+
+		pkg := &bytes.Buffer{}
+		if err := WriteZipPackage(src, created.Dataset, pkg); err != nil {
+			t.Errorf("case %d error writing zip package: %s", i, err.Error())
+			continue
+		}
+		files, err := readZipPackage(pkg.Bytes())
+		if err != nil {
+			t.Errorf("case %d error reading zip package: %s", i, err.Error())
+			continue
+		}
+		if string(files["data.csv"]) != raw {
+			t.Errorf("case %d expected packaged data to be the uncompressed data, got: %q", i, files["data.csv"])
+			continue
+		}
+
+		dst, err := testrepo.NewTestRepo()
+		if err != nil {
+			t.Errorf("error allocating test repo: %s", err.Error())
+			return
+		}
+		got := &repo.DatasetRef{}
+		if err := NewDatasetRequests(dst, nil).AddPackage(&AddPackageParams{Zip: pkg.Bytes(), Name: c.name}, got); err != nil {
+			t.Errorf("case %d error adding package: %s", i, err.Error())
+			continue
+		}
+		if got.Dataset.Structure.Compression != c.structure {
+			t.Errorf("case %d structure compression mismatch. expected: %v, got: %v", i, c.structure, got.Dataset.Structure.Compression)
+		}
+		f, err := repo.LoadData(dst, got.Dataset)
+		if err != nil {
+			t.Errorf("case %d error loading imported data: %s", i, err.Error())
+			continue
+		}
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Errorf("case %d error reading imported data: %s", i, err.Error())
+			continue
+		}
+		if string(data) != raw {
+			t.Errorf("case %d imported data mismatch. expected: %q, got: %q", i, raw, data)
+		}
+	}
+}
+
+func testZip(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
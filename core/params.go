@@ -26,6 +26,9 @@ type ListParams struct {
 	OrderBy  string
 	Limit    int
 	Offset   int
+	// PublicOnly hides private & shared datasets, for listing datasets to
+	// viewers other than this peer
+	PublicOnly bool
 }
 
 // NewListParams creates a ListParams from page & pagesize, pages are 1-indexed
@@ -1,7 +1,7 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/rpc"
 
@@ -124,7 +124,7 @@ func (d *PeerRequests) ConnectToPeer(pid *peer.ID, res *profile.Profile) error {
 		return d.cli.Call("PeerRequests.ConnectToPeer", pid, res)
 	}
 
-	if err := d.qriNode.ConnectToPeer(*pid); err != nil {
+	if err := d.qriNode.ConnectToPeer(context.Background(), *pid); err != nil {
 		return fmt.Errorf("error connecting to peer: %s", err.Error())
 	}
 
@@ -190,7 +190,7 @@ func (d *PeerRequests) GetReferences(p *PeerRefsParams, res *[]repo.DatasetRef)
 		return err
 	}
 
-	r, err := d.qriNode.SendMessage(id, &p2p.Message{
+	r, err := d.qriNode.SendMessage(context.Background(), id, &p2p.Message{
 		Phase: p2p.MpRequest,
 		Type:  p2p.MtDatasets,
 		Payload: &p2p.DatasetsReqParams{
@@ -202,15 +202,11 @@ func (d *PeerRequests) GetReferences(p *PeerRefsParams, res *[]repo.DatasetRef)
 		return fmt.Errorf("error sending message to peer: %s", err.Error())
 	}
 
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		return fmt.Errorf("error encoding peer response: %s", err.Error())
-	}
-	refs := []repo.DatasetRef{}
-	if err := json.Unmarshal(data, &refs); err != nil {
-		return fmt.Errorf("error parsing peer response: %s", err.Error())
+	refs, ok := r.Payload.(*[]repo.DatasetRef)
+	if !ok {
+		return fmt.Errorf("error parsing peer response: no datasets")
 	}
 
-	*res = refs
+	*res = *refs
 	return nil
 }
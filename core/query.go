@@ -0,0 +1,496 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/rpc"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/query"
+	"github.com/qri-io/qri/repo"
+)
+
+// QueryRequests encapsulates business logic for running SQL
+// queries against datasets
+type QueryRequests struct {
+	repo repo.Repo
+	cli  *rpc.Client
+	Node *p2p.QriNode
+}
+
+// CoreRequestsName implements the Requets interface
+func (QueryRequests) CoreRequestsName() string { return "queries" }
+
+// NewQueryRequests creates a QueryRequests pointer from either a repo
+// or an rpc.Client
+func NewQueryRequests(r repo.Repo, cli *rpc.Client) *QueryRequests {
+	if r != nil && cli != nil {
+		panic(fmt.Errorf("both repo and client supplied to NewQueryRequests"))
+	}
+	return &QueryRequests{
+		repo: r,
+		cli:  cli,
+	}
+}
+
+// QueryParams defines parameters for running a query
+type QueryParams struct {
+	// Statement is the SQL SELECT statement to execute. required.
+	// tables are referred to as peername.dataset_name
+	Statement string
+	// Name to save the resulting dataset as. optional. when provided
+	// a reference to the result is added to the repo
+	Name string
+}
+
+// Query executes a SQL statement, saving the result as a new dataset
+// whose transform records the statement & the paths of all inputs
+func (r *QueryRequests) Query(p *QueryParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("QueryRequests.Query", p, res)
+	}
+
+	if p.Statement == "" {
+		return fmt.Errorf("statement is required")
+	}
+	if p.Name != "" {
+		if err := validate.ValidName(p.Name); err != nil {
+			return fmt.Errorf("invalid name: %s", err.Error())
+		}
+	}
+
+	sel, err := query.Parse(p.Statement)
+	if err != nil {
+		return err
+	}
+
+	resources := map[string]repo.DatasetRef{}
+	for _, name := range sel.TableNames() {
+		ref, err := TableRef(name)
+		if err != nil {
+			return err
+		}
+		if err := repo.CanonicalizeDatasetRef(r.repo, &ref); err != nil {
+			return fmt.Errorf("error canonicalizing reference for table '%s': %s", name, err.Error())
+		}
+		resources[name] = ref
+	}
+
+	ds, data, err := ExecTransform(r.repo, sel, p.Statement, resources)
+	if err != nil {
+		return err
+	}
+	if err := setCommitAuthor(r.repo, ds.Commit, nil); err != nil {
+		return err
+	}
+
+	dspath, err := r.repo.CreateDataset(ds, memfs.NewMemfileBytes("data."+ds.Structure.Format.String(), data), true)
+	if err != nil {
+		return fmt.Errorf("error creating dataset: %s", err.Error())
+	}
+
+	ref := repo.DatasetRef{Name: p.Name, Path: dspath.String(), Dataset: ds}
+	if p.Name != "" {
+		if ref.Peername, err = currentPeername(r.repo); err != nil {
+			return err
+		}
+		if err := r.repo.PutRef(ref); err != nil {
+			return fmt.Errorf("error adding dataset name to repo: %s", err.Error())
+		}
+	}
+
+	item := &repo.QueryLogItem{
+		Query:       p.Statement,
+		Name:        p.Name,
+		DatasetPath: dspath,
+		Time:        time.Now(),
+	}
+	if ds.Transform != nil {
+		item.Key = ds.Transform.Path()
+	}
+	if err := r.repo.LogQuery(item); err != nil {
+		return fmt.Errorf("error logging query: %s", err.Error())
+	}
+
+	*res = ref
+	return nil
+}
+
+// RunParams defines parameters for re-running a dataset's transform
+type RunParams struct {
+	// Ref is the dataset whose transform should be executed. required.
+	Ref repo.DatasetRef
+	// Pinned executes against the exact input versions recorded in the
+	// transform instead of the latest known version of each input
+	Pinned bool
+	// DryRun executes the transform without saving results
+	DryRun bool
+}
+
+// RunInput describes the version of a transform input used by a run
+type RunInput struct {
+	Name     string `json:"name"`
+	PrevPath string `json:"prevPath"`
+	Path     string `json:"path"`
+}
+
+// Moved is true when the input version differs from the recorded version
+func (in RunInput) Moved() bool {
+	return in.PrevPath != in.Path
+}
+
+// RunResult is the outcome of a transform run
+type RunResult struct {
+	// Ref points to the newly saved version, or the previous version
+	// when output is unchanged or the run is a dry run
+	Ref repo.DatasetRef `json:"ref"`
+	// Changed is true when the transform produced different output
+	Changed bool `json:"changed"`
+	// Saved is true when a new version was written
+	Saved  bool       `json:"saved"`
+	Inputs []RunInput `json:"inputs"`
+}
+
+// Run re-executes a dataset's recorded transform, saving a new version of
+// the dataset if the output differs from the current version
+func (r *QueryRequests) Run(p *RunParams, res *RunResult) error {
+	if r.cli != nil {
+		return r.cli.Call("QueryRequests.Run", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing reference: %s", err.Error())
+	}
+	if p.Ref.Path == "" {
+		return fmt.Errorf("dataset not found: %s", p.Ref)
+	}
+
+	store := repo.NewDecryptingStore(r.repo.Store(), r.repo)
+	prev, err := dsfs.LoadDataset(store, datastore.NewKey(p.Ref.Path))
+	if err != nil {
+		return fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	if prev.Transform == nil {
+		return fmt.Errorf("dataset %s has no transform", p.Ref)
+	}
+
+	trans := prev.Transform
+	if trans.Path().String() != "" {
+		if trans, err = dsfs.LoadTransform(store, trans.Path()); err != nil {
+			return fmt.Errorf("error loading transform: %s", err.Error())
+		}
+	}
+	if trans.Syntax != "sql" {
+		return fmt.Errorf("unsupported transform syntax: '%s'", trans.Syntax)
+	}
+
+	sel, err := query.Parse(trans.Data)
+	if err != nil {
+		return fmt.Errorf("error parsing transform: %s", err.Error())
+	}
+
+	var (
+		resources = map[string]repo.DatasetRef{}
+		inputs    = []RunInput{}
+	)
+	for _, name := range sel.TableNames() {
+		in := RunInput{Name: name}
+		if recorded, ok := trans.Resources[name]; ok && recorded != nil {
+			in.PrevPath = recorded.Path().String()
+		}
+
+		ref := repo.DatasetRef{Path: in.PrevPath}
+		if !p.Pinned {
+			if ref, err = TableRef(name); err != nil {
+				return err
+			}
+			if err := repo.CanonicalizeDatasetRef(r.repo, &ref); err != nil {
+				return fmt.Errorf("error canonicalizing reference for table '%s': %s", name, err.Error())
+			}
+		}
+		if ref.Path == "" {
+			return fmt.Errorf("cannot resolve input '%s'", name)
+		}
+
+		in.Path = ref.Path
+		resources[name] = ref
+		inputs = append(inputs, in)
+	}
+
+	ds, data, err := ExecTransform(r.repo, sel, trans.Data, resources)
+	if err != nil {
+		return err
+	}
+
+	prevData, err := repo.LoadData(r.repo, prev)
+	if err != nil {
+		return fmt.Errorf("error loading previous data: %s", err.Error())
+	}
+	prevBytes, err := ioutil.ReadAll(prevData)
+	if err != nil {
+		return fmt.Errorf("error reading previous data: %s", err.Error())
+	}
+
+	*res = RunResult{
+		Ref:     p.Ref,
+		Changed: !bytes.Equal(prevBytes, data),
+		Inputs:  inputs,
+	}
+	if p.DryRun || !res.Changed {
+		return nil
+	}
+
+	ds.Commit = &dataset.Commit{
+		Title:   "re-run transform",
+		Message: runCommitMessage(inputs),
+	}
+	ds.Meta = prev.Meta
+
+	saved := &repo.DatasetRef{}
+	dsr := NewDatasetRequests(r.repo, nil)
+	if err := dsr.Save(&SaveParams{
+		Prev:         p.Ref,
+		Changes:      ds,
+		DataFilename: "data." + ds.Structure.Format.String(),
+		Data:         bytes.NewReader(data),
+	}, saved); err != nil {
+		return fmt.Errorf("error saving: %s", err.Error())
+	}
+
+	res.Ref = *saved
+	res.Saved = true
+	return nil
+}
+
+// runCommitMessage lists inputs that moved to new versions
+func runCommitMessage(inputs []RunInput) string {
+	moved := []string{}
+	for _, in := range inputs {
+		if in.Moved() {
+			moved = append(moved, fmt.Sprintf("%s: %s -> %s", in.Name, in.PrevPath, in.Path))
+		}
+	}
+	if len(moved) == 0 {
+		return "no inputs changed"
+	}
+	return "updated inputs:\n" + strings.Join(moved, "\n")
+}
+
+// TableRef converts a table name as written in a query, peername.dataset_name,
+// into a dataset reference. Names without a peername refer to the local peer
+func TableRef(name string) (repo.DatasetRef, error) {
+	// quoted identifiers can hold full references, including timestamp
+	// selectors: "peer/name@{2018-03-01}"
+	if strings.Contains(name, "/") {
+		return repo.ParseDatasetRef(name)
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		return repo.ParseDatasetRef(name[:i] + "/" + name[i+1:])
+	}
+	return repo.DatasetRef{Peername: "me", Name: name}, nil
+}
+
+// ExecTransform runs a parsed statement against a set of resolved input
+// references, returning a dataset (with transform & structure populated)
+// and its encoded data. The result is not written to the repo
+func ExecTransform(r repo.Repo, sel *query.Select, stmt string, resources map[string]repo.DatasetRef) (*dataset.Dataset, []byte, error) {
+	var (
+		tables = map[string]*query.Table{}
+		trans  = &dataset.Transform{
+			Syntax:    "sql",
+			Data:      stmt,
+			Resources: map[string]*dataset.Dataset{},
+		}
+	)
+
+	for name, ref := range resources {
+		if ref.Path == "" {
+			return nil, nil, fmt.Errorf("dataset not found: %s", ref)
+		}
+		t, err := loadQueryTable(r, datastore.NewKey(ref.Path))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading table '%s': %s", name, err.Error())
+		}
+		tables[name] = t
+		trans.Resources[name] = dataset.NewDatasetRef(datastore.NewKey(ref.Path))
+	}
+
+	result, err := query.Exec(sel, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := queryResultCSV(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding query results: %s", err.Error())
+	}
+
+	st, err := detect.FromReader("data.csv", bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error determining result schema: %s", err.Error())
+	}
+
+	ds := &dataset.Dataset{
+		Commit:    &dataset.Commit{Title: "query results"},
+		Meta:      &dataset.Meta{},
+		Structure: st,
+		Transform: trans,
+	}
+	return ds, data, nil
+}
+
+// loadQueryTable reads all rows of a dataset into a query table. Column
+// names are taken from schema titles for array rows & keys for object rows
+func loadQueryTable(r repo.Repo, path datastore.Key) (*query.Table, error) {
+	ds, err := repo.LoadDataset(r, path)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("dataset has no structure")
+	}
+
+	file, err := repo.LoadData(r, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+
+	t := &query.Table{Columns: schemaColumnTitles(ds.Structure)}
+	objects := false
+
+	rr, err := dsio.NewValueReader(ds.Structure, file)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+	err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+
+		v, err := rowValue(val)
+		if err != nil {
+			return err
+		}
+
+		switch row := v.(type) {
+		case []interface{}:
+			for len(t.Columns) < len(row) {
+				t.Columns = append(t.Columns, fmt.Sprintf("field_%d", len(t.Columns)+1))
+			}
+			t.Rows = append(t.Rows, row)
+		case map[string]interface{}:
+			objects = true
+			for key := range row {
+				if indexOf(t.Columns, key) < 0 {
+					t.Columns = append(t.Columns, key)
+				}
+			}
+			t.Rows = append(t.Rows, []interface{}{row})
+		default:
+			if len(t.Columns) == 0 {
+				t.Columns = []string{"value"}
+			}
+			t.Rows = append(t.Rows, []interface{}{row})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("row iteration error: %s", err.Error())
+	}
+
+	// object rows are collected whole so every key seen across all
+	// entries becomes a column, then flattened in column order
+	if objects {
+		for i, row := range t.Rows {
+			flat := make([]interface{}, len(t.Columns))
+			if obj, ok := row[0].(map[string]interface{}); ok {
+				for j, col := range t.Columns {
+					flat[j] = obj[col]
+				}
+			}
+			t.Rows[i] = flat
+		}
+	}
+
+	return t, nil
+}
+
+// schemaColumnTitles reads column titles from a tabular schema
+func schemaColumnTitles(st *dataset.Structure) []string {
+	if st.Schema == nil {
+		return nil
+	}
+	data, err := st.Schema.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+	sch := struct {
+		Items struct {
+			Items []struct {
+				Title string `json:"title"`
+			} `json:"items"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(data, &sch); err != nil {
+		return nil
+	}
+
+	titles := make([]string, len(sch.Items.Items))
+	for i, f := range sch.Items.Items {
+		titles[i] = f.Title
+		if titles[i] == "" {
+			titles[i] = fmt.Sprintf("field_%d", i+1)
+		}
+	}
+	return titles
+}
+
+// queryResultCSV encodes a result table as csv with a header row
+func queryResultCSV(t *query.Table) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(t.Columns); err != nil {
+		return nil, err
+	}
+	for _, row := range t.Rows {
+		rec, err := csvRecord(row)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.Write(rec); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func currentPeername(r repo.Repo) (string, error) {
+	pro, err := r.Profile()
+	if err != nil {
+		return "", fmt.Errorf("error getting profile: %s", err.Error())
+	}
+	return pro.Peername, nil
+}
+
+func indexOf(strs []string, s string) int {
+	for i, str := range strs {
+		if str == s {
+			return i
+		}
+	}
+	return -1
+}
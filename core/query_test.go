@@ -0,0 +1,157 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestQueryRequestsQuery(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p       *QueryParams
+		entries int
+		err     string
+	}{
+		{&QueryParams{}, 0, "statement is required"},
+		{&QueryParams{Statement: "select * from peer.cities", Name: "bad name"}, 0, "invalid name: error: illegal name 'bad name', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
+		{&QueryParams{Statement: "select from"}, 0, "syntax error at position 7: unexpected 'FROM'"},
+		{&QueryParams{Statement: "select * from peer.nope"}, 0, "dataset not found: peer/nope"},
+		{&QueryParams{Statement: "select city, pop from peer.cities where in_usa = true order by pop desc"}, 4, ""},
+		{&QueryParams{Statement: "select in_usa, count(*) as n from me.cities group by in_usa", Name: "usa_counts"}, 2, ""},
+	}
+
+	req := NewQueryRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Query(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		ds := got.Dataset
+		if ds.Transform == nil || ds.Transform.Data != c.p.Statement {
+			t.Errorf("case %d expected transform to record statement", i)
+			continue
+		}
+		if ds.Transform.Resources["peer.cities"] == nil && ds.Transform.Resources["me.cities"] == nil {
+			t.Errorf("case %d expected transform to record input resource", i)
+		}
+		if ds.Structure.Entries != c.entries {
+			t.Errorf("case %d entries mismatch. expected: %d, got: %d", i, c.entries, ds.Structure.Entries)
+		}
+
+		if c.p.Name != "" {
+			ref, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: c.p.Name})
+			if err != nil {
+				t.Errorf("case %d error getting saved reference: %s", i, err.Error())
+				continue
+			}
+			if ref.Path != got.Path {
+				t.Errorf("case %d saved path mismatch. expected: %s, got: %s", i, got.Path, ref.Path)
+			}
+		}
+
+		if _, err := mr.QueryLogItem(&repo.QueryLogItem{Query: c.p.Statement}); err != nil {
+			t.Errorf("case %d expected query to be logged: %s", i, err.Error())
+		}
+	}
+}
+
+func TestQueryRequestsRun(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewQueryRequests(mr, nil)
+	qres := &repo.DatasetRef{}
+	if err := req.Query(&QueryParams{Statement: "select city from peer.cities where pop > 1000000", Name: "big_cities"}, qres); err != nil {
+		t.Errorf("error running query: %s", err.Error())
+		return
+	}
+
+	cities, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	res := &RunResult{}
+	if err := req.Run(&RunParams{Ref: repo.DatasetRef{Peername: "peer", Name: "big_cities"}}, res); err != nil {
+		t.Errorf("error running unchanged transform: %s", err.Error())
+		return
+	}
+	if res.Changed || res.Saved {
+		t.Errorf("expected unchanged inputs to produce unchanged output")
+	}
+
+	dsr := NewDatasetRequests(mr, nil)
+	saved := &repo.DatasetRef{}
+	if err := dsr.Save(&SaveParams{
+		Prev:         repo.DatasetRef{Peername: "peer", Name: "cities"},
+		Changes:      &dataset.Dataset{Commit: &dataset.Commit{Title: "add a big city"}},
+		DataFilename: "cities.csv",
+		Data:         strings.NewReader("city,pop,avg_age,in_usa\ntoronto,40000000,55.5,false\nnew york,8500000,44.4,true\nlos angeles,4000000,35.0,true\n"),
+	}, saved); err != nil {
+		t.Errorf("error saving new cities version: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p       *RunParams
+		changed bool
+		saved   bool
+		moved   bool
+		err     string
+	}{
+		{&RunParams{Ref: repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}}, false, false, false, "dataset not found: peer/not_a_dataset"},
+		{&RunParams{Ref: cities}, false, false, false, "dataset peer/cities@" + cities.Path + " has no transform"},
+		{&RunParams{Ref: repo.DatasetRef{Peername: "peer", Name: "big_cities"}, Pinned: true}, false, false, false, ""},
+		{&RunParams{Ref: repo.DatasetRef{Peername: "peer", Name: "big_cities"}, DryRun: true}, true, false, true, ""},
+		{&RunParams{Ref: repo.DatasetRef{Peername: "peer", Name: "big_cities"}}, true, true, true, ""},
+	}
+
+	for i, c := range cases {
+		got := &RunResult{}
+		err := req.Run(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Changed != c.changed {
+			t.Errorf("case %d changed mismatch. expected: %t, got: %t", i, c.changed, got.Changed)
+		}
+		if got.Saved != c.saved {
+			t.Errorf("case %d saved mismatch. expected: %t, got: %t", i, c.saved, got.Saved)
+		}
+		if len(got.Inputs) != 1 {
+			t.Errorf("case %d expected 1 input, got: %d", i, len(got.Inputs))
+			continue
+		}
+		if got.Inputs[0].Moved() != c.moved {
+			t.Errorf("case %d input moved mismatch. expected: %t, got: %t", i, c.moved, got.Inputs[0].Moved())
+		}
+		if c.saved && got.Inputs[0].Path != saved.Path {
+			t.Errorf("case %d expected input path to be latest version. expected: %s, got: %s", i, saved.Path, got.Inputs[0].Path)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// removeVersion splices a version out of a dataset's history. Versions
+// after the removed one are rewritten to point past it, which changes
+// their paths. It returns the new head of the dataset & the paths of all
+// versions no longer in the history
+func (r *DatasetRequests) removeVersion(ref repo.DatasetRef, version string) (repo.DatasetRef, []string, error) {
+	store := r.repo.Store()
+
+	paths := []string{}
+	chain := []*dataset.Dataset{}
+	path := ref.Path
+	for {
+		ds, err := repo.LoadDataset(r.repo, datastore.NewKey(path))
+		if err != nil {
+			return ref, nil, fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		paths = append(paths, path)
+		chain = append(chain, ds)
+		if packagePath(path) == packagePath(version) {
+			break
+		}
+		if ds.PreviousPath == "" || ds.PreviousPath == "/" {
+			return ref, nil, fmt.Errorf("version %s isn't in the history of %s", version, repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+		}
+		path = ds.PreviousPath
+	}
+
+	i := len(chain) - 1
+	prev := chain[i].PreviousPath
+	if i == 0 && (prev == "" || prev == "/") {
+		return ref, nil, fmt.Errorf("can't remove the only version of a dataset, remove the dataset instead")
+	}
+
+	// rewrite later versions oldest first, so each points to the one before.
+	// changing the previous path invalidates signatures, rewritten versions
+	// are signed again by this repo
+	for j := i - 1; j >= 0; j-- {
+		ds := chain[j]
+		ds.PreviousPath = prev
+		key, err := r.repo.RewriteDataset(ds, true)
+		if err != nil {
+			return ref, nil, fmt.Errorf("error rewriting dataset: %s", err.Error())
+		}
+		if err := r.repo.PutDataset(key, ds); err != nil {
+			return ref, nil, fmt.Errorf("error adding dataset to repo: %s", err.Error())
+		}
+		if sp, err := r.repo.StatsPath(datastore.NewKey(paths[j])); err == nil {
+			if err := r.repo.PutStatsPath(key, sp); err != nil {
+				return ref, nil, fmt.Errorf("error saving stats path: %s", err.Error())
+			}
+		}
+		prev = key.String()
+	}
+
+	if err := r.repo.DeleteRef(ref); err != nil {
+		return ref, nil, err
+	}
+	head := repo.DatasetRef{Peername: ref.Peername, Name: ref.Name, Path: prev}
+	if err := r.repo.PutRef(head); err != nil {
+		return ref, nil, fmt.Errorf("error updating dataset reference: %s", err.Error())
+	}
+
+	if pinner, ok := store.(cafs.Pinner); ok {
+		unpinVersions(pinner, paths)
+		kept, err := historyVersions(store, prev)
+		if err != nil {
+			return ref, nil, err
+		}
+		if err := r.unpinChunks(pinner, head, paths, kept); err != nil {
+			return ref, nil, err
+		}
+	}
+	for _, p := range paths {
+		r.repo.DeleteDataset(datastore.NewKey(p))
+	}
+	return head, paths, nil
+}
+
+// historyVersions lists the paths of every version in a history, newest
+// first
+func historyVersions(store cafs.Filestore, path string) ([]string, error) {
+	versions := []string{}
+	for path != "" && path != "/" {
+		ds, err := repo.LoadDatasetDocument(store, datastore.NewKey(path))
+		if err != nil {
+			return nil, fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		versions = append(versions, path)
+		path = ds.PreviousPath
+	}
+	return versions, nil
+}
+
+// historyBlocks adds the paths of the dataset packages, data, data chunks &
+// components a set of versions reference to blocks
+func (r *DatasetRequests) historyBlocks(versions []string, blocks map[string]bool) error {
+	store := r.repo.Store()
+	for _, path := range versions {
+		ds, err := repo.LoadDatasetDocument(store, datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		blocks[packagePath(path)] = true
+		if ds.DataPath != "" {
+			blocks[ds.DataPath] = true
+		}
+		chunks, err := repo.DataChunks(store, ds)
+		if err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			blocks[c] = true
+		}
+		if ds.Commit != nil && ds.Commit.Path().String() != "" {
+			blocks[ds.Commit.Path().String()] = true
+		}
+		if ds.Abstract != nil && ds.Abstract.Path().String() != "" {
+			blocks[ds.Abstract.Path().String()] = true
+		}
+		if ds.Transform != nil && ds.Transform.Path().String() != "" {
+			blocks[ds.Transform.Path().String()] = true
+		}
+		if ds.AbstractTransform != nil && ds.AbstractTransform.Path().String() != "" {
+			blocks[ds.AbstractTransform.Path().String()] = true
+		}
+		if sp, err := r.repo.StatsPath(datastore.NewKey(path)); err == nil {
+			blocks[sp.String()] = true
+		}
+	}
+	return nil
+}
+
+// unsharedBlocks gives the blocks a history references that no other
+// dataset in the repo does
+func (r *DatasetRequests) unsharedBlocks(ref repo.DatasetRef, versions []string) ([]string, error) {
+	own := map[string]bool{}
+	if err := r.historyBlocks(versions, own); err != nil {
+		return nil, err
+	}
+
+	others, err := r.otherVersions(ref)
+	if err != nil {
+		return nil, err
+	}
+	shared := map[string]bool{}
+	if err := r.historyBlocks(others, shared); err != nil {
+		return nil, err
+	}
+
+	blocks := []string{}
+	for path := range own {
+		if !shared[path] {
+			blocks = append(blocks, path)
+		}
+	}
+	return blocks, nil
+}
+
+// otherVersions lists the versions of every dataset in the repo but ref
+func (r *DatasetRequests) otherVersions(ref repo.DatasetRef) ([]string, error) {
+	count, err := r.repo.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.repo.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for _, other := range refs {
+		if other.Peername == ref.Peername && other.Name == ref.Name {
+			continue
+		}
+		vs, err := historyVersions(r.repo.Store(), other.Path)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, vs...)
+	}
+	return versions, nil
+}
+
+// purge deletes removed versions from the repo & blocks from the store
+func (r *DatasetRequests) purge(versions, blocks []string) error {
+	for _, path := range versions {
+		r.repo.DeleteDataset(datastore.NewKey(path))
+	}
+	store := r.repo.Store()
+	for _, path := range blocks {
+		key := datastore.NewKey(path)
+		// components may be stored within the package, deleting the
+		// package removes them
+		if has, err := store.Has(key); err != nil || !has {
+			continue
+		}
+		if err := store.Delete(key); err != nil {
+			return fmt.Errorf("error deleting %s: %s", path, err.Error())
+		}
+	}
+	return nil
+}
+
+// unpinChunks unpins the data chunks of removed versions of a dataset.
+// Chunks are pinned on their own as they're written, so unpinning a version
+// leaves its chunks pinned. Chunks that kept versions of the dataset or any
+// other dataset use stay pinned
+func (r *DatasetRequests) unpinChunks(pinner cafs.Pinner, ref repo.DatasetRef, removed, kept []string) error {
+	store := r.repo.Store()
+	own := map[string]bool{}
+	for _, path := range removed {
+		ds, err := repo.LoadDatasetDocument(store, datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		chunks, err := repo.DataChunks(store, ds)
+		if err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			own[c] = true
+		}
+	}
+	if len(own) == 0 {
+		return nil
+	}
+
+	others, err := r.otherVersions(ref)
+	if err != nil {
+		return err
+	}
+	shared := map[string]bool{}
+	if err := r.historyBlocks(append(kept, others...), shared); err != nil {
+		return err
+	}
+	for path := range own {
+		if !shared[path] {
+			pinner.Unpin(datastore.NewKey(path), true)
+		}
+	}
+	return nil
+}
+
+// unpinVersions unpins dataset versions. Versions that were never pinned
+// on their own (the common case for all but the latest version) error, so
+// errors are ignored
+func unpinVersions(pinner cafs.Pinner, paths []string) {
+	for _, path := range paths {
+		pinner.Unpin(datastore.NewKey(path), true)
+	}
+}
+
+// announceRemoved tells peers that were given a dataset that versions of
+// it are gone. Announcing is best-effort, removal has already happened
+func (r *DatasetRequests) announceRemoved(ref repo.DatasetRef, paths []string) {
+	if r.Node == nil || !r.Node.Online {
+		return
+	}
+	r.Node.AnnounceTombstone(context.Background(), &p2p.Tombstone{
+		Ref:     ref,
+		Paths:   paths,
+		Removed: time.Now(),
+	})
+}
+
+// packagePath trims the dataset file from a dataset path, giving the path
+// of the package
+func packagePath(path string) string {
+	return strings.TrimSuffix(path, "/"+dsfs.PackageFileDataset.String())
+}
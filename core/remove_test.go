@@ -0,0 +1,136 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsRemoveVersion(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	hreq := NewHistoryRequests(mr, nil)
+	letters := repo.DatasetRef{Peername: "me", Name: "letters"}
+
+	versions := []*repo.DatasetRef{{}, {}, {}}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n"),
+	}, versions[0]); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	for i, data := range []string{"id,name\n1,a\n2,b\n", "id,name\n1,a\n2,b\n3,c\n"} {
+		if err := req.Save(&SaveParams{
+			Prev:         letters,
+			Changes:      &dataset.Dataset{Commit: &dataset.Commit{Title: "add a row"}},
+			DataFilename: "letters.csv",
+			Data:         strings.NewReader(data),
+		}, versions[i+1]); err != nil {
+			t.Errorf("error saving dataset: %s", err.Error())
+			return
+		}
+	}
+
+	ok := false
+	err = req.Remove(&RemoveParams{Ref: letters, Version: "/map/not_a_version"}, &ok)
+	if err == nil || err.Error() != "version /map/not_a_version isn't in the history of me/letters" {
+		t.Errorf("expected version not found error, got: %s", err)
+	}
+
+	if err := req.Remove(&RemoveParams{Ref: letters, Version: versions[1].Path}, &ok); err != nil {
+		t.Errorf("error removing version: %s", err.Error())
+		return
+	}
+
+	log := []repo.DatasetRef{}
+	if err := hreq.Log(&LogParams{Ref: letters, ListParams: ListParams{Limit: 10}}, &log); err != nil {
+		t.Errorf("error getting log: %s", err.Error())
+		return
+	}
+	if len(log) != 2 {
+		t.Fatalf("expected 2 versions after removal, got: %d", len(log))
+	}
+	if log[0].Path == versions[2].Path {
+		t.Errorf("expected the latest version to be rewritten")
+	}
+	if log[0].Dataset.PreviousPath != versions[0].Path {
+		t.Errorf("previous path mismatch. expected: %s, got: %s", versions[0].Path, log[0].Dataset.PreviousPath)
+	}
+	if log[0].Dataset.DataPath != versions[2].Dataset.DataPath {
+		t.Errorf("expected the latest version's data to be unchanged")
+	}
+	if log[0].SignatureStatus != repo.SignatureValid {
+		t.Errorf("expected the rewritten version to be signed, got: %s", log[0].SignatureStatus)
+	}
+
+	ref, err := mr.GetRef(letters)
+	if err != nil {
+		t.Errorf("error getting ref: %s", err.Error())
+		return
+	}
+	dataPath := datastore.NewKey(log[0].Dataset.DataPath)
+
+	if err := req.Remove(&RemoveParams{Ref: letters, AllVersions: true, Purge: true}, &ok); err != nil {
+		t.Errorf("error purging dataset: %s", err.Error())
+		return
+	}
+	if _, err := mr.GetRef(letters); err != repo.ErrNotFound {
+		t.Errorf("expected purged ref to be removed, got: %v", err)
+	}
+	if has, _ := mr.Store().Has(datastore.NewKey(ref.Path)); has {
+		t.Errorf("expected purged version to be deleted from the store")
+	}
+	if has, _ := mr.Store().Has(dataPath); has {
+		t.Errorf("expected purged data to be deleted from the store")
+	}
+}
+
+func TestDatasetRequestsPurgeChunked(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	ref := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "chunked_letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader("id,name\n1,a\n2,b\n"),
+		Chunked:      true,
+	}, ref); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	chunks, err := repo.DataChunks(mr.Store(), ref.Dataset)
+	if err != nil {
+		t.Errorf("error reading chunks: %s", err.Error())
+		return
+	}
+	if len(chunks) == 0 {
+		t.Fatalf("expected chunked data")
+	}
+
+	ok := false
+	if err := req.Remove(&RemoveParams{Ref: repo.DatasetRef{Peername: "me", Name: "chunked_letters"}, AllVersions: true, Purge: true}, &ok); err != nil {
+		t.Errorf("error purging dataset: %s", err.Error())
+		return
+	}
+	for _, c := range chunks {
+		if has, _ := mr.Store().Has(datastore.NewKey(c)); has {
+			t.Errorf("expected purged chunk %s to be deleted from the store", c)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qri/query"
+)
+
+// UnknownColumnError is returned when data params name a column the data
+// doesn't have
+type UnknownColumnError string
+
+func (e UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown column: %s", string(e))
+}
+
+// errRowsDone stops reading rows once a filter's limit is reached
+var errRowsDone = fmt.Errorf("rows done")
+
+// rowFilter applies column selection, predicates, sorting & distinct to
+// rows as they stream out of a dataset. Rows are written as soon as they
+// match unless a sort is requested, which buffers matching rows until flush
+type rowFilter struct {
+	columns  []string
+	project  []string
+	filters  []*query.Predicate
+	sort     []query.SortKey
+	distinct bool
+	// limit & offset apply to matched rows. -1 limit means no limit
+	limit, offset int
+	// schema is the projected schema, nil if all columns are selected
+	schema *jsonschema.RootSchema
+
+	seen    map[string]bool
+	matched int
+	written int
+	sorted  []sortRow
+}
+
+type sortRow struct {
+	keys []interface{}
+	val  vals.Value
+}
+
+func newRowFilter(st *dataset.Structure, p *StructuredDataParams) (*rowFilter, error) {
+	rf := &rowFilter{
+		columns:  schemaColumnTitles(st),
+		project:  p.Columns,
+		filters:  p.Filters,
+		sort:     p.Sort,
+		distinct: p.Distinct,
+		limit:    -1,
+		seen:     map[string]bool{},
+	}
+
+	if rf.filtering() && !p.All {
+		rf.limit, rf.offset = p.Limit, p.Offset
+	}
+
+	// validate column names up front for tabular data. object rows are
+	// checked by key as they're read
+	if len(rf.columns) > 0 {
+		names := append([]string{}, p.Columns...)
+		for _, f := range p.Filters {
+			names = append(names, f.Column)
+		}
+		for _, s := range p.Sort {
+			names = append(names, s.Column)
+		}
+		for _, name := range names {
+			if indexOf(rf.columns, name) < 0 {
+				return nil, UnknownColumnError(name)
+			}
+		}
+	}
+
+	if len(p.Columns) > 0 && len(rf.columns) > 0 {
+		sch, err := projectSchema(st.Schema, rf.columns, p.Columns)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting schema columns: %s", err.Error())
+		}
+		rf.schema = sch
+	}
+
+	return rf, nil
+}
+
+// filtering is true when results may contain fewer rows than the source
+// or rows in a different order
+func (rf *rowFilter) filtering() bool {
+	return len(rf.filters) > 0 || len(rf.sort) > 0 || rf.distinct
+}
+
+// done is true once limit rows have been written. sorted rows aren't
+// written until every row is read
+func (rf *rowFilter) done() bool {
+	return len(rf.sort) == 0 && rf.limit >= 0 && rf.written >= rf.limit
+}
+
+// write checks a single row, passing matches on to the write func
+func (rf *rowFilter) write(val vals.Value, write func(vals.Value) error) error {
+	if !rf.filtering() && len(rf.project) == 0 {
+		return rf.emit(val, write)
+	}
+
+	row, err := rowValue(val)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range rf.filters {
+		if !f.Match(rf.column(row, f.Column)) {
+			return nil
+		}
+	}
+
+	if len(rf.project) > 0 {
+		if val, err = rf.projectValue(val); err != nil {
+			return err
+		}
+	}
+
+	if rf.distinct {
+		v, err := rowValue(val)
+		if err != nil {
+			return err
+		}
+		key := query.RowKey([]interface{}{v})
+		if rf.seen[key] {
+			return nil
+		}
+		rf.seen[key] = true
+	}
+
+	if len(rf.sort) > 0 {
+		keys := make([]interface{}, len(rf.sort))
+		for i, s := range rf.sort {
+			keys[i] = rf.column(row, s.Column)
+		}
+		rf.sorted = append(rf.sorted, sortRow{keys: keys, val: val})
+		return nil
+	}
+
+	return rf.emit(val, write)
+}
+
+// flush writes any buffered rows in sorted order
+func (rf *rowFilter) flush(write func(vals.Value) error) error {
+	if len(rf.sort) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(rf.sorted, func(a, b int) bool {
+		for i, s := range rf.sort {
+			cmp := query.CompareValues(rf.sorted[a].keys[i], rf.sorted[b].keys[i])
+			if cmp == 0 {
+				continue
+			}
+			if s.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	for _, r := range rf.sorted {
+		if err := rf.emit(r.val, write); err != nil {
+			return err
+		}
+	}
+	rf.sorted = nil
+	return nil
+}
+
+// emit applies offset & limit to matched rows
+func (rf *rowFilter) emit(val vals.Value, write func(vals.Value) error) error {
+	rf.matched++
+	if rf.matched <= rf.offset || (rf.limit >= 0 && rf.written >= rf.limit) {
+		return nil
+	}
+	rf.written++
+	return write(val)
+}
+
+// column gets a named value from a normalized row
+func (rf *rowFilter) column(row interface{}, name string) interface{} {
+	switch r := row.(type) {
+	case []interface{}:
+		if i := indexOf(rf.columns, name); i >= 0 && i < len(r) {
+			return r[i]
+		}
+	case map[string]interface{}:
+		return r[name]
+	}
+	return nil
+}
+
+// projectValue selects columns from a row value
+func (rf *rowFilter) projectValue(val vals.Value) (vals.Value, error) {
+	switch v := val.(type) {
+	case vals.Array:
+		arr := make(vals.Array, len(rf.project))
+		for i, name := range rf.project {
+			if idx := indexOf(rf.columns, name); idx >= 0 && idx < len(v) {
+				arr[i] = v[idx]
+			}
+		}
+		return arr, nil
+	case vals.Object:
+		obj := vals.Object{}
+		for _, name := range rf.project {
+			if e, ok := v[name]; ok {
+				obj[name] = e
+			}
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cannot select columns from non-tabular value: %v", val)
+}
+
+// rowValue converts a value into a normalized go value for comparison
+func rowValue(val vals.Value) (interface{}, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return query.Normalize(v), nil
+}
+
+// csvRecord converts a row of normalized values to csv fields
+func csvRecord(row []interface{}) ([]string, error) {
+	rec := make([]string, len(row))
+	for i, v := range row {
+		switch x := v.(type) {
+		case nil:
+		case string:
+			rec[i] = x
+		case float64:
+			rec[i] = strconv.FormatFloat(x, 'f', -1, 64)
+		case bool:
+			rec[i] = strconv.FormatBool(x)
+		default:
+			data, err := json.Marshal(x)
+			if err != nil {
+				return nil, err
+			}
+			rec[i] = string(data)
+		}
+	}
+	return rec, nil
+}
+
+// projectSchema creates a tabular schema with a subset of columns
+func projectSchema(sch *jsonschema.RootSchema, columns, project []string) (*jsonschema.RootSchema, error) {
+	data, err := sch.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	items, ok := doc["items"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema is not tabular")
+	}
+	fields, ok := items["items"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema is not tabular")
+	}
+
+	selected := make([]interface{}, len(project))
+	for i, name := range project {
+		if idx := indexOf(columns, name); idx >= 0 && idx < len(fields) {
+			selected[i] = fields[idx]
+		}
+	}
+	items["items"] = selected
+
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	projected := &jsonschema.RootSchema{}
+	if err := projected.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return projected, nil
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// checkSignature verifies the commit signature of a referenced dataset
+// against the data checksum recorded in its structure, recording the result
+// in the reference. Data isn't read, Verify & Add check data matches the
+// recorded checksum. When required, datasets without a valid signature are
+// an error
+func checkSignature(ref *repo.DatasetRef, required bool) error {
+	if ref.Dataset == nil {
+		return nil
+	}
+	status, err := repo.VerifyCommit(ref.Dataset, nil)
+	ref.SignatureStatus = status
+	if required && status != repo.SignatureValid {
+		return fmt.Errorf("rejecting %s commit %s: %s", status, ref.Path, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestCheckSignature(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	req.RequireSigned = true
+
+	res := repo.DatasetRef{}
+	if err := req.Get(&repo.DatasetRef{Peername: "peer", Name: "movies"}, &res); err != nil {
+		t.Errorf("error getting signed dataset: %s", err.Error())
+		return
+	}
+	if res.SignatureStatus != repo.SignatureValid {
+		t.Errorf("expected created dataset to be validly signed, got: %s", res.SignatureStatus)
+	}
+
+	hr := NewHistoryRequests(mr, nil)
+	hr.RequireSigned = true
+	log := []repo.DatasetRef{}
+	if err := hr.Log(&LogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}}, &log); err != nil {
+		t.Errorf("error getting signed log: %s", err.Error())
+	}
+
+	cases := []struct {
+		ref      repo.DatasetRef
+		required bool
+		status   repo.SignatureStatus
+		err      string
+	}{
+		{repo.DatasetRef{Path: "/map/a", Dataset: &dataset.Dataset{}}, false, repo.SignatureUnsigned, ""},
+		{repo.DatasetRef{Path: "/map/a", Dataset: &dataset.Dataset{}}, true, repo.SignatureUnsigned, "rejecting unsigned commit /map/a: commit is not signed"},
+		{repo.DatasetRef{Path: "/map/a", Dataset: &dataset.Dataset{Commit: &dataset.Commit{Signature: "bad"}}}, true, repo.SignatureInvalid, "rejecting invalid commit /map/a: commit has no author"},
+	}
+
+	for i, c := range cases {
+		err := checkSignature(&c.ref, c.required)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.ref.SignatureStatus != c.status {
+			t.Errorf("case %d status mismatch. expected: %s, got: %s", i, c.status, c.ref.SignatureStatus)
+		}
+	}
+}
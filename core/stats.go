@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/stats"
+)
+
+// Stats gets column-level statistics for a dataset. Stats are calculated
+// when datasets are created or saved, datasets without stats have them
+// calculated & stored on first request
+func (r *DatasetRequests) Stats(p *repo.DatasetRef, res *stats.Stats) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Stats", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, p); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	if p.Path == "" {
+		ref, err := r.repo.GetRef(*p)
+		if err != nil {
+			return fmt.Errorf("error getting dataset: %s", err.Error())
+		}
+		p.Path = ref.Path
+	}
+	path := datastore.NewKey(p.Path)
+
+	statsPath, err := r.repo.StatsPath(path)
+	if err == repo.ErrNotFound {
+		s, err := writeStats(r.repo, path)
+		if err != nil {
+			return err
+		}
+		*res = *s
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error getting stats path: %s", err.Error())
+	}
+
+	f, err := r.repo.Store().Get(statsPath)
+	if err != nil {
+		return fmt.Errorf("error loading stats: %s", err.Error())
+	}
+	// stats of encrypted datasets are encrypted with the dataset's key
+	if f, err = repo.DecryptFile(r.repo, f); err != nil {
+		return fmt.Errorf("error decrypting stats: %s", err.Error())
+	}
+	defer f.Close()
+	s := &stats.Stats{}
+	if err := json.NewDecoder(f).Decode(s); err != nil {
+		return fmt.Errorf("error decoding stats: %s", err.Error())
+	}
+	*res = *s
+	return nil
+}
+
+// writeStats calculates stats for a stored dataset, writing them to the store
+func writeStats(r repo.Repo, path datastore.Key) (*stats.Stats, error) {
+	ds, err := repo.LoadDataset(r, path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	keyID, err := repo.DataKeyID(r.Store(), ds)
+	if err != nil {
+		return nil, err
+	}
+	file, err := repo.LoadData(r, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer file.Close()
+
+	s, err := calcStats(ds.Structure, file)
+	if err != nil {
+		return nil, err
+	}
+	return s, putStats(r, path, s, keyID)
+}
+
+// writeDataStats calculates stats from raw data, writing them to the store.
+// stats are encrypted if keyID is set
+func writeDataStats(r repo.Repo, path datastore.Key, st *dataset.Structure, data []byte, keyID string) error {
+	s, err := calcStats(st, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return putStats(r, path, s, keyID)
+}
+
+// calcStats streams data through a stats accumulator
+func calcStats(st *dataset.Structure, data io.Reader) (*stats.Stats, error) {
+	rr, err := dsio.NewValueReader(st, data)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+
+	acc := stats.NewAccumulator(schemaColumnTitles(st))
+	err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+		v, err := rowValue(val)
+		if err != nil {
+			return err
+		}
+		acc.WriteRow(v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calculating stats: %s", err.Error())
+	}
+	return acc.Stats(), nil
+}
+
+// putStats stores stats as a content-addressed file, indexed by dataset path.
+// stats summarize data, so they're encrypted with the data key of encrypted
+// datasets
+func putStats(r repo.Repo, path datastore.Key, s *stats.Stats, keyID string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error encoding stats: %s", err.Error())
+	}
+	if keyID != "" {
+		key, err := r.DataKey(keyID)
+		if err != nil {
+			return err
+		}
+		if data, err = repo.Encrypt(key, keyID, data); err != nil {
+			return fmt.Errorf("error encrypting stats: %s", err.Error())
+		}
+	}
+	key, err := r.Store().Put(memfs.NewMemfileBytes("stats.json", data), true)
+	if err != nil {
+		return fmt.Errorf("error putting stats in store: %s", err.Error())
+	}
+	if err := r.PutStatsPath(path, key); err != nil {
+		return fmt.Errorf("error recording stats path: %s", err.Error())
+	}
+	return nil
+}
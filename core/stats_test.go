@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/stats"
+)
+
+func TestDatasetRequestsStats(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		ref  *repo.DatasetRef
+		rows int
+		err  string
+	}{
+		{&repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}, 0, "error getting dataset: repo: not found"},
+		{&repo.DatasetRef{Peername: "peer", Name: "cities"}, 5, ""},
+		// second request reads stored stats
+		{&repo.DatasetRef{Peername: "peer", Name: "cities"}, 5, ""},
+		{&repo.DatasetRef{Peername: "peer", Name: "counter"}, 20, ""},
+	}
+
+	for i, c := range cases {
+		got := &stats.Stats{}
+		err := req.Stats(c.ref, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Rows != c.rows {
+			t.Errorf("case %d rows mismatch. expected: %d, got: %d", i, c.rows, got.Rows)
+		}
+		if _, err := mr.StatsPath(datastore.NewKey(c.ref.Path)); err != nil {
+			t.Errorf("case %d expected stats path to be recorded: %s", i, err.Error())
+		}
+	}
+
+	cities := &stats.Stats{}
+	if err := req.Stats(&repo.DatasetRef{Peername: "peer", Name: "cities"}, cities); err != nil {
+		t.Errorf("error getting cities stats: %s", err.Error())
+		return
+	}
+	pop := cities.Column("pop")
+	if pop == nil {
+		t.Errorf("expected cities stats to have a pop column")
+		return
+	}
+	if pop.Type != "number" || pop.Min != 35000.0 || pop.Max != 40000000.0 {
+		t.Errorf("pop stats mismatch. got type: %s, min: %v, max: %v", pop.Type, pop.Min, pop.Max)
+	}
+	if usa := cities.Column("in_usa"); usa == nil || usa.Type != "boolean" || usa.Distinct != 2 {
+		t.Errorf("in_usa stats mismatch. got: %v", usa)
+	}
+
+	// saving a new dataset records stats at creation time
+	ref := &repo.DatasetRef{}
+	p := &InitParams{
+		Peername:     "me",
+		Name:         "tasks",
+		DataFilename: "tasks.csv",
+		Data:         memfs.NewMemfileBytes("tasks.csv", []byte("task,hours\nwrite,2\nreview,1\n")),
+	}
+	if err := req.Init(p, ref); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	if _, err := mr.StatsPath(datastore.NewKey(ref.Path)); err != nil {
+		t.Errorf("expected init to record stats: %s", err.Error())
+	}
+}
@@ -0,0 +1,463 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/qri/repo"
+)
+
+const (
+	// StreamFormatNDJSON writes one JSON value per line
+	StreamFormatNDJSON = "ndjson"
+	// StreamFormatCSV writes comma-separated values
+	StreamFormatCSV = "csv"
+	// streamFlushRows is the number of rows written between flushes
+	streamFlushRows = 100
+)
+
+// DataCursor marks a position in a specific version of a dataset's data.
+// Cursors pin the dataset path, so paging through data with cursors gives
+// consistent results even if the dataset is updated mid-read
+type DataCursor struct {
+	// Ref is the peername/name of the dataset the cursor was given for.
+	// Resuming checks Path is a version of Ref, so visibility can be checked
+	// against the named dataset
+	Ref  string `json:"n,omitempty"`
+	Path string `json:"p"`
+	Row  int    `json:"r"`
+	// Offset is the byte offset of Row in the data, so resuming seeks to Row
+	// instead of reading every row before it. Only csv data has offsets,
+	// resuming other formats reads from the first row
+	Offset int64 `json:"o,omitempty"`
+}
+
+// String encodes the cursor as an opaque, url-safe token
+func (c DataCursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseDataCursor decodes a cursor token
+func ParseDataCursor(str string) (DataCursor, error) {
+	c := DataCursor{}
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Path == "" || c.Row < 0 || c.Offset < 0 {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// StreamDataParams defines parameters for streaming dataset data
+type StreamDataParams struct {
+	// Path of the dataset to read. ignored if Cursor is set
+	Path datastore.Key
+	// Ref is the peername/name of the dataset at Path, recorded in cursors.
+	// ignored if Cursor is set
+	Ref string
+	// Format is one of StreamFormatNDJSON (default) or StreamFormatCSV
+	Format string
+	// Cursor continues a previous read. optional.
+	Cursor string
+	// Start is the index of the first row to write. ignored if Cursor is set
+	Start int
+	// Limit is the max number of rows to write, -1 for no limit
+	Limit int
+}
+
+// DataStream is a prepared read of dataset data that can be written to any
+// io.Writer. Details like the next cursor are available before writing,
+// so they can be sent ahead of data (eg. as http headers)
+type DataStream struct {
+	Ref     string
+	Path    datastore.Key
+	Format  string
+	Start   int
+	Limit   int
+	Entries int
+
+	repo    repo.Repo
+	dataset *dataset.Dataset
+	// offset is the byte offset reading starts from, the offset of row base
+	offset int64
+	base   int
+	// next is the byte offset of the row after the stream, zero if unknown
+	next int64
+}
+
+// StreamData prepares a stream of dataset data. It's only available
+// locally, as RPC cannot stream responses
+func (r *DatasetRequests) StreamData(p *StreamDataParams) (*DataStream, error) {
+	if r.cli != nil {
+		return nil, fmt.Errorf("streaming data is not supported over RPC")
+	}
+
+	ref, path, start := p.Ref, p.Path, p.Start
+	offset := int64(0)
+	if p.Cursor != "" {
+		c, err := ParseDataCursor(p.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Ref != "" {
+			if err := r.checkCursorRef(c); err != nil {
+				return nil, err
+			}
+		}
+		ref, path, start, offset = c.Ref, datastore.NewKey(c.Path), c.Row, c.Offset
+	}
+	if path.String() == "" || path.String() == "/" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("invalid start row: %d", start)
+	}
+
+	format := p.Format
+	if format == "" {
+		format = StreamFormatNDJSON
+	}
+	if format != StreamFormatNDJSON && format != StreamFormatCSV {
+		return nil, fmt.Errorf("invalid stream format: '%s'. must be one of: %s, %s", format, StreamFormatNDJSON, StreamFormatCSV)
+	}
+
+	ds, err := repo.LoadDataset(r.repo, path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("dataset has no structure")
+	}
+
+	s := &DataStream{
+		Ref:     ref,
+		Path:    path,
+		Format:  format,
+		Start:   start,
+		Limit:   p.Limit,
+		Entries: ds.Structure.Entries,
+		repo:    r.repo,
+		dataset: ds,
+	}
+	if ds.Structure.Format != dataset.CSVDataFormat {
+		return s, nil
+	}
+	if offset > 0 {
+		s.offset, s.base = offset, start
+	}
+	// the next cursor is given before data is written, so the offset of the
+	// row after the stream is found ahead of time, reading only this stream's
+	// rows
+	if s.Limit >= 0 {
+		if s.next, err = s.nextOffset(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// nextOffset finds the byte offset of the row following the stream in csv
+// data
+func (s *DataStream) nextOffset() (int64, error) {
+	file, err := repo.LoadData(s.repo, s.dataset)
+	if err != nil {
+		return 0, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	rows := s.Start - s.base + s.Limit
+	if s.offset > 0 {
+		if _, err := io.CopyN(ioutil.Discard, br, s.offset); err != nil {
+			return 0, fmt.Errorf("error seeking data: %s", err.Error())
+		}
+	} else if csvHeaderRow(s.dataset.Structure) {
+		rows++
+	}
+
+	read, err := skipCSVRecords(br, rows)
+	if err != nil {
+		return 0, fmt.Errorf("error reading data: %s", err.Error())
+	}
+	return s.offset + read, nil
+}
+
+// NextCursor gives a cursor to the row following this stream, or an
+// empty string if the stream reaches the end of the data
+func (s *DataStream) NextCursor() string {
+	if s.Limit < 0 {
+		return ""
+	}
+	next := s.Start + s.Limit
+	// when entries are known we can tell if data is exhausted
+	if s.Entries > 0 && next >= s.Entries {
+		return ""
+	}
+	return DataCursor{Ref: s.Ref, Path: s.Path.String(), Row: next, Offset: s.next}.String()
+}
+
+// checkCursorRef errors unless a cursor's path is a version of the dataset
+// the cursor names
+func (r *DatasetRequests) checkCursorRef(c DataCursor) error {
+	ref, err := repo.ParseDatasetRef(c.Ref)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	head, err := r.repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	versions, err := historyVersions(r.repo.Store(), head.Path)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v == c.Path {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid cursor")
+}
+
+// ContentType gives the mime type for the stream format
+func (s *DataStream) ContentType() string {
+	if s.Format == StreamFormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// Write streams rows to w, flushing periodically if w implements
+// http.Flusher-style Flush(). Rows are read directly from the data file,
+// nothing beyond the current row is held in memory
+func (s *DataStream) Write(w io.Writer) (rows int, err error) {
+	file, err := repo.LoadData(s.repo, s.dataset)
+	if err != nil {
+		return 0, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer file.Close()
+
+	var data io.Reader = file
+	if s.offset > 0 {
+		if data, err = seekCSVRecord(file, s.offset, csvHeaderRow(s.dataset.Structure)); err != nil {
+			return 0, err
+		}
+	}
+
+	rr, err := dsio.NewValueReader(s.dataset.Structure, data)
+	if err != nil {
+		return 0, fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+
+	var (
+		bw      = bufio.NewWriter(w)
+		cw      *csv.Writer
+		flusher interface{ Flush() }
+	)
+	if f, ok := w.(interface{ Flush() }); ok {
+		flusher = f
+	}
+	flush := func() error {
+		if cw != nil {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	if s.Format == StreamFormatCSV {
+		cw = csv.NewWriter(bw)
+		if header := schemaColumnTitles(s.dataset.Structure); len(header) > 0 {
+			if err := cw.Write(header); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+		// rows read after seeking are counted from the row seeked to
+		if s.base+i < s.Start {
+			return nil
+		}
+		if s.Limit >= 0 && rows >= s.Limit {
+			return errStreamDone
+		}
+
+		if cw != nil {
+			v, err := rowValue(val)
+			if err != nil {
+				return err
+			}
+			row, ok := v.([]interface{})
+			if !ok {
+				row = []interface{}{v}
+			}
+			rec, err := csvRecord(row)
+			if err != nil {
+				return err
+			}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		} else {
+			data, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			if _, err := bw.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+
+		rows++
+		if rows%streamFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && err != errStreamDone {
+		return rows, fmt.Errorf("row iteration error: %s", err.Error())
+	}
+
+	return rows, flush()
+}
+
+// errStreamDone stops row iteration once a stream's limit is reached
+var errStreamDone = fmt.Errorf("stream done")
+
+// csvHeaderRow checks if csv data starts with a header row
+func csvHeaderRow(st *dataset.Structure) bool {
+	opts, ok := st.FormatConfig.(*dataset.CSVOptions)
+	return ok && opts.HeaderRow
+}
+
+// readCSVRecord reads the raw bytes of a single csv record, including its
+// line ending. quoted fields can hold newlines
+func readCSVRecord(br *bufio.Reader) ([]byte, error) {
+	var (
+		rec    []byte
+		quoted bool
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		rec = append(rec, line...)
+		// escaped quotes come in pairs, leaving quoting as it was
+		if bytes.Count(line, []byte{'"'})%2 == 1 {
+			quoted = !quoted
+		}
+		if err != nil || !quoted {
+			return rec, err
+		}
+	}
+}
+
+// skipCSVRecords reads past n csv records, giving the number of bytes read.
+// reaching the end of data isn't an error
+func skipCSVRecords(br *bufio.Reader, n int) (int64, error) {
+	read := int64(0)
+	for i := 0; i < n; i++ {
+		rec, err := readCSVRecord(br)
+		read += int64(len(rec))
+		if err == io.EOF {
+			return read, nil
+		} else if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+// seekCSVRecord gives a reader of csv data starting at the record at offset,
+// keeping any header row so the data reads the same way
+func seekCSVRecord(r io.Reader, offset int64, header bool) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	var head []byte
+	if header {
+		var err error
+		if head, err = readCSVRecord(br); err != nil {
+			return nil, fmt.Errorf("error reading header row: %s", err.Error())
+		}
+	}
+	if offset < int64(len(head)) {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if _, err := io.CopyN(ioutil.Discard, br, offset-int64(len(head))); err != nil {
+		return nil, fmt.Errorf("error seeking data: %s", err.Error())
+	}
+	return io.MultiReader(bytes.NewReader(head), br), nil
+}
+
+// RowRange is a window of rows, similar to an HTTP byte range
+type RowRange struct {
+	Start int
+	// End is the inclusive index of the last row, -1 for open-ended ranges
+	End int
+}
+
+// ParseRowRange reads a Range header value in the form "rows=[start]-[end]",
+// eg: "rows=0-99" for the first hundred rows, "rows=100-" for all rows after
+// the first hundred. Only a single range is supported
+func ParseRowRange(str string) (RowRange, error) {
+	rr := RowRange{End: -1}
+	if !strings.HasPrefix(str, "rows=") {
+		return rr, fmt.Errorf("invalid range unit, only 'rows' is supported")
+	}
+	spec := strings.TrimPrefix(str, "rows=")
+	if strings.Contains(spec, ",") {
+		return rr, fmt.Errorf("multiple ranges are not supported")
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return rr, fmt.Errorf("invalid range: '%s'", str)
+	}
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil || start < 0 {
+		return rr, fmt.Errorf("invalid range start: '%s'", parts[0])
+	}
+	rr.Start = start
+
+	if parts[1] != "" {
+		end, err := strconv.Atoi(parts[1])
+		if err != nil || end < start {
+			return rr, fmt.Errorf("invalid range end: '%s'", parts[1])
+		}
+		rr.End = end
+	}
+	return rr, nil
+}
+
+// Limit gives the number of rows in the range, -1 if open-ended
+func (rr RowRange) Limit() int {
+	if rr.End < 0 {
+		return -1
+	}
+	return rr.End - rr.Start + 1
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestParseDataCursor(t *testing.T) {
+	c := DataCursor{Ref: "peer/cities", Path: "/map/QmFoo", Row: 20, Offset: 512}
+	got, err := ParseDataCursor(c.String())
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if got != c {
+		t.Errorf("cursor mismatch. expected: %v, got: %v", c, got)
+	}
+
+	for i, str := range []string{"", "bad cursor", DataCursor{}.String(), DataCursor{Path: "/a", Row: -1}.String(), DataCursor{Path: "/a", Offset: -1}.String()} {
+		if _, err := ParseDataCursor(str); err == nil {
+			t.Errorf("case %d expected error parsing cursor '%s'", i, str)
+		}
+	}
+}
+
+func TestParseRowRange(t *testing.T) {
+	cases := []struct {
+		str        string
+		start, end int
+		limit      int
+		err        string
+	}{
+		{"rows=0-9", 0, 9, 10, ""},
+		{"rows=10-", 10, -1, -1, ""},
+		{"rows=5-5", 5, 5, 1, ""},
+		{"bytes=0-9", 0, 0, 0, "invalid range unit, only 'rows' is supported"},
+		{"rows=0-1,5-6", 0, 0, 0, "multiple ranges are not supported"},
+		{"rows=-5", 0, 0, 0, "invalid range: 'rows=-5'"},
+		{"rows=a-5", 0, 0, 0, "invalid range start: 'a'"},
+		{"rows=5-1", 0, 0, 0, "invalid range end: '1'"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseRowRange(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Start != c.start || got.End != c.end || got.Limit() != c.limit {
+			t.Errorf("case %d mismatch. expected: %d-%d (%d), got: %d-%d (%d)", i, c.start, c.end, c.limit, got.Start, got.End, got.Limit())
+		}
+	}
+}
+
+func TestDatasetRequestsStreamData(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	ref, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Errorf("error getting cities ref: %s", err.Error())
+		return
+	}
+	path := datastore.NewKey(ref.Path)
+
+	cases := []struct {
+		p      *StreamDataParams
+		expect string
+		rows   int
+		next   bool
+		err    string
+	}{
+		{&StreamDataParams{}, "", 0, false, "path is required"},
+		{&StreamDataParams{Path: path, Format: "xml"}, "", 0, false, "invalid stream format: 'xml'. must be one of: ndjson, csv"},
+		{&StreamDataParams{Cursor: "nope"}, "", 0, false, "invalid cursor"},
+		{&StreamDataParams{Path: path, Start: 1, Limit: 2}, "[\"new york\",8500000,44.4,true]\n[\"chicago\",300000,44.4,true]\n", 2, true, ""},
+		{&StreamDataParams{Path: path, Format: StreamFormatCSV, Start: 3, Limit: 5}, "city,pop,avg_age,in_usa\nchatham,35000,65.25,true\nraleigh,250000,50.65,true\n", 2, false, ""},
+		{&StreamDataParams{Cursor: DataCursor{Path: ref.Path, Row: 4}.String(), Limit: -1}, "[\"raleigh\",250000,50.65,true]\n", 1, false, ""},
+		{&StreamDataParams{Cursor: DataCursor{Ref: "peer/cities", Path: ref.Path, Row: 4}.String(), Limit: -1}, "[\"raleigh\",250000,50.65,true]\n", 1, false, ""},
+		{&StreamDataParams{Cursor: DataCursor{Ref: "peer/movies", Path: ref.Path, Row: 4}.String(), Limit: -1}, "", 0, false, "invalid cursor"},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		stream, err := req.StreamData(c.p)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		rows, err := stream.Write(buf)
+		if err != nil {
+			t.Errorf("case %d unexpected error writing stream: %s", i, err.Error())
+			continue
+		}
+		if rows != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, rows)
+		}
+		if buf.String() != c.expect {
+			t.Errorf("case %d output mismatch. expected:\n%s\ngot:\n%s", i, c.expect, buf.String())
+		}
+		if (stream.NextCursor() != "") != c.next {
+			t.Errorf("case %d expected next cursor: %t, got: '%s'", i, c.next, stream.NextCursor())
+		}
+	}
+
+	// paging with cursors reads every row once, seeking to each page
+	paged := &bytes.Buffer{}
+	p := &StreamDataParams{Path: path, Limit: 2}
+	for page := 0; page < 5; page++ {
+		stream, err := req.StreamData(p)
+		if err != nil {
+			t.Fatalf("page %d error preparing stream: %s", page, err.Error())
+		}
+		if _, err := stream.Write(paged); err != nil {
+			t.Fatalf("page %d error writing stream: %s", page, err.Error())
+		}
+		next := stream.NextCursor()
+		if next == "" {
+			break
+		}
+		if c, err := ParseDataCursor(next); err != nil || c.Offset == 0 {
+			t.Errorf("page %d expected next cursor to have an offset, got: %v", page, c)
+		}
+		p = &StreamDataParams{Cursor: next, Limit: 2}
+	}
+	all := &bytes.Buffer{}
+	stream, err := req.StreamData(&StreamDataParams{Path: path, Limit: -1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := stream.Write(all); err != nil {
+		t.Fatal(err.Error())
+	}
+	if paged.String() != all.String() {
+		t.Errorf("paged output mismatch. expected:\n%s\ngot:\n%s", all.String(), paged.String())
+	}
+
+	// resuming trusts the cursor's offset over reading up to its row
+	first, err := req.StreamData(&StreamDataParams{Path: path, Limit: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c, _ := ParseDataCursor(first.NextCursor())
+	c.Row = 4
+	seeked, err := req.StreamData(&StreamDataParams{Cursor: c.String(), Limit: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	buf := &bytes.Buffer{}
+	if _, err := seeked.Write(buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	if expect := "[\"chicago\",300000,44.4,true]\n"; buf.String() != expect {
+		t.Errorf("expected stream to seek to the cursor offset. expected: %s, got: %s", expect, buf.String())
+	}
+}
+
+func TestSkipCSVRecords(t *testing.T) {
+	data := "a,b\n\"multi\nline\",1\n\"say \"\"hi\"\"\",2\nlast,3"
+	cases := []struct {
+		n    int
+		read int64
+	}{
+		{0, 0},
+		{1, 4},
+		{2, 19},
+		{3, 34},
+		{4, 40},
+		{10, 40},
+	}
+	for i, c := range cases {
+		read, err := skipCSVRecords(bufio.NewReader(strings.NewReader(data)), c.n)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if read != c.read {
+			t.Errorf("case %d bytes read mismatch. expected: %d, got: %d", i, c.read, read)
+		}
+	}
+}
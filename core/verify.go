@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/qri/repo"
+)
+
+const (
+	// ComponentDataset is the root dataset document
+	ComponentDataset = "dataset"
+	// ComponentStructure is the dataset structure
+	ComponentStructure = "structure"
+	// ComponentData is the dataset data file
+	ComponentData = "data"
+	// ComponentCommit is the dataset commit & its signature
+	ComponentCommit = "commit"
+)
+
+// VerifyParams defines parameters for dataset verification
+type VerifyParams struct {
+	Ref repo.DatasetRef
+	// History verifies every version in the dataset's PreviousPath chain
+	History bool
+}
+
+// VerifyProblem describes an integrity failure in a dataset component
+type VerifyProblem struct {
+	// Component is one of ComponentDataset, ComponentStructure, ComponentData
+	// or ComponentCommit
+	Component string
+	Message   string
+}
+
+// String implements the stringer interface
+func (p VerifyProblem) String() string {
+	return p.Component + ": " + p.Message
+}
+
+// VerifyResult reports the integrity of a single dataset version
+type VerifyResult struct {
+	Path     string
+	Problems []VerifyProblem
+	// Locked is true when a dataset is encrypted with a key this peer
+	// doesn't have, so neither its components nor its data can be checked
+	Locked bool
+	// Signature is the status of the commit signature, checked against data
+	// when it can be read
+	Signature repo.SignatureStatus
+}
+
+// OK is true when no problems were found
+func (v VerifyResult) OK() bool {
+	return len(v.Problems) == 0
+}
+
+// Verify recomputes data checksums, lengths & entry counts for a dataset,
+// comparing them to values recorded in the dataset structure, and checks
+// commit signatures against the checksum of data. Verification
+// problems are reported in results, not as an error
+func (r *DatasetRequests) Verify(p *VerifyParams, res *[]VerifyResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Verify", p, res)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	if p.Ref.Path == "" {
+		ref, err := r.repo.GetRef(p.Ref)
+		if err != nil {
+			return fmt.Errorf("error getting dataset: %s", err.Error())
+		}
+		p.Ref.Path = ref.Path
+	}
+
+	results := []VerifyResult{}
+	path := p.Ref.Path
+	for path != "" && path != "/" {
+		vr, prev := verifyVersion(r.repo, datastore.NewKey(path))
+		results = append(results, vr)
+		if !p.History {
+			break
+		}
+		path = prev
+	}
+
+	*res = results
+	return nil
+}
+
+// verifyVersion checks a single dataset version, returning the path to
+// the previous version
+func verifyVersion(r repo.Repo, path datastore.Key) (VerifyResult, string) {
+	vr := VerifyResult{Path: path.String()}
+
+	doc, err := repo.LoadDatasetDocument(r.Store(), path)
+	if err != nil {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentDataset, fmt.Sprintf("error loading dataset: %s", err.Error())})
+		return vr, ""
+	}
+	if keyID, err := repo.DataKeyID(r.Store(), doc); err == nil && keyID != "" {
+		if _, err := r.DataKey(keyID); err != nil {
+			vr.Locked = true
+			vr.Problems = append(vr.Problems, VerifyProblem{ComponentData, err.Error()})
+			return vr, doc.PreviousPath
+		}
+	}
+
+	ds, err := repo.LoadDataset(r, path)
+	if err != nil {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentDataset, fmt.Sprintf("error loading dataset: %s", err.Error())})
+		return vr, doc.PreviousPath
+	}
+	// data that can't be read is checked against its recorded checksum
+	vr.Signature, _ = repo.VerifyCommit(ds, nil)
+	if ds.Structure == nil {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentStructure, "dataset has no structure"})
+		return vr, ds.PreviousPath
+	}
+
+	file, err := repo.LoadData(r, ds)
+	if err != nil {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentData, fmt.Sprintf("error loading data: %s", err.Error())})
+		return vr, ds.PreviousPath
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentData, fmt.Sprintf("error reading data: %s", err.Error())})
+		return vr, ds.PreviousPath
+	}
+
+	vr.Problems = append(vr.Problems, checkData(ds.Structure, data)...)
+	vr.Signature, err = repo.VerifyCommit(ds, data)
+	if vr.Signature == repo.SignatureInvalid {
+		vr.Problems = append(vr.Problems, VerifyProblem{ComponentCommit, err.Error()})
+	}
+	return vr, ds.PreviousPath
+}
+
+// checkData compares data to the length, checksum & entry count recorded
+// in a structure. values a structure doesn't record aren't checked
+func checkData(st *dataset.Structure, data []byte) (problems []VerifyProblem) {
+	if st.Length > 0 && st.Length != len(data) {
+		problems = append(problems, VerifyProblem{ComponentData, fmt.Sprintf("length mismatch. structure records %d bytes, data is %d bytes", st.Length, len(data))})
+	}
+
+	if st.Checksum != "" {
+		sum, err := repo.DataChecksum(data)
+		if err != nil {
+			problems = append(problems, VerifyProblem{ComponentData, fmt.Sprintf("error calculating checksum: %s", err.Error())})
+		} else if sum != st.Checksum {
+			problems = append(problems, VerifyProblem{ComponentData, fmt.Sprintf("checksum mismatch. structure records %s, data hashes to %s", st.Checksum, sum)})
+		}
+	}
+
+	if st.Entries > 0 {
+		entries, err := countEntries(st, data)
+		if err != nil {
+			problems = append(problems, VerifyProblem{ComponentData, fmt.Sprintf("error reading entries: %s", err.Error())})
+		} else if entries != st.Entries {
+			problems = append(problems, VerifyProblem{ComponentData, fmt.Sprintf("entry count mismatch. structure records %d entries, data has %d", st.Entries, entries)})
+		}
+	}
+	return
+}
+
+// countEntries counts the top-level entries in data
+func countEntries(st *dataset.Structure, data []byte) (int, error) {
+	rr, err := dsio.NewValueReader(st, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	entries := 0
+	err = dsio.EachValue(rr, func(i int, val vals.Value, err error) error {
+		if err != nil {
+			return err
+		}
+		entries++
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsVerify(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	data := []byte("a,b\n1,2\n")
+	sum, err := repo.DataChecksum(data)
+	if err != nil {
+		t.Errorf("error calculating checksum: %s", err.Error())
+		return
+	}
+
+	// two versions, the latest recording the wrong checksum
+	ds1 := &dataset.Dataset{
+		Commit:    &dataset.Commit{Title: "initial commit"},
+		Structure: &dataset.Structure{Format: dataset.CSVDataFormat, Checksum: sum, Length: len(data)},
+	}
+	ds1p, err := dsfs.WriteDataset(mr.Store(), ds1, memfs.NewMemfileBytes("data.csv", data), true)
+	if err != nil {
+		t.Errorf("error writing dataset: %s", err.Error())
+		return
+	}
+	ds2 := &dataset.Dataset{
+		Commit:       &dataset.Commit{Title: "corrupt"},
+		Structure:    &dataset.Structure{Format: dataset.CSVDataFormat, Checksum: "QmBadChecksum", Length: len(data) + 1},
+		PreviousPath: ds1p.String(),
+	}
+	ds2p, err := dsfs.WriteDataset(mr.Store(), ds2, memfs.NewMemfileBytes("data.csv", data), true)
+	if err != nil {
+		t.Errorf("error writing dataset: %s", err.Error())
+		return
+	}
+	if err := mr.PutRef(repo.DatasetRef{Peername: "peer", Name: "corrupt", Path: ds2p.String()}); err != nil {
+		t.Errorf("error putting ref: %s", err.Error())
+		return
+	}
+
+	// a signed version with metadata changed after it was signed
+	movies, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+	forged, err := dsfs.LoadDataset(mr.Store(), datastore.NewKey(movies.Path))
+	if err != nil {
+		t.Errorf("error loading movies: %s", err.Error())
+		return
+	}
+	forged.Meta = &dataset.Meta{Title: "forged"}
+	moviesData, err := dsfs.LoadData(mr.Store(), forged)
+	if err != nil {
+		t.Errorf("error loading movies data: %s", err.Error())
+		return
+	}
+	forgedp, err := dsfs.WriteDataset(mr.Store(), forged, moviesData, true)
+	if err != nil {
+		t.Errorf("error writing dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p        *VerifyParams
+		problems []int
+		err      string
+	}{
+		{&VerifyParams{Ref: repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}}, nil, "error getting dataset: repo: not found"},
+		{&VerifyParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}}, []int{0}, ""},
+		{&VerifyParams{Ref: repo.DatasetRef{Peername: "peer", Name: "corrupt"}}, []int{2}, ""},
+		{&VerifyParams{Ref: repo.DatasetRef{Peername: "peer", Name: "corrupt"}, History: true}, []int{2, 0}, ""},
+		{&VerifyParams{Ref: repo.DatasetRef{Path: ds1p.String()}}, []int{0}, ""},
+		{&VerifyParams{Ref: repo.DatasetRef{Path: forgedp.String()}}, []int{1}, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := []VerifyResult{}
+		err := req.Verify(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != len(c.problems) {
+			t.Errorf("case %d result length mismatch. expected: %d, got: %d", i, len(c.problems), len(got))
+			continue
+		}
+		for j, n := range c.problems {
+			if len(got[j].Problems) != n {
+				t.Errorf("case %d result %d problem count mismatch. expected: %d, got: %d: %v", i, j, n, len(got[j].Problems), got[j].Problems)
+			}
+		}
+	}
+
+	got := []VerifyResult{}
+	if err := req.Verify(&VerifyParams{Ref: movies}, &got); err != nil {
+		t.Errorf("error verifying movies: %s", err.Error())
+		return
+	}
+	if got[0].Signature != repo.SignatureValid {
+		t.Errorf("expected movies signature to be valid, got: %s", got[0].Signature)
+	}
+
+	problems := checkData(ds2.Structure, data)
+	expect := []string{
+		"data: length mismatch. structure records 9 bytes, data is 8 bytes",
+		"data: checksum mismatch. structure records QmBadChecksum, data hashes to " + sum,
+	}
+	for i, e := range expect {
+		if i >= len(problems) || problems[i].String() != e {
+			t.Errorf("problem %d mismatch. expected: %s, got: %v", i, e, problems)
+		}
+	}
+}
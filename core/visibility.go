@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// ShareParams defines parameters for changing dataset visibility
+type ShareParams struct {
+	Ref repo.DatasetRef
+	// Public makes a dataset visible to all peers, removing any shares
+	Public bool
+	// Private hides a dataset from all peers, removing any shares
+	Private bool
+	// With lists ids of peers to share a dataset with, making it private
+	// to everyone else
+	With []string
+	// Revoke lists ids of peers to stop sharing a dataset with
+	Revoke []string
+}
+
+// Share sets which peers can see a dataset. Without any changes Share
+// reports the current visibility of a dataset
+func (r *DatasetRequests) Share(p *ShareParams, res *repo.Visibility) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Share", p, res)
+	}
+
+	if p.Public && (p.Private || len(p.With) > 0) {
+		return fmt.Errorf("a dataset can't be both public and shared with peers")
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	ref, err := r.repo.GetRef(p.Ref)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+
+	v, err := r.repo.RefVisibility(ref)
+	if err != nil {
+		return fmt.Errorf("error getting visibility: %s", err.Error())
+	}
+
+	changed := p.Public || p.Private || len(p.With) > 0 || len(p.Revoke) > 0
+	if p.Public || p.Private {
+		v = repo.Visibility{Private: p.Private}
+	}
+	if len(p.With) > 0 {
+		v.Private = true
+		for _, id := range p.With {
+			if indexOf(v.SharedWith, id) < 0 {
+				v.SharedWith = append(v.SharedWith, id)
+			}
+		}
+	}
+	for _, id := range p.Revoke {
+		if i := indexOf(v.SharedWith, id); i >= 0 {
+			v.SharedWith = append(v.SharedWith[:i], v.SharedWith[i+1:]...)
+		}
+	}
+
+	if changed {
+		if err := r.repo.PutRefVisibility(ref, v); err != nil {
+			return fmt.Errorf("error setting visibility: %s", err.Error())
+		}
+		if v.Private {
+			if err := unindex(r.repo, ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	*res = v
+	return nil
+}
+
+// setPrivate hides a dataset from other peers, keeping existing shares
+func setPrivate(r repo.Repo, ref repo.DatasetRef) error {
+	v, err := r.RefVisibility(ref)
+	if err != nil {
+		return fmt.Errorf("error getting visibility: %s", err.Error())
+	}
+	v.Private = true
+	if err := r.PutRefVisibility(ref, v); err != nil {
+		return fmt.Errorf("error setting visibility: %s", err.Error())
+	}
+	return unindex(r, ref)
+}
+
+// unindex removes a dataset from a repo's search index, so private datasets
+// can't be found with search
+func unindex(r repo.Repo, ref repo.DatasetRef) error {
+	i, ok := r.(repo.Indexer)
+	if !ok {
+		return nil
+	}
+	if err := i.Unindex(ref); err != nil {
+		return fmt.Errorf("error removing dataset from search index: %s", err.Error())
+	}
+	return nil
+}
+
+// moveVisibility carries visibility over to a renamed reference. it's called
+// between removing the old ref & adding the new one, so a private dataset is
+// never re-added to the search index
+func moveVisibility(r repo.Repo, from, to repo.DatasetRef) error {
+	v, err := r.RefVisibility(from)
+	if err != nil {
+		return fmt.Errorf("error getting visibility: %s", err.Error())
+	}
+	if !v.Private {
+		return nil
+	}
+	if err := r.PutRefVisibility(to, v); err != nil {
+		return fmt.Errorf("error setting visibility: %s", err.Error())
+	}
+	return r.PutRefVisibility(from, repo.Visibility{})
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/fs"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsShare(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	movies := repo.DatasetRef{Peername: "peer", Name: "movies"}
+
+	cases := []struct {
+		p          *ShareParams
+		visibility string
+		sharedWith int
+		err        string
+	}{
+		{&ShareParams{Ref: repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}}, "", 0, "error getting dataset: repo: not found"},
+		{&ShareParams{Ref: movies, Public: true, Private: true}, "", 0, "a dataset can't be both public and shared with peers"},
+		{&ShareParams{Ref: movies}, repo.VisibilityPublic, 0, ""},
+		{&ShareParams{Ref: movies, Private: true}, repo.VisibilityPrivate, 0, ""},
+		{&ShareParams{Ref: movies, With: []string{"QmA", "QmB", "QmA"}}, repo.VisibilityShared, 2, ""},
+		{&ShareParams{Ref: movies, Revoke: []string{"QmA"}}, repo.VisibilityShared, 1, ""},
+		{&ShareParams{Ref: movies, Revoke: []string{"QmB"}}, repo.VisibilityPrivate, 0, ""},
+		{&ShareParams{Ref: movies, Public: true}, repo.VisibilityPublic, 0, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.Visibility{}
+		err := req.Share(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.String() != c.visibility {
+			t.Errorf("case %d visibility mismatch. expected: %s, got: %s", i, c.visibility, got.String())
+		}
+		if len(got.SharedWith) != c.sharedWith {
+			t.Errorf("case %d shared peers mismatch. expected: %d, got: %d", i, c.sharedWith, len(got.SharedWith))
+		}
+	}
+}
+
+func TestDatasetRequestsListPrivate(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	ref := &repo.DatasetRef{}
+	if err := req.Init(&InitParams{
+		Peername:     "peer",
+		Name:         "secrets",
+		DataFilename: "secrets.csv",
+		Data:         strings.NewReader("key,value\na,1\n"),
+		Private:      true,
+	}, ref); err != nil {
+		t.Errorf("error initializing private dataset: %s", err.Error())
+		return
+	}
+
+	all := []repo.DatasetRef{}
+	if err := req.List(&ListParams{Limit: 100}, &all); err != nil {
+		t.Errorf("error listing datasets: %s", err.Error())
+		return
+	}
+	public := []repo.DatasetRef{}
+	if err := req.List(&ListParams{Limit: 100, PublicOnly: true}, &public); err != nil {
+		t.Errorf("error listing public datasets: %s", err.Error())
+		return
+	}
+	if len(all) != len(public)+1 {
+		t.Errorf("expected public listing to hide one dataset. all: %d, public: %d", len(all), len(public))
+	}
+	for _, r := range all {
+		if r.Name == "secrets" && r.Visibility != repo.VisibilityPrivate {
+			t.Errorf("expected secrets to be listed as private, got: '%s'", r.Visibility)
+		}
+	}
+	for _, r := range public {
+		if r.Name == "secrets" {
+			t.Errorf("expected secrets to be hidden from public listing")
+		}
+	}
+
+	// renaming keeps a dataset private
+	renamed := &repo.DatasetRef{}
+	if err := req.Rename(&RenameParams{Current: repo.DatasetRef{Peername: "peer", Name: "secrets"}, New: repo.DatasetRef{Peername: "peer", Name: "hidden"}}, renamed); err != nil {
+		t.Errorf("error renaming dataset: %s", err.Error())
+		return
+	}
+	if ok, _ := repo.CanView(mr, repo.DatasetRef{Peername: "peer", Name: "hidden"}, ""); ok {
+		t.Errorf("expected renamed dataset to stay private")
+	}
+	if ok, _ := repo.CanView(mr, repo.DatasetRef{Peername: "peer", Name: "secrets"}, ""); !ok {
+		t.Errorf("expected old name to be public after rename")
+	}
+}
+
+func TestSearchPrivate(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_test_search_private")
+	defer os.RemoveAll(path)
+	r, err := fsrepo.NewRepo(memfs.NewMapstore(), path, "test_repo_id")
+	if err != nil {
+		t.Errorf("error creating repo: %s", err.Error())
+		return
+	}
+	pk, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Errorf("error generating key: %s", err.Error())
+		return
+	}
+	if err := r.SetPrivateKey(pk); err != nil {
+		t.Errorf("error setting private key: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(r, nil)
+	ref := repo.DatasetRef{Peername: "peer", Name: "plans"}
+	if err := req.Init(&InitParams{
+		Peername:     ref.Peername,
+		Name:         ref.Name,
+		DataFilename: "plans.csv",
+		Data:         strings.NewReader("key,value\na,1\n"),
+		Metadata:     strings.NewReader(`{"title":"secret plans"}`),
+	}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	search := NewSearchRequests(r, nil)
+	cases := []struct {
+		change  func() error
+		results int
+	}{
+		{func() error { return nil }, 1},
+		{func() error { return req.Share(&ShareParams{Ref: ref, Private: true}, &repo.Visibility{}) }, 0},
+		{func() error { return req.Share(&ShareParams{Ref: ref, Public: true}, &repo.Visibility{}) }, 1},
+		{func() error { return req.Share(&ShareParams{Ref: ref, With: []string{"QmFriend"}}, &repo.Visibility{}) }, 0},
+		{func() error { return req.Share(&ShareParams{Ref: ref, Public: true}, &repo.Visibility{}) }, 1},
+		{func() error {
+			return req.Save(&SaveParams{
+				Prev:    ref,
+				Changes: &dataset.Dataset{Commit: &dataset.Commit{Title: "revise plans"}, Meta: &dataset.Meta{Title: "secret plans, revised"}},
+				Private: true,
+			}, &repo.DatasetRef{})
+		}, 0},
+	}
+
+	for i, c := range cases {
+		if err := c.change(); err != nil {
+			t.Errorf("case %d error changing dataset: %s", i, err.Error())
+			continue
+		}
+		got := []repo.DatasetRef{}
+		if err := search.Search(&repo.SearchParams{Q: "secret", Limit: 10}, &got); err != nil {
+			t.Errorf("case %d error searching: %s", i, err.Error())
+			continue
+		}
+		if len(got) != c.results {
+			t.Errorf("case %d result count mismatch. expected: %d, got: %d", i, c.results, len(got))
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qri/repo"
+)
+
+// DefaultWatchDebounce is how long a watched file must go unchanged before
+// it's saved. Spreadsheet programs often write a file several times when
+// saving
+const DefaultWatchDebounce = time.Second * 2
+
+// WatchParams defines parameters for watching a file for changes
+type WatchParams struct {
+	// Ref is the dataset to save new versions of
+	Ref repo.DatasetRef
+	// Path of the data file to watch
+	Path string
+	// Debounce is how long to wait for changes to settle before saving.
+	// defaults to DefaultWatchDebounce
+	Debounce time.Duration
+	// Strict skips saving data that doesn't validate against the dataset's
+	// schema
+	Strict bool
+}
+
+// WatchEvent describes the result of a watched file changing
+type WatchEvent struct {
+	// Ref is the saved version, empty if the change was skipped
+	Ref repo.DatasetRef
+	// Skipped gives the reason a change wasn't saved
+	Skipped string
+	// Err is set when saving failed
+	Err error
+}
+
+// Watch saves a new version of a dataset each time a data file changes,
+// calling saved after each change. Watch blocks until done is closed.
+// Watching isn't available over RPC, a watch has to run in the process
+// that owns the repo
+func (r *DatasetRequests) Watch(p *WatchParams, done <-chan bool, saved func(WatchEvent)) error {
+	if r.cli != nil {
+		return fmt.Errorf("watching a file isn't supported over RPC, stop qri connect or use qri connect --watch")
+	}
+
+	w, err := r.newFileWatch(p)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %s", err.Error())
+	}
+	defer watcher.Close()
+	// watch the directory, editors often replace files instead of writing
+	// to them, which drops watches on the file itself
+	if err := watcher.Add(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("error watching %s: %s", p.Path, err.Error())
+	}
+
+	debounce := p.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case e := <-watcher.Events:
+			if filepath.Clean(e.Name) != w.path || e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			timer.Reset(debounce)
+		case err := <-watcher.Errors:
+			saved(WatchEvent{Err: fmt.Errorf("error watching %s: %s", p.Path, err.Error())})
+		case <-timer.C:
+			saved(w.save())
+		}
+	}
+}
+
+// fileWatch tracks the last saved state of a watched file
+type fileWatch struct {
+	req  *DatasetRequests
+	p    *WatchParams
+	path string
+	// hash & data of the last saved version
+	hash [sha256.Size]byte
+	data []byte
+}
+
+func (r *DatasetRequests) newFileWatch(p *WatchParams) (*fileWatch, error) {
+	if p.Path == "" {
+		return nil, fmt.Errorf("a file to watch is required")
+	}
+	path, err := filepath.Abs(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &p.Ref); err != nil {
+		return nil, fmt.Errorf("error canonicalizing dataset reference: %s", err.Error())
+	}
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&p.Ref, ref); err != nil {
+		return nil, fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	f, err := repo.LoadData(r.repo, ref.Dataset)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data: %s", err.Error())
+	}
+
+	return &fileWatch{
+		req:  r,
+		p:    p,
+		path: path,
+		hash: sha256.Sum256(data),
+		data: data,
+	}, nil
+}
+
+// save saves the watched file as a new version if it's changed
+func (w *fileWatch) save() WatchEvent {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return WatchEvent{Err: fmt.Errorf("error reading %s: %s", w.p.Path, err.Error())}
+	}
+	hash := sha256.Sum256(data)
+	if hash == w.hash {
+		return WatchEvent{Skipped: "data is unchanged"}
+	}
+
+	filename := filepath.Base(w.path)
+	if w.p.Strict {
+		errs := []jsonschema.ValError{}
+		if err := w.req.Validate(&ValidateDatasetParams{
+			Ref:          w.p.Ref,
+			DataFilename: filename,
+			Data:         bytes.NewReader(data),
+		}, &errs); err != nil {
+			return WatchEvent{Skipped: fmt.Sprintf("data doesn't validate: %s", err.Error())}
+		}
+		if len(errs) > 0 {
+			return WatchEvent{Skipped: fmt.Sprintf("data has %d validation errors", len(errs))}
+		}
+	}
+
+	res := &repo.DatasetRef{}
+	if err := w.req.Save(&SaveParams{
+		Prev: w.p.Ref,
+		Changes: &dataset.Dataset{
+			Commit: &dataset.Commit{
+				Title:   fmt.Sprintf("auto-save %s", filename),
+				Message: diffSummary(w.data, data),
+			},
+		},
+		DataFilename: filename,
+		Data:         bytes.NewReader(data),
+	}, res); err != nil {
+		return WatchEvent{Err: fmt.Errorf("error saving dataset: %s", err.Error())}
+	}
+
+	w.hash, w.data = hash, data
+	w.p.Ref.Path = res.Path
+	return WatchEvent{Ref: *res}
+}
+
+// diffSummary counts lines added & removed between two versions of a
+// file, ignoring order. For row-per-line formats like CSV lines are rows
+func diffSummary(prev, next []byte) string {
+	counts := map[string]int{}
+	for _, line := range strings.Split(string(prev), "\n") {
+		counts[line]++
+	}
+	added := 0
+	for _, line := range strings.Split(string(next), "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+			continue
+		}
+		added++
+	}
+	removed := 0
+	for _, c := range counts {
+		removed += c
+	}
+	return fmt.Sprintf("%s added, %s removed", plural(added, "row"), plural(removed, "row"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDiffSummary(t *testing.T) {
+	cases := []struct {
+		prev, next string
+		expect     string
+	}{
+		{"a\n1\n", "a\n1\n", "0 rows added, 0 rows removed"},
+		{"a\n1\n", "a\n1\n2\n", "1 row added, 0 rows removed"},
+		{"a\n1\n2\n", "a\n3\n", "1 row added, 2 rows removed"},
+		{"a\n1\n2\n", "a\n2\n1\n", "0 rows added, 0 rows removed"},
+	}
+
+	for i, c := range cases {
+		if got := diffSummary([]byte(c.prev), []byte(c.next)); got != c.expect {
+			t.Errorf("case %d mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestFileWatchSave(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	initial := "id,name\n1,a\n"
+	if err := req.Init(&InitParams{
+		Peername:     "me",
+		Name:         "letters",
+		DataFilename: "letters.csv",
+		Data:         strings.NewReader(initial),
+	}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "qri_watch")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "letters.csv")
+
+	w, err := req.newFileWatch(&WatchParams{
+		Ref:    repo.DatasetRef{Peername: "me", Name: "letters"},
+		Path:   path,
+		Strict: true,
+	})
+	if err != nil {
+		t.Errorf("error creating watch: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		data    string
+		saved   bool
+		message string
+	}{
+		{initial, false, ""},
+		{"id,name\n1,a\n2,b\n", true, "1 row added, 0 rows removed"},
+		{"id,name\n1,a\n2,b\n", false, ""},
+		{"id,name\nnot_a_number,a\n", false, ""},
+		{"id,name\n2,b\n", true, "0 rows added, 1 row removed"},
+	}
+
+	for i, c := range cases {
+		if err := ioutil.WriteFile(path, []byte(c.data), os.ModePerm); err != nil {
+			t.Fatal(err.Error())
+		}
+		e := w.save()
+		if e.Err != nil {
+			t.Errorf("case %d unexpected error: %s", i, e.Err.Error())
+			continue
+		}
+		if saved := e.Skipped == ""; saved != c.saved {
+			t.Errorf("case %d saved mismatch. expected: %t, got: %t (%s)", i, c.saved, saved, e.Skipped)
+			continue
+		}
+		if !c.saved {
+			continue
+		}
+		if msg := e.Ref.Dataset.Commit.Message; msg != c.message {
+			t.Errorf("case %d commit message mismatch. expected: '%s', got: '%s'", i, c.message, msg)
+		}
+	}
+}
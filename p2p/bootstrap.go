@@ -39,7 +39,7 @@ func (n *QriNode) Bootstrap(boostrapAddrs []string, boostrapPeers chan pstore.Pe
 		go func(p pstore.PeerInfo) {
 			n.log.Infof("boostrapping to: %s", p.ID.Pretty())
 			if err := n.Host.Connect(context.Background(), p); err == nil {
-				if err = n.AddQriPeer(p); err != nil {
+				if err = n.AddQriPeer(n.ctx, p); err != nil {
 					n.log.Infof("error adding peer: %s", err.Error())
 				} else {
 					boostrapPeers <- p
@@ -0,0 +1,214 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+// DefaultBroadcastWorkers is the number of peers a broadcast messages at once
+const DefaultBroadcastWorkers = 8
+
+// BroadcastParams configures a broadcast
+type BroadcastParams struct {
+	// Workers bounds the number of peers messaged at once, defaults to
+	// DefaultBroadcastWorkers
+	Workers int
+	// PeerTimeout is the deadline for each peer to respond, defaults to the
+	// time a request takes to use all of its retries
+	PeerTimeout time.Duration
+	// Enough ends the broadcast once this many peers have responded without
+	// error. zero waits for every peer
+	Enough int
+}
+
+// BroadcastResponse is a single peer's response to a broadcast
+type BroadcastResponse struct {
+	Peer peer.ID
+	Res  *Message
+	Err  error
+}
+
+// Broadcast sends a message to peers, streaming each peer's response as it
+// arrives. Peers are deduplicated & messaged in a stable order, this node is
+// skipped. The returned channel closes once every peer has responded, Enough
+// responses have arrived, or ctx is done. Callers that stop reading early
+// should cancel ctx
+func (n *QriNode) Broadcast(ctx context.Context, peers []peer.ID, msg *Message, p BroadcastParams) <-chan BroadcastResponse {
+	if p.PeerTimeout == 0 {
+		p.PeerTimeout = n.retryBudget()
+	}
+	var self peer.ID
+	if n.Host != nil {
+		self = n.Host.ID()
+	}
+
+	return fanOut(ctx, uniquePeers(peers, self), p, func(ctx context.Context, pid peer.ID) (*Message, error) {
+		// each peer gets its own copy, sending sets the message phase
+		m := *msg
+		return n.SendMessage(ctx, pid, &m)
+	})
+}
+
+// BroadcastMessage sends a message to all connected peers, gathering the
+// responses that arrive before the context deadline. Responses are ordered
+// by peer, not by arrival
+func (n *QriNode) BroadcastMessage(ctx context.Context, msg *Message) ([]*Message, error) {
+	peers := n.connectedPeers()
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers connected")
+	}
+
+	if ctx == nil {
+		ctx = n.ctx
+	}
+	// without a deadline of its own a broadcast gathers responses for as
+	// long as a request can take to use all of its retries
+	if _, ok := ctx.Deadline(); !ok {
+		if budget := n.retryBudget(); budget > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, budget)
+			defer cancel()
+		}
+	}
+
+	n.log.Infof("broadcasting %s message to %d peers", msg.Type, len(peers))
+	byPeer := map[peer.ID]*Message{}
+	for r := range n.Broadcast(ctx, peers, msg, BroadcastParams{}) {
+		if r.Err != nil {
+			n.log.Infof("broadcast to %s failed: %s", r.Peer.Pretty(), r.Err.Error())
+			continue
+		}
+		byPeer[r.Peer] = r.Res
+	}
+
+	res := make([]*Message, 0, len(byPeer))
+	for _, pid := range peers {
+		if r, ok := byPeer[pid]; ok {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+// connectedPeers lists connected qri peers, without duplicates or this node
+func (n *QriNode) connectedPeers() []peer.ID {
+	var self peer.ID
+	if n.Host != nil {
+		self = n.Host.ID()
+	}
+	return uniquePeers(n.QriPeers.Peers(), self)
+}
+
+// gather reads broadcast responses as they arrive, passing each successful
+// response to take, which gives the number of results the response carried.
+// gather returns once limit results have arrived, limit <= 0 reads every
+// response. Callers cancel the broadcast when gather returns
+func gather(responses <-chan BroadcastResponse, limit int, take func(res *Message) int) {
+	results := 0
+	for r := range responses {
+		if r.Err != nil {
+			continue
+		}
+		results += take(r.Res)
+		if limit > 0 && results >= limit {
+			return
+		}
+	}
+}
+
+// uniquePeers drops duplicates & self from a list of peers, sorting the
+// rest so broadcasts message peers in a stable order
+func uniquePeers(peers []peer.ID, self peer.ID) []peer.ID {
+	seen := map[peer.ID]bool{self: true}
+	unique := make([]peer.ID, 0, len(peers))
+	for _, pid := range peers {
+		if !seen[pid] {
+			seen[pid] = true
+			unique = append(unique, pid)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
+
+// fanOut calls send for each peer from a bounded pool of workers, streaming
+// the results
+func fanOut(ctx context.Context, peers []peer.ID, p BroadcastParams, send func(ctx context.Context, pid peer.ID) (*Message, error)) <-chan BroadcastResponse {
+	out := make(chan BroadcastResponse)
+	ctx, cancel := context.WithCancel(ctx)
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = DefaultBroadcastWorkers
+	}
+	if workers > len(peers) {
+		workers = len(peers)
+	}
+
+	jobs := make(chan peer.ID)
+	results := make(chan BroadcastResponse)
+	wg := sync.WaitGroup{}
+
+	go func() {
+		defer close(jobs)
+		for _, pid := range peers {
+			select {
+			case jobs <- pid:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for pid := range jobs {
+				pctx, pcancel := ctx, context.CancelFunc(func() {})
+				if p.PeerTimeout > 0 {
+					pctx, pcancel = context.WithTimeout(ctx, p.PeerTimeout)
+				}
+				res, err := send(pctx, pid)
+				pcancel()
+
+				select {
+				case results <- BroadcastResponse{Peer: pid, Res: res, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		defer cancel()
+		responded := 0
+		for r := range results {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+			if r.Err == nil {
+				responded++
+				if p.Enough > 0 && responded >= p.Enough {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
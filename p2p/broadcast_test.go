@@ -0,0 +1,154 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+func TestUniquePeers(t *testing.T) {
+	got := uniquePeers([]peer.ID{"c", "a", "self", "b", "a", "c"}, "self")
+	expect := []peer.ID{"a", "b", "c"}
+	if len(got) != len(expect) {
+		t.Fatalf("length mismatch. expected: %d, got: %d", len(expect), len(got))
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("index %d mismatch. expected: %s, got: %s", i, expect[i], got[i])
+		}
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	peers := []peer.ID{"a", "b", "c", "d", "e", "f"}
+
+	// slow peers never respond, erroring at their deadline
+	send := func(slow map[peer.ID]bool, mu *sync.Mutex, active, max *int) func(ctx context.Context, pid peer.ID) (*Message, error) {
+		return func(ctx context.Context, pid peer.ID) (*Message, error) {
+			mu.Lock()
+			*active++
+			if *active > *max {
+				*max = *active
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				*active--
+				mu.Unlock()
+			}()
+
+			if slow[pid] {
+				<-ctx.Done()
+				return nil, ErrTimeout
+			}
+			time.Sleep(time.Millisecond * 5)
+			return &Message{Type: MtPing, Phase: MpResponse}, nil
+		}
+	}
+
+	cases := []struct {
+		p         BroadcastParams
+		slow      map[peer.ID]bool
+		responses int
+		errs      int
+	}{
+		{BroadcastParams{Workers: 2}, nil, 6, 0},
+		{BroadcastParams{Workers: 3, PeerTimeout: time.Millisecond * 20}, map[peer.ID]bool{"b": true, "e": true}, 4, 2},
+		{BroadcastParams{Workers: 1, Enough: 2}, nil, 2, 0},
+	}
+
+	for i, c := range cases {
+		mu := &sync.Mutex{}
+		active, max := 0, 0
+		responses, errs := 0, 0
+		for r := range fanOut(context.Background(), peers, c.p, send(c.slow, mu, &active, &max)) {
+			if r.Err != nil {
+				errs++
+			} else {
+				responses++
+			}
+		}
+
+		if responses != c.responses {
+			t.Errorf("case %d responses mismatch. expected: %d, got: %d", i, c.responses, responses)
+		}
+		if errs != c.errs {
+			t.Errorf("case %d errors mismatch. expected: %d, got: %d", i, c.errs, errs)
+		}
+		if max > c.p.Workers {
+			t.Errorf("case %d expected at most %d peers messaged at once, got: %d", i, c.p.Workers, max)
+		}
+	}
+}
+
+func TestFanOutCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	block := func(ctx context.Context, pid peer.ID) (*Message, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("cancelled")
+	}
+
+	out := fanOut(ctx, []peer.ID{"a", "b"}, BroadcastParams{}, block)
+	cancel()
+
+	select {
+	case <-drain(out):
+	case <-time.After(time.Second):
+		t.Errorf("expected cancelling to end the broadcast")
+	}
+}
+
+func TestGather(t *testing.T) {
+	// each peer responds with as many results as its name is long
+	results := func(ctx context.Context, pid peer.ID) (*Message, error) {
+		if pid == "err" {
+			return nil, ErrRefused
+		}
+		names := make([]string, len(pid))
+		return &Message{Type: MtNodes, Phase: MpResponse, Payload: &names}, nil
+	}
+	count := func(got *int) func(res *Message) int {
+		return func(res *Message) int {
+			names, ok := res.Payload.(*[]string)
+			if !ok {
+				return 0
+			}
+			*got += len(*names)
+			return len(*names)
+		}
+	}
+
+	cases := []struct {
+		limit  int
+		expect int
+	}{
+		{0, 10},
+		{1, 1},
+		{3, 3},
+		{4, 6},
+		{20, 10},
+	}
+	for i, c := range cases {
+		ctx, cancel := context.WithCancel(context.Background())
+		got := 0
+		gather(fanOut(ctx, []peer.ID{"a", "bb", "ccc", "dddd", "err"}, BroadcastParams{Workers: 1}, results), c.limit, count(&got))
+		cancel()
+		if got != c.expect {
+			t.Errorf("case %d results mismatch. expected: %d, got: %d", i, c.expect, got)
+		}
+	}
+}
+
+func drain(out <-chan BroadcastResponse) <-chan bool {
+	done := make(chan bool)
+	go func() {
+		for range out {
+		}
+		done <- true
+	}()
+	return done
+}
@@ -3,6 +3,7 @@ package p2p
 import (
 	"crypto/rand"
 	"fmt"
+	"time"
 
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/cafs/ipfs"
@@ -36,6 +37,16 @@ type NodeCfg struct {
 	// Online is a flag for weather this node should connect
 	// to the distributed network
 	Online bool
+	// RequestTimeout is the deadline for each attempt at a request to a peer,
+	// within the request's own context. zero means attempts only end with
+	// their context
+	RequestTimeout time.Duration
+	// RequestRetries is the number of extra attempts made at idempotent
+	// requests that time out or are refused, a request is sent at most
+	// RequestRetries + 1 times. non-idempotent requests are never retried
+	RequestRetries int
+	// RetryBackoff is the wait before the first retry, doubling each retry
+	RetryBackoff time.Duration
 }
 
 // DefaultNodeCfg generates sensible settings for a Qri Node
@@ -67,6 +78,9 @@ func DefaultNodeCfg() *NodeCfg {
 		// Port:     4444,
 		QriBootstrapAddrs: DefaultBootstrapAddresses,
 		Secure:            true,
+		RequestTimeout:    DefaultRequestTimeout,
+		RequestRetries:    DefaultRequestRetries,
+		RetryBackoff:      DefaultRetryBackoff,
 	}
 }
 
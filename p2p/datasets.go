@@ -1,20 +1,20 @@
 package p2p
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
 	"github.com/qri-io/qri/repo"
 )
 
 // RequestDatasetsList gets a list of a peer's datasets
-func (n *QriNode) RequestDatasetsList(peername string) ([]repo.DatasetRef, error) {
+func (n *QriNode) RequestDatasetsList(ctx context.Context, peername string) ([]repo.DatasetRef, error) {
 	id, err := n.Repo.Peers().IPFSPeerID(peername)
 	if err != nil {
 		return nil, fmt.Errorf("error getting peer IPFS id: %s", err.Error())
 	}
 
-	res, err := n.SendMessage(id, &Message{
+	res, err := n.SendMessage(ctx, id, &Message{
 		Type:    MtDatasets,
 		Phase:   MpRequest,
 		Payload: nil,
@@ -25,24 +25,21 @@ func (n *QriNode) RequestDatasetsList(peername string) ([]repo.DatasetRef, error
 		return nil, err
 	}
 
-	data, err := json.Marshal(res.Payload)
-	if err != nil {
-		return nil, err
+	refs, ok := res.Payload.(*[]repo.DatasetRef)
+	if !ok {
+		return nil, PayloadTypeError{Type: MtDatasets, Payload: res.Payload}
 	}
-
-	ref := []repo.DatasetRef{}
-	err = json.Unmarshal(data, &ref)
-	return ref, err
+	return *refs, nil
 }
 
 // RequestDatasetInfo get's qri profile information from a PeerInfo
-func (n *QriNode) RequestDatasetInfo(ref *repo.DatasetRef) (*repo.DatasetRef, error) {
+func (n *QriNode) RequestDatasetInfo(ctx context.Context, ref *repo.DatasetRef) (*repo.DatasetRef, error) {
 	id, err := n.Repo.Peers().IPFSPeerID(ref.Peername)
 	if err != nil {
 		return nil, fmt.Errorf("error getting peer IPFS id: %s", err.Error())
 	}
 
-	res, err := n.SendMessage(id, &Message{
+	res, err := n.SendMessage(ctx, id, &Message{
 		Type:    MtDatasetInfo,
 		Phase:   MpRequest,
 		Payload: ref,
@@ -53,24 +50,20 @@ func (n *QriNode) RequestDatasetInfo(ref *repo.DatasetRef) (*repo.DatasetRef, er
 		return nil, err
 	}
 
-	data, err := json.Marshal(res.Payload)
-	if err != nil {
-		return nil, err
+	resref, ok := res.Payload.(*repo.DatasetRef)
+	if !ok {
+		return nil, PayloadTypeError{Type: MtDatasetInfo, Payload: res.Payload}
 	}
-
-	resref := &repo.DatasetRef{}
-	err = json.Unmarshal(data, resref)
-
-	return resref, err
+	return resref, nil
 }
 
 // RequestDatasetLog gets the log information of Peer's dataset
-func (n *QriNode) RequestDatasetLog(ref repo.DatasetRef) (*[]repo.DatasetRef, error) {
+func (n *QriNode) RequestDatasetLog(ctx context.Context, ref repo.DatasetRef) (*[]repo.DatasetRef, error) {
 	id, err := n.Repo.Peers().IPFSPeerID(ref.Peername)
 	if err != nil {
 		return nil, fmt.Errorf("error getting peer IPFS id: %s", err.Error())
 	}
-	res, err := n.SendMessage(id, &Message{
+	res, err := n.SendMessage(ctx, id, &Message{
 		Type:    MtDatasetLog,
 		Phase:   MpRequest,
 		Payload: ref,
@@ -80,16 +73,12 @@ func (n *QriNode) RequestDatasetLog(ref repo.DatasetRef) (*[]repo.DatasetRef, er
 		return nil, err
 	}
 
-	data, err := json.Marshal(res.Payload)
-	if err != nil {
-		return nil, err
+	resref, ok := res.Payload.(*[]repo.DatasetRef)
+	if !ok {
+		return nil, PayloadTypeError{Type: MtDatasetLog, Payload: res.Payload}
 	}
-
-	resref := []repo.DatasetRef{}
-	err = json.Unmarshal(data, &resref)
-	if len(resref) == 0 && err != nil {
-		err = fmt.Errorf("no log found")
+	if len(*resref) == 0 {
+		return nil, ErrNotFound
 	}
-
-	return &resref, err
+	return resref, nil
 }
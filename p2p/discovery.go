@@ -51,7 +51,7 @@ func (n *QriNode) HandlePeerFound(pinfo pstore.PeerInfo) {
 		}
 
 		if support {
-			if err := n.AddQriPeer(pinfo); err != nil {
+			if err := n.AddQriPeer(n.ctx, pinfo); err != nil {
 				fmt.Println(err.Error())
 			}
 		}
@@ -77,7 +77,7 @@ func (n *QriNode) SupportsQriProtocol(peer peer.ID) (bool, error) {
 
 	if err == nil {
 		for _, p := range protos {
-			if p == string(QriProtocolID) {
+			if p == string(QriProtocolIDv1) || p == string(QriProtocolID) {
 				return true, nil
 			}
 		}
@@ -92,7 +92,7 @@ func (n *QriNode) DiscoverPeerstoreQriPeers(store pstore.Peerstore) {
 		if _, err := n.Host.Peerstore().Get(pid, qriSupportKey); err == pstore.ErrNotFound {
 			if supports, err := n.SupportsQriProtocol(pid); err == nil && supports {
 				// TODO - slow this down plz
-				if err := n.AddQriPeer(store.PeerInfo(pid)); err != nil {
+				if err := n.AddQriPeer(n.ctx, store.PeerInfo(pid)); err != nil {
 					fmt.Println(err.Error())
 				}
 			}
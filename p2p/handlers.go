@@ -1,7 +1,7 @@
 package p2p
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
@@ -11,6 +11,7 @@ import (
 
 	pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
 	ma "gx/ipfs/QmXY77cVe7rVRQXZZQRioukUM7aRW3BTcAgJe12MCtb3Ji/go-multiaddr"
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
 )
 
 func (n *QriNode) handlePingRequest(r *Message) *Message {
@@ -22,13 +23,9 @@ func (n *QriNode) handlePingRequest(r *Message) *Message {
 
 func (n *QriNode) handlePeerInfoRequest(r *Message) *Message {
 	go func(r *Message) error {
-		data, err := json.Marshal(r.Payload)
-		if err != nil {
-			return nil
-		}
-		p := &profile.Profile{}
-		if err := json.Unmarshal(data, p); err != nil {
-			return err
+		p, ok := r.Payload.(*profile.Profile)
+		if !ok {
+			return fmt.Errorf("peer info request has no profile")
 		}
 
 		pid, err := p.IPFSPeerID()
@@ -59,13 +56,9 @@ func (n *QriNode) handlePeerInfoRequest(r *Message) *Message {
 }
 
 func (n *QriNode) handleProfileResponse(pi pstore.PeerInfo, r *Message) error {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		return err
-	}
-	p := &profile.Profile{}
-	if err := json.Unmarshal(data, p); err != nil {
-		return err
+	p, ok := r.Payload.(*profile.Profile)
+	if !ok {
+		return fmt.Errorf("peer info response has no profile")
 	}
 
 	// pinfo.Profile = p
@@ -96,17 +89,12 @@ func (n *QriNode) handleNodesRequest(r *Message) *Message {
 }
 
 func (n *QriNode) handleNodesResponse(r *Message) error {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		return err
-	}
-
-	res := []string{}
-	if err := json.Unmarshal(data, &res); err != nil {
-		return err
+	res, ok := r.Payload.(*[]string)
+	if !ok {
+		return fmt.Errorf("nodes response has no addresses")
 	}
 
-	for _, addr := range res {
+	for _, addr := range *res {
 		fmt.Println(addr)
 		a, err := ma.NewMultiaddr(addr)
 		if err != nil {
@@ -129,15 +117,9 @@ type PeersReqParams struct {
 }
 
 func (n *QriNode) handlePeersRequest(r *Message) *Message {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		n.log.Info(err.Error())
-		return nil
-	}
-	p := &PeersReqParams{}
-	if err := json.Unmarshal(data, p); err != nil {
-		n.log.Info("unmarshal peers request error:", err.Error())
-		return nil
+	p, ok := r.Payload.(*PeersReqParams)
+	if !ok {
+		p = &PeersReqParams{}
 	}
 
 	profiles, err := repo.QueryPeers(n.Repo.Peers(), query.Query{
@@ -158,14 +140,11 @@ func (n *QriNode) handlePeersRequest(r *Message) *Message {
 }
 
 func (n *QriNode) handlePeersResponse(r *Message) error {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		return err
-	}
-	peers := []*profile.Profile{}
-	if err := json.Unmarshal(data, &peers); err != nil {
-		return err
+	res, ok := r.Payload.(*[]*profile.Profile)
+	if !ok {
+		return fmt.Errorf("peers response has no peers")
 	}
+	peers := *res
 
 	// we can ignore this error b/c we might not be running IPFS,
 	ipfsPeerID, _ := n.IPFSPeerID()
@@ -199,26 +178,38 @@ type DatasetsReqParams struct {
 	Offset int
 }
 
-func (n *QriNode) handleDatasetsRequest(r *Message) *Message {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		n.log.Info(err.Error())
-		return nil
-	}
-	p := &DatasetsReqParams{}
-	if err := json.Unmarshal(data, p); err != nil {
-		n.log.Info("unmarshal dataset request error:", err.Error())
-		return nil
+func (n *QriNode) handleDatasetsRequest(pid peer.ID, r *Message) *Message {
+	p, ok := r.Payload.(*DatasetsReqParams)
+	if !ok {
+		p = &DatasetsReqParams{}
 	}
 
 	if p.Limit == 0 {
 		p.Limit = 50
 	}
-	refs, err := n.Repo.References(p.Limit, p.Offset)
+	count, err := n.Repo.RefCount()
+	if err != nil {
+		n.log.Info("repo names error:", err)
+		return nil
+	}
+	refs, err := n.Repo.References(count, 0)
 	if err != nil {
 		n.log.Info("repo names error:", err)
 		return nil
 	}
+	// page after hiding private refs, so pages are full
+	if refs, err = repo.VisibleRefs(n.Repo, refs, pid.Pretty()); err != nil {
+		n.log.Info("repo visibility error:", err)
+		return nil
+	}
+	if p.Offset > len(refs) {
+		p.Offset = len(refs)
+	}
+	refs = refs[p.Offset:]
+	if len(refs) > p.Limit {
+		refs = refs[:p.Limit]
+	}
+	n.recordListings(pid, refs)
 
 	// replies := make([]*repo.DatasetRef, p.Limit)
 	// i := 0
@@ -244,21 +235,31 @@ func (n *QriNode) handleDatasetsRequest(r *Message) *Message {
 }
 
 func (n *QriNode) handleDatasetsResponse(pi pstore.PeerInfo, r *Message) error {
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		return err
+	res, ok := r.Payload.(*[]repo.DatasetRef)
+	if !ok {
+		return fmt.Errorf("datasets response has no datasets")
 	}
-	ds := []*repo.DatasetRef{}
-	if err := json.Unmarshal(data, &ds); err != nil {
-		return err
+	ds := make([]*repo.DatasetRef, len(*res))
+	for i := range *res {
+		ds[i] = &(*res)[i]
 	}
 
 	return n.Repo.Cache().PutDatasets(ds)
 }
 
-// Search broadcasts a search request to all connected peers, aggregating results
-func (n *QriNode) Search(terms string, limit, offset int) (res []*repo.DatasetRef, err error) {
-	responses, err := n.BroadcastMessage(&Message{
+// Search broadcasts a search request to all connected peers, aggregating
+// results as they arrive. Search stops asking peers once limit results have
+// arrived
+func (n *QriNode) Search(ctx context.Context, terms string, limit, offset int) (res []*repo.DatasetRef, err error) {
+	peers := n.connectedPeers()
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers connected")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	datasets := []*repo.DatasetRef{}
+	responses := n.Broadcast(ctx, peers, &Message{
 		Phase: MpRequest,
 		Type:  MtSearch,
 		Payload: &repo.SearchParams{
@@ -266,38 +267,29 @@ func (n *QriNode) Search(terms string, limit, offset int) (res []*repo.DatasetRe
 			Limit:  limit,
 			Offset: offset,
 		},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	datasets := []*repo.DatasetRef{}
-
-	for _, r := range responses {
-		data, err := json.Marshal(r.Payload)
-		if err != nil {
-			return datasets, err
+	}, BroadcastParams{})
+	gather(responses, limit, func(r *Message) int {
+		refs, ok := r.Payload.(*[]repo.DatasetRef)
+		if !ok {
+			return 0
 		}
-		ds := []*repo.DatasetRef{}
-		if err := json.Unmarshal(data, &ds); err != nil {
-			return datasets, err
+		for i := range *refs {
+			datasets = append(datasets, &(*refs)[i])
 		}
-		datasets = append(datasets, ds...)
-	}
+		return len(*refs)
+	})
 
+	if limit > 0 && len(datasets) > limit {
+		datasets = datasets[:limit]
+	}
 	return datasets, nil
 }
 
-func (n *QriNode) handleSearchRequest(r *Message) *Message {
+func (n *QriNode) handleSearchRequest(pid peer.ID, r *Message) *Message {
 	n.log.Info("handling search request")
-	data, err := json.Marshal(r.Payload)
-	if err != nil {
-		n.log.Info(err.Error())
-		return nil
-	}
-	p := &repo.SearchParams{}
-	if err := json.Unmarshal(data, p); err != nil {
-		n.log.Info("unmarshal search request error:", err.Error())
+	p, ok := r.Payload.(*repo.SearchParams)
+	if !ok {
+		n.log.Info("search request has no search params")
 		return nil
 	}
 
@@ -308,6 +300,7 @@ func (n *QriNode) handleSearchRequest(r *Message) *Message {
 			n.log.Info("search error:", err.Error())
 			return nil
 		}
+		results = n.visibleSearchResults(pid, results)
 		return &Message{
 			Phase:   MpResponse,
 			Type:    MtSearch,
@@ -326,24 +319,51 @@ func (n *QriNode) handleSearchResponse(pi pstore.PeerInfo, m *Message) error {
 	return fmt.Errorf("not yet finished")
 }
 
-func (n *QriNode) handleDatasetInfoRequest(r *Message) *Message {
-	data, err := json.Marshal(r.Payload)
+// visibleSearchResults drops search results a peer can't see. private
+// datasets aren't indexed, this guards against a stale index
+func (n *QriNode) visibleSearchResults(pid peer.ID, results []repo.DatasetRef) []repo.DatasetRef {
+	visible := make([]repo.DatasetRef, 0, len(results))
+	for _, res := range results {
+		ref, err := n.Repo.GetRef(res)
+		if err != nil {
+			continue
+		}
+		if ok, err := repo.CanView(n.Repo, ref, pid.Pretty()); err != nil || !ok {
+			continue
+		}
+		visible = append(visible, res)
+	}
+	return visible
+}
+
+// getVisibleRef completes a reference, returning repo.ErrNotFound for
+// references a peer can't see so private datasets aren't revealed
+func (n *QriNode) getVisibleRef(pid peer.ID, ref repo.DatasetRef) (repo.DatasetRef, error) {
+	ref, err := n.Repo.GetRef(ref)
 	if err != nil {
-		n.log.Info(err.Error())
-		return nil
+		return ref, err
+	}
+	ok, err := repo.CanView(n.Repo, ref, pid.Pretty())
+	if err != nil {
+		return ref, err
+	}
+	if !ok {
+		return repo.DatasetRef{}, repo.ErrNotFound
 	}
+	return ref, nil
+}
 
-	ref := repo.DatasetRef{}
-	if err = json.Unmarshal(data, &ref); err != nil {
-		n.log.Infof(err.Error())
+func (n *QriNode) handleDatasetInfoRequest(pid peer.ID, r *Message) *Message {
+	req, ok := r.Payload.(*repo.DatasetRef)
+	if !ok {
 		return &Message{
 			Type:    MtDatasetInfo,
 			Phase:   MpError,
-			Payload: err,
+			Payload: fmt.Errorf("dataset info request has no dataset reference"),
 		}
 	}
 
-	ref, err = n.Repo.GetRef(ref)
+	ref, err := n.getVisibleRef(pid, *req)
 	if err != nil {
 		return &Message{
 			Type:    MtDatasetInfo,
@@ -362,6 +382,7 @@ func (n *QriNode) handleDatasetInfoRequest(r *Message) *Message {
 	}
 
 	ref.Dataset = ds
+	n.recordListings(pid, []repo.DatasetRef{ref})
 
 	return &Message{
 		Type:    MtDatasetInfo,
@@ -374,25 +395,17 @@ func (n *QriNode) handleDatasetInfoResponse(m *Message) error {
 	return fmt.Errorf("not yet finished")
 }
 
-func (n *QriNode) handleDatasetLogRequest(r *Message) *Message {
-	data, err := json.Marshal(r.Payload)
-
-	if err != nil {
-		n.log.Info(err.Error())
-		return nil
-	}
-
-	ref := repo.DatasetRef{}
-	if err = json.Unmarshal(data, &ref); err != nil {
-		n.log.Infof(err.Error())
+func (n *QriNode) handleDatasetLogRequest(pid peer.ID, r *Message) *Message {
+	req, ok := r.Payload.(*repo.DatasetRef)
+	if !ok {
 		return &Message{
 			Type:    MtDatasetLog,
 			Phase:   MpError,
-			Payload: err,
+			Payload: fmt.Errorf("dataset log request has no dataset reference"),
 		}
 	}
 
-	ref, err = n.Repo.GetRef(ref)
+	ref, err := n.getVisibleRef(pid, *req)
 	if err != nil {
 		return &Message{
 			Type:    MtDatasetLog,
@@ -3,12 +3,12 @@ package p2p
 import (
 	"bufio"
 	"fmt"
-	"time"
 
 	net "gx/ipfs/QmNa31VPzC561NWwRsJLE7nGYZYuuD2QfpK2b1q9BK54J1/go-libp2p-net"
 	multicodec "gx/ipfs/QmU4qokxecGJBZPGmc4D9g2HdTyo8CPqUoZ2gwXKsQxqc9/go-multicodec"
+	cbor "gx/ipfs/QmU4qokxecGJBZPGmc4D9g2HdTyo8CPqUoZ2gwXKsQxqc9/go-multicodec/cbor"
 	json "gx/ipfs/QmU4qokxecGJBZPGmc4D9g2HdTyo8CPqUoZ2gwXKsQxqc9/go-multicodec/json"
-	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+	protocol "gx/ipfs/QmZNkThpqfVXs9GNbexPrfBbXSLNYeKrE7jwFM2oqHbyqN/go-libp2p-protocol"
 )
 
 // MsgType indicates the type of message being sent
@@ -38,6 +38,8 @@ const (
 	MtDatasetInfo = MsgType("DATASET_INFO")
 	// MtDatasetLog gets log of a dataset
 	MtDatasetLog = MsgType("DATASET_LOG")
+	// MtTombstone announces removed dataset versions
+	MtTombstone = MsgType("TOMBSTONE")
 )
 
 func (mt MsgType) String() string {
@@ -57,7 +59,8 @@ const (
 )
 
 // Message is a serializable/encodable object that we will send
-// on a Stream.
+// on a Stream. Received messages carry the payload type listed in
+// payloadSchemas for their type & phase, error phase payloads are errors
 type Message struct {
 	Type    MsgType
 	Phase   MsgPhase
@@ -70,10 +73,12 @@ type Message struct {
 // and bufios with us
 type WrappedStream struct {
 	stream net.Stream
-	enc    multicodec.Encoder
-	dec    multicodec.Decoder
-	w      *bufio.Writer
-	r      *bufio.Reader
+	// protocol is the wire protocol negotiated for the stream
+	protocol protocol.ID
+	enc      multicodec.Encoder
+	dec      multicodec.Decoder
+	w        *bufio.Writer
+	r        *bufio.Reader
 }
 
 // WrapStream takes a stream and complements it with r/w bufios and
@@ -85,17 +90,21 @@ type WrappedStream struct {
 func WrapStream(s net.Stream) *WrappedStream {
 	reader := bufio.NewReader(s)
 	writer := bufio.NewWriter(s)
-	// This is where we pick our specific multicodec. In order to change the
-	// codec, we only need to change this place.
-	// See https://godoc.org/github.com/multiformats/go-multicodec/json
-	dec := json.Multicodec(false).Decoder(reader)
-	enc := json.Multicodec(false).Encoder(writer)
+	// This is where we pick our specific multicodec, by the protocol the
+	// stream negotiated. QriProtocolIDv1 streams send cbor envelopes, the
+	// original QriProtocolID sends messages as json
+	// See https://godoc.org/github.com/multiformats/go-multicodec
+	codec := json.Multicodec(false)
+	if s.Protocol() == QriProtocolIDv1 {
+		codec = cbor.Multicodec()
+	}
 	return &WrappedStream{
-		stream: s,
-		r:      reader,
-		w:      writer,
-		enc:    enc,
-		dec:    dec,
+		stream:   s,
+		protocol: s.Protocol(),
+		r:        reader,
+		w:        writer,
+		enc:      codec.Encoder(writer),
+		dec:      codec.Decoder(reader),
 	}
 }
 
@@ -105,95 +114,24 @@ func (n *QriNode) MessageStreamHandler(s net.Stream) {
 	n.handleStream(WrapStream(s))
 }
 
-// SendMessage to a given multiaddr
-func (n *QriNode) SendMessage(pi peer.ID, msg *Message) (res *Message, err error) {
-	// TODO - do we need a timeout here?
-	// ctx, cancel := context.WithTimeout(n.ctx, time.Second*60)
-	// defer cancel()
-
-	s, err := n.Host.NewStream(n.ctx, pi, QriProtocolID)
-	if err != nil {
-		return nil, fmt.Errorf("error opening stream: %s", err.Error())
-	}
-	defer s.Close()
-
-	wrappedStream := WrapStream(s)
-
-	msg.Phase = MpRequest
-	err = sendMessage(msg, wrappedStream)
-	if err != nil {
-		return
-	}
-
-	return receiveMessage(wrappedStream)
-}
-
-// BroadcastMessage sends a message to all connected peers
-func (n *QriNode) BroadcastMessage(msg *Message) (res []*Message, err error) {
-	peers := n.QriPeers.Peers()
-	reschan := make(chan Message, 4)
-	done := make(chan bool, 0)
-	timer := time.NewTimer(time.Second * 6)
-	nodeID := n.Host.ID()
-
-	go func() {
-		defer func() {
-			done <- true
-		}()
-		for {
-			select {
-			case r, ok := <-reschan:
-				if !ok {
-					return
-				}
-				res = append(res, &r)
-			case <-timer.C:
-				fmt.Println("timeout")
-				return
-			}
+// receiveMessage reads and decodes a message from the stream
+func receiveMessage(ws *WrappedStream) (*Message, error) {
+	if ws.protocol == QriProtocolIDv1 {
+		env := &envelope{}
+		if err := ws.dec.Decode(env); err != nil {
+			return nil, err
 		}
-	}()
-
-	tasks := len(peers)
-	if len(peers) == 0 || tasks == 1 && peers[0] == nodeID {
-		close(reschan)
-		return nil, fmt.Errorf("no peers connected")
-	}
-
-	fmt.Printf("broadcasting message to %d peers\n", tasks)
-	sent := map[peer.ID]bool{}
-	for _, p := range peers {
-		go func() {
-			if !sent[p] {
-				sent[p] = true
-				if p != nodeID {
-					r, e := n.SendMessage(n.QriPeers.PeerInfo(p).ID, msg)
-					if e != nil {
-						fmt.Errorf(e.Error())
-					} else {
-						reschan <- *r
-					}
-				}
-			}
-
-			tasks--
-			if tasks == 0 {
-				close(reschan)
-			}
-		}()
+		return decodeEnvelope(env)
 	}
 
-	<-done
-	return
-}
-
-// receiveMessage reads and decodes a message from the stream
-func receiveMessage(ws *WrappedStream) (*Message, error) {
 	var msg Message
 	err := ws.dec.Decode(&msg)
 	if err != nil {
 		return nil, err
 	}
+	if err := typePayload(&msg); err != nil {
+		return nil, err
+	}
 	return &msg, nil
 }
 
@@ -203,7 +141,16 @@ func sendMessage(msg *Message, ws *WrappedStream) error {
 		return fmt.Errorf("message type is required to send a message")
 	}
 
-	err := ws.enc.Encode(msg)
+	var v interface{} = msg
+	if ws.protocol == QriProtocolIDv1 {
+		env, err := encodeEnvelope(msg)
+		if err != nil {
+			return err
+		}
+		v = env
+	}
+
+	err := ws.enc.Encode(v)
 	// Because output is buffered with bufio, we need to flush!
 	ws.w.Flush()
 	return err
@@ -216,6 +163,8 @@ func sendMessage(msg *Message, ws *WrappedStream) error {
 // TODO - I know this is completely awful. it'll get better in
 // due time
 func (n *QriNode) handleStream(ws *WrappedStream) {
+	// the requesting peer, used to check dataset visibility
+	pid := ws.stream.Conn().RemotePeer()
 	for {
 		// Read
 		r, err := receiveMessage(ws)
@@ -230,9 +179,9 @@ func (n *QriNode) handleStream(ws *WrappedStream) {
 			case MtPeerInfo:
 				res = n.handlePeerInfoRequest(r)
 			case MtDatasets:
-				res = n.handleDatasetsRequest(r)
+				res = n.handleDatasetsRequest(pid, r)
 			case MtSearch:
-				res = n.handleSearchRequest(r)
+				res = n.handleSearchRequest(pid, r)
 			case MtPeers:
 				res = n.handlePeersRequest(r)
 			case MtPing:
@@ -240,9 +189,11 @@ func (n *QriNode) handleStream(ws *WrappedStream) {
 			case MtNodes:
 				res = n.handleNodesRequest(r)
 			case MtDatasetInfo:
-				res = n.handleDatasetInfoRequest(r)
+				res = n.handleDatasetInfoRequest(pid, r)
 			case MtDatasetLog:
-				res = n.handleDatasetLogRequest(r)
+				res = n.handleDatasetLogRequest(pid, r)
+			case MtTombstone:
+				res = n.handleTombstoneRequest(pid, r)
 			}
 		}
 
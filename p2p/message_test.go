@@ -23,7 +23,7 @@ func TestPing(t *testing.T) {
 			Phase: MpRequest,
 			Type:  MtPing,
 		}
-		pong, err := a.SendMessage(b.Identity, ping)
+		pong, err := a.SendMessage(context.Background(), b.Identity, ping)
 		if err != nil {
 			t.Errorf("ping %d response error: %s", i, err.Error())
 			return
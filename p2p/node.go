@@ -3,6 +3,7 @@ package p2p
 import (
 	"context"
 	"fmt"
+	"time"
 	// "sort"
 
 	"github.com/qri-io/cafs/ipfs"
@@ -52,6 +53,11 @@ type QriNode struct {
 
 	// BootstrapAddrs is a list of multiaddresses to bootrap *qri* from (not IPFS)
 	BootstrapAddrs []string
+
+	// request deadline & retry settings, see NodeCfg
+	requestTimeout time.Duration
+	requestRetries int
+	retryBackoff   time.Duration
 }
 
 // NewQriNode creates a new node, providing no arguments will use
@@ -78,6 +84,9 @@ func NewQriNode(r repo.Repo, options ...func(o *NodeCfg)) (node *QriNode, err er
 		Repo:           r,
 		ctx:            context.Background(),
 		BootstrapAddrs: cfg.QriBootstrapAddrs,
+		requestTimeout: cfg.RequestTimeout,
+		requestRetries: cfg.RequestRetries,
+		retryBackoff:   cfg.RetryBackoff,
 	}
 
 	if cfg.Online {
@@ -104,6 +113,7 @@ func NewQriNode(r repo.Repo, options ...func(o *NodeCfg)) (node *QriNode, err er
 
 		// add multistream handler for qri protocol to the host
 		// for more info on multistreams check github.com/multformats/go-multistream
+		node.Host.SetStreamHandler(QriProtocolIDv1, node.MessageStreamHandler)
 		node.Host.SetStreamHandler(QriProtocolID, node.MessageStreamHandler)
 	}
 
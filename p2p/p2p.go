@@ -10,9 +10,14 @@ import (
 	identify "gx/ipfs/QmefgzMbKZYsmHFkLqxgaTBG9ypeEjrdWRD5WXH4j1cWDL/go-libp2p/p2p/protocol/identify"
 )
 
-// QriProtocolID is the top level Protocol Identifier
+// QriProtocolID is the top level Protocol Identifier. Streams on it send
+// messages as json, it's kept for peers that don't speak QriProtocolIDv1
 const QriProtocolID = protocol.ID("/qri")
 
+// QriProtocolIDv1 identifies the versioned binary wire protocol, sending
+// cbor envelopes with typed payloads. see ProtocolVersion
+const QriProtocolIDv1 = protocol.ID("/qri/1.0.0")
+
 // QriServiceTag tags the type & version of the qri service
 const QriServiceTag = "qri/0.0.1"
 
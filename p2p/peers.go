@@ -9,9 +9,12 @@ import (
 	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
 )
 
+// DefaultPeersListLimit is the number of peers asked for when a peer is added
+const DefaultPeersListLimit = 10
+
 // AddQriPeer negotiates a connection with a peer to get their profile details
 // and peer list.
-func (n *QriNode) AddQriPeer(pinfo pstore.PeerInfo) error {
+func (n *QriNode) AddQriPeer(ctx context.Context, pinfo pstore.PeerInfo) error {
 	// add this peer to our store
 	n.QriPeers.AddAddrs(pinfo.ID, pinfo.Addrs, pstore.TempAddrTTL)
 
@@ -20,14 +23,16 @@ func (n *QriNode) AddQriPeer(pinfo pstore.PeerInfo) error {
 	// 	return nil
 	// }
 
-	if err := n.RequestProfileInfo(pinfo); err != nil {
+	if err := n.RequestProfileInfo(ctx, pinfo); err != nil {
 		return err
 	}
 
 	// some time later ask for a list of their peers, you know, "for a friend"
 	go func() {
 		// time.Sleep(time.Second * 2)
-		n.RequestPeersList(pinfo.ID)
+		if err := n.RequestPeersList(n.ctx, DefaultPeersListLimit, pinfo.ID); err != nil {
+			n.log.Infof("error requesting peers from %s: %s", pinfo.ID.Pretty(), err.Error())
+		}
 	}()
 
 	return nil
@@ -39,7 +44,7 @@ func (n *QriNode) RequestPeername(peername string) error {
 }
 
 // RequestProfileInfo get's qri profile information from a PeerInfo
-func (n *QriNode) RequestProfileInfo(pinfo pstore.PeerInfo) error {
+func (n *QriNode) RequestProfileInfo(ctx context.Context, pinfo pstore.PeerInfo) error {
 	// Get this repo's profile information
 	profile, err := n.Repo.Profile()
 	if err != nil {
@@ -53,7 +58,7 @@ func (n *QriNode) RequestProfileInfo(pinfo pstore.PeerInfo) error {
 	}
 	profile.Addresses = addrs
 
-	res, err := n.SendMessage(pinfo.ID, &Message{
+	res, err := n.SendMessage(ctx, pinfo.ID, &Message{
 		Type:    MtPeerInfo,
 		Payload: profile,
 	})
@@ -72,35 +77,42 @@ func (n *QriNode) RequestProfileInfo(pinfo pstore.PeerInfo) error {
 	return nil
 }
 
-// RequestPeersList asks a peer for a list of peers they've seen
-func (n *QriNode) RequestPeersList(id peer.ID) {
-	res, err := n.SendMessage(id, &Message{
+// RequestPeersList asks peers for lists of peers they've seen, storing the
+// profiles they respond with. Lists are read as they arrive, peers stop being
+// asked once limit profiles have arrived
+func (n *QriNode) RequestPeersList(ctx context.Context, limit int, ids ...peer.ID) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	profiles := []*profile.Profile{}
+	responses := n.Broadcast(ctx, ids, &Message{
 		Type: MtPeers,
 		Payload: &PeersReqParams{
 			Offset: 0,
-			Limit:  10,
+			Limit:  limit,
 		},
+	}, BroadcastParams{})
+	gather(responses, limit, func(r *Message) int {
+		res, ok := r.Payload.(*[]*profile.Profile)
+		if !ok {
+			return 0
+		}
+		profiles = append(profiles, *res...)
+		return len(*res)
 	})
 
-	if err != nil {
-		fmt.Println("send peers message error:", err.Error())
-		return
-	}
-
-	if res.Phase == MpResponse {
-		if err := n.handlePeersResponse(res); err != nil {
-			fmt.Println("peers response error", err.Error())
-			return
-		}
+	if limit > 0 && len(profiles) > limit {
+		profiles = profiles[:limit]
 	}
+	return n.handlePeersResponse(&Message{Type: MtPeers, Phase: MpResponse, Payload: &profiles})
 }
 
 // ConnectToPeer takes a raw peer ID & tries to work out a route to that
 // peer, explicitly connecting to them.
-func (n *QriNode) ConnectToPeer(pid peer.ID) error {
+func (n *QriNode) ConnectToPeer(ctx context.Context, pid peer.ID) error {
 	// first check for local peer info
 	if pinfo := n.Host.Peerstore().PeerInfo(pid); pinfo.ID.String() != "" {
-		return n.RequestProfileInfo(pinfo)
+		return n.RequestProfileInfo(ctx, pinfo)
 	}
 
 	// attempt to use ipfs routing table to discover peer
@@ -109,12 +121,12 @@ func (n *QriNode) ConnectToPeer(pid peer.ID) error {
 		return err
 	}
 
-	pinfo, err := ipfsnode.Routing.FindPeer(context.Background(), pid)
+	pinfo, err := ipfsnode.Routing.FindPeer(ctx, pid)
 	if err != nil {
 		return err
 	}
 
-	return n.RequestProfileInfo(pinfo)
+	return n.RequestProfileInfo(ctx, pinfo)
 }
 
 // ConnectedPeers lists all IPFS connected peers
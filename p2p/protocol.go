@@ -0,0 +1,209 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+
+	cbor "gx/ipfs/QmU4qokxecGJBZPGmc4D9g2HdTyo8CPqUoZ2gwXKsQxqc9/go-multicodec/cbor"
+)
+
+// ProtocolVersion is the version of the typed wire protocol spoken on
+// QriProtocolIDv1 streams. It's sent with every message, peers reject
+// messages from versions newer than their own
+const ProtocolVersion = 1
+
+// payloadKey identifies the payload schema of a message phase
+type payloadKey struct {
+	Type  MsgType
+	Phase MsgPhase
+}
+
+// payloadSchema describes the typed payload of a message phase
+type payloadSchema struct {
+	// new allocates a value to decode a payload into
+	new func() interface{}
+	// wire allocates the cbor form of payloads that can't be encoded field
+	// by field. nil for payloads that are encoded as they are
+	wire func() wirePayload
+}
+
+// payloadSchemas lists the payload of every message phase that carries one.
+// Error phases always carry an error message
+var payloadSchemas = map[payloadKey]payloadSchema{
+	{MtPeerInfo, MpRequest}:     {func() interface{} { return &profile.Profile{} }, func() wirePayload { return &wireProfile{} }},
+	{MtPeerInfo, MpResponse}:    {func() interface{} { return &profile.Profile{} }, func() wirePayload { return &wireProfile{} }},
+	{MtPeers, MpRequest}:        {func() interface{} { return &PeersReqParams{} }, nil},
+	{MtPeers, MpResponse}:       {func() interface{} { return &[]*profile.Profile{} }, func() wirePayload { return &wireProfiles{} }},
+	{MtDatasets, MpRequest}:     {func() interface{} { return &DatasetsReqParams{} }, nil},
+	{MtDatasets, MpResponse}:    {func() interface{} { return &[]repo.DatasetRef{} }, func() wirePayload { return &wireRefs{} }},
+	{MtSearch, MpRequest}:       {func() interface{} { return &repo.SearchParams{} }, nil},
+	{MtSearch, MpResponse}:      {func() interface{} { return &[]repo.DatasetRef{} }, func() wirePayload { return &wireRefs{} }},
+	{MtNodes, MpResponse}:       {func() interface{} { return &[]string{} }, nil},
+	{MtDatasetInfo, MpRequest}:  {func() interface{} { return &repo.DatasetRef{} }, func() wirePayload { return &wireRef{} }},
+	{MtDatasetInfo, MpResponse}: {func() interface{} { return &repo.DatasetRef{} }, func() wirePayload { return &wireRef{} }},
+	{MtDatasetLog, MpRequest}:   {func() interface{} { return &repo.DatasetRef{} }, func() wirePayload { return &wireRef{} }},
+	{MtDatasetLog, MpResponse}:  {func() interface{} { return &[]repo.DatasetRef{} }, func() wirePayload { return &wireRefs{} }},
+	{MtTombstone, MpRequest}:    {func() interface{} { return &Tombstone{} }, func() wirePayload { return &wireTombstone{} }},
+}
+
+// envelope is the wire form of a Message on QriProtocolIDv1 streams.
+// Payload is encoded according to the payload schema of the message phase,
+// so it's decoded straight into its type
+type envelope struct {
+	Version int
+	Type    MsgType
+	Phase   MsgPhase
+	Payload []byte
+	HangUp  bool
+}
+
+// PayloadError gives the error a peer responded with, nil unless the message
+// is in the error phase
+func PayloadError(msg *Message) error {
+	if msg.Phase != MpError {
+		return nil
+	}
+	if err, ok := msg.Payload.(error); ok {
+		return err
+	}
+	return fmt.Errorf("peer responded with an error")
+}
+
+// encodeEnvelope wraps a message for the wire
+func encodeEnvelope(msg *Message) (*envelope, error) {
+	env := &envelope{
+		Version: ProtocolVersion,
+		Type:    msg.Type,
+		Phase:   msg.Phase,
+		HangUp:  msg.HangUp,
+	}
+	if msg.Payload == nil {
+		return env, nil
+	}
+
+	var err error
+	if msg.Phase == MpError {
+		text := fmt.Sprintf("%v", msg.Payload)
+		if e, ok := msg.Payload.(error); ok {
+			text = e.Error()
+		}
+		env.Payload, err = encodeCBOR(text)
+		return env, err
+	}
+
+	schema, ok := payloadSchemas[payloadKey{msg.Type, msg.Phase}]
+	if !ok {
+		return nil, fmt.Errorf("%s messages don't carry a payload", msg.Type)
+	}
+	payload := msg.Payload
+	if schema.wire != nil {
+		w := schema.wire()
+		if err := w.set(msg.Payload); err != nil {
+			return nil, fmt.Errorf("error encoding %s payload: %s", msg.Type, err.Error())
+		}
+		payload = w
+	}
+	if env.Payload, err = encodeCBOR(payload); err != nil {
+		return nil, fmt.Errorf("error encoding %s payload: %s", msg.Type, err.Error())
+	}
+	return env, nil
+}
+
+// decodeEnvelope unwraps a message read from the wire, decoding its payload
+// into its schema type
+func decodeEnvelope(env *envelope) (*Message, error) {
+	if env.Version > ProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d, this peer speaks version %d", env.Version, ProtocolVersion)
+	}
+	msg := &Message{
+		Type:   env.Type,
+		Phase:  env.Phase,
+		HangUp: env.HangUp,
+	}
+	if len(env.Payload) == 0 {
+		return msg, nil
+	}
+
+	if env.Phase == MpError {
+		text := ""
+		if err := decodeCBOR(env.Payload, &text); err != nil {
+			return nil, fmt.Errorf("error decoding %s error: %s", env.Type, err.Error())
+		}
+		msg.Payload = fmt.Errorf("%s", text)
+		return msg, nil
+	}
+
+	schema, ok := payloadSchemas[payloadKey{env.Type, env.Phase}]
+	if !ok {
+		return nil, fmt.Errorf("%s messages don't carry a payload", env.Type)
+	}
+	if schema.wire == nil {
+		v := schema.new()
+		if err := decodeCBOR(env.Payload, v); err != nil {
+			return nil, fmt.Errorf("error decoding %s payload: %s", env.Type, err.Error())
+		}
+		msg.Payload = v
+		return msg, nil
+	}
+
+	w := schema.wire()
+	if err := decodeCBOR(env.Payload, w); err != nil {
+		return nil, fmt.Errorf("error decoding %s payload: %s", env.Type, err.Error())
+	}
+	v, err := w.payload()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s payload: %s", env.Type, err.Error())
+	}
+	msg.Payload = v
+	return msg, nil
+}
+
+// typePayload converts the generic payload of a message read from a
+// QriProtocolID json stream to its schema type, so handlers see the same
+// payloads no matter which protocol a peer speaks
+func typePayload(msg *Message) error {
+	if msg.Payload == nil {
+		return nil
+	}
+
+	if msg.Phase == MpError {
+		// json streams encode errors as empty objects
+		if text, ok := msg.Payload.(string); ok {
+			msg.Payload = fmt.Errorf("%s", text)
+		} else {
+			msg.Payload = fmt.Errorf("peer responded with an error")
+		}
+		return nil
+	}
+
+	schema, ok := payloadSchemas[payloadKey{msg.Type, msg.Phase}]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("error encoding %s payload: %s", msg.Type, err.Error())
+	}
+	v := schema.new()
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s payload: %s", msg.Type, err.Error())
+	}
+	msg.Payload = v
+	return nil
+}
+
+func encodeCBOR(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := cbor.Codec().Encoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeCBOR(data []byte, v interface{}) error {
+	return cbor.Codec().Decoder(bytes.NewReader(data)).Decode(v)
+}
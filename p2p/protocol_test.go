@@ -0,0 +1,95 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestEnvelope(t *testing.T) {
+	created := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		msg    *Message
+		expect interface{}
+		err    string
+	}{
+		{&Message{Type: MtPing, Phase: MpRequest}, nil, ""},
+		{&Message{Type: MtDatasets, Phase: MpRequest, Payload: &DatasetsReqParams{Limit: 10, Offset: 5}}, &DatasetsReqParams{Limit: 10, Offset: 5}, ""},
+		{&Message{Type: MtNodes, Phase: MpResponse, Payload: []string{"/ip4/127.0.0.1"}}, &[]string{"/ip4/127.0.0.1"}, ""},
+		{&Message{Type: MtDatasetInfo, Phase: MpRequest, Payload: repo.DatasetRef{Peername: "me", Name: "movies"}}, &repo.DatasetRef{Peername: "me", Name: "movies"}, ""},
+		{&Message{Type: MtDatasets, Phase: MpResponse, Payload: []repo.DatasetRef{{Peername: "me", Name: "movies"}, {Peername: "me", Name: "cities"}}}, &[]repo.DatasetRef{{Peername: "me", Name: "movies"}, {Peername: "me", Name: "cities"}}, ""},
+		{&Message{Type: MtPeerInfo, Phase: MpResponse, Payload: &profile.Profile{ID: "QmPeer", Peername: "me", Created: created}}, &profile.Profile{ID: "QmPeer", Peername: "me", Created: created}, ""},
+		{&Message{Type: MtTombstone, Phase: MpRequest, Payload: Tombstone{Ref: repo.DatasetRef{Peername: "me", Name: "movies"}, Paths: []string{"/map/QmVersion"}, Removed: created}}, &Tombstone{Ref: repo.DatasetRef{Peername: "me", Name: "movies"}, Paths: []string{"/map/QmVersion"}, Removed: created}, ""},
+		{&Message{Type: MtDatasetLog, Phase: MpError, Payload: fmt.Errorf("not found")}, fmt.Errorf("not found"), ""},
+		{&Message{Type: MtPing, Phase: MpRequest, Payload: "ping"}, nil, "PING messages don't carry a payload"},
+	}
+
+	for i, c := range cases {
+		env, err := encodeEnvelope(c.msg)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if env.Version != ProtocolVersion {
+			t.Errorf("case %d version mismatch. expected: %d, got: %d", i, ProtocolVersion, env.Version)
+		}
+		if len(env.Payload) > 0 && json.Valid(env.Payload) {
+			t.Errorf("case %d expected payload to be encoded as cbor, got json: %s", i, env.Payload)
+		}
+
+		got, err := decodeEnvelope(env)
+		if err != nil {
+			t.Errorf("case %d error decoding envelope: %s", i, err.Error())
+			continue
+		}
+		if got.Type != c.msg.Type || got.Phase != c.msg.Phase {
+			t.Errorf("case %d type or phase mismatch", i)
+		}
+		if !reflect.DeepEqual(got.Payload, c.expect) {
+			t.Errorf("case %d payload mismatch. expected: %#v, got: %#v", i, c.expect, got.Payload)
+		}
+	}
+
+	_, err := decodeEnvelope(&envelope{Version: ProtocolVersion + 1, Type: MtPing})
+	if err == nil {
+		t.Errorf("expected newer protocol versions to error")
+	}
+}
+
+func TestTypePayload(t *testing.T) {
+	// payloads read from json streams decode to generic values
+	generic := func(v interface{}) interface{} {
+		data, _ := json.Marshal(v)
+		var g interface{}
+		json.Unmarshal(data, &g)
+		return g
+	}
+
+	cases := []struct {
+		msg    *Message
+		expect interface{}
+	}{
+		{&Message{Type: MtPing, Phase: MpResponse}, nil},
+		{&Message{Type: MtPeers, Phase: MpRequest, Payload: generic(&PeersReqParams{Limit: 10})}, &PeersReqParams{Limit: 10}},
+		{&Message{Type: MtDatasets, Phase: MpResponse, Payload: generic([]repo.DatasetRef{{Peername: "me", Name: "movies"}})}, &[]repo.DatasetRef{{Peername: "me", Name: "movies"}}},
+		{&Message{Type: MtDatasetInfo, Phase: MpError, Payload: generic(fmt.Errorf("not found"))}, fmt.Errorf("peer responded with an error")},
+	}
+
+	for i, c := range cases {
+		if err := typePayload(c.msg); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(c.msg.Payload, c.expect) {
+			t.Errorf("case %d payload mismatch. expected: %#v, got: %#v", i, c.expect, c.msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+var (
+	// ErrTimeout is returned when a peer doesn't respond before the request
+	// deadline
+	ErrTimeout = fmt.Errorf("peer didn't respond in time")
+	// ErrRefused is returned when a connection to a peer can't be opened, or
+	// is closed before the peer responds
+	ErrRefused = fmt.Errorf("peer refused the connection")
+	// ErrProtocolUnsupported is returned when a peer doesn't speak the qri
+	// protocol
+	ErrProtocolUnsupported = fmt.Errorf("peer doesn't support the qri protocol")
+	// ErrNotFound is returned when a peer doesn't have the requested dataset,
+	// or won't show it to this peer
+	ErrNotFound = fmt.Errorf("peer doesn't have the requested dataset")
+)
+
+const (
+	// DefaultRequestTimeout is the deadline given to requests whose context
+	// doesn't set one
+	DefaultRequestTimeout = time.Second * 30
+	// DefaultRequestRetries is the number of times idempotent requests are
+	// retried after the first attempt
+	DefaultRequestRetries = 2
+	// DefaultRetryBackoff is the wait before retrying a request, doubling
+	// with each retry
+	DefaultRetryBackoff = time.Millisecond * 500
+)
+
+// idempotent lists the message types that are safe to send more than once
+var idempotent = map[MsgType]bool{
+	MtPing:        true,
+	MtPeerInfo:    true,
+	MtPeers:       true,
+	MtDatasets:    true,
+	MtSearch:      true,
+	MtNodes:       true,
+	MtDatasetInfo: true,
+	MtDatasetLog:  true,
+}
+
+// PayloadTypeError is returned when a response doesn't carry the payload its
+// message type should
+type PayloadTypeError struct {
+	Type    MsgType
+	Payload interface{}
+}
+
+func (e PayloadTypeError) Error() string {
+	return fmt.Sprintf("error decoding %s response: unexpected payload type %T", e.Type, e.Payload)
+}
+
+// requestContext gives the context for a single attempt at a request,
+// bounded by the node's request timeout as well as ctx
+func (n *QriNode) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if n.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, n.requestTimeout)
+}
+
+// retryBudget is the longest an idempotent request can take: every attempt
+// timing out, with backoff between attempts. zero when requests have no
+// timeout
+func (n *QriNode) retryBudget() time.Duration {
+	if n.requestTimeout <= 0 {
+		return 0
+	}
+	budget := n.requestTimeout * time.Duration(n.requestRetries+1)
+	backoff := n.retryBackoff
+	for i := 0; i < n.requestRetries; i++ {
+		budget += backoff
+		backoff *= 2
+	}
+	return budget
+}
+
+// SendMessage sends a request to a peer, returning the peer's response.
+// Each attempt at a request gives up after the node's request timeout, & the
+// request gives up entirely when ctx is done. Idempotent requests that time
+// out or are refused are retried with backoff. Errors are one of ErrTimeout,
+// ErrRefused, ErrProtocolUnsupported, ErrNotFound, context.Canceled or the
+// error the peer responded with
+func (n *QriNode) SendMessage(ctx context.Context, pid peer.ID, msg *Message) (*Message, error) {
+	if ctx == nil {
+		ctx = n.ctx
+	}
+
+	retries := 0
+	if idempotent[msg.Type] {
+		retries = n.requestRetries
+	}
+	backoff := n.retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		actx, cancel := n.requestContext(ctx)
+		res, err := n.sendRequest(actx, pid, msg)
+		cancel()
+		if err == nil {
+			return res, responseError(res)
+		}
+		if attempt >= retries || !(err == ErrTimeout || err == ErrRefused) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		n.log.Infof("retrying %s request to %s: %s", msg.Type, pid.Pretty(), err.Error())
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return nil, requestError(ctx, ctx.Err())
+		}
+	}
+}
+
+// sendRequest makes a single attempt at a request
+func (n *QriNode) sendRequest(ctx context.Context, pid peer.ID, msg *Message) (*Message, error) {
+	// listing both protocols negotiates the newest one the peer speaks
+	s, err := n.Host.NewStream(ctx, pid, QriProtocolIDv1, QriProtocolID)
+	if err != nil {
+		n.log.Infof("error opening stream to %s: %s", pid.Pretty(), err.Error())
+		return nil, requestError(ctx, err)
+	}
+	defer s.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetDeadline(deadline)
+	}
+	// closing the stream unblocks reads & writes when ctx is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-done:
+		}
+	}()
+
+	ws := WrapStream(s)
+	msg.Phase = MpRequest
+	if err := sendMessage(msg, ws); err != nil {
+		n.log.Infof("error sending %s request to %s: %s", msg.Type, pid.Pretty(), err.Error())
+		return nil, requestError(ctx, err)
+	}
+
+	res, err := receiveMessage(ws)
+	if err != nil {
+		n.log.Infof("error reading %s response from %s: %s", msg.Type, pid.Pretty(), err.Error())
+		return nil, requestError(ctx, err)
+	}
+	return res, nil
+}
+
+// requestError classifies an error from a request to a peer
+func requestError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return ErrTimeout
+	case context.Canceled:
+		return context.Canceled
+	}
+	if te, ok := err.(interface {
+		Timeout() bool
+	}); ok && te.Timeout() {
+		return ErrTimeout
+	}
+	if strings.Contains(err.Error(), "protocol not supported") {
+		return ErrProtocolUnsupported
+	}
+	return ErrRefused
+}
+
+// responseError gives the error an error phase response carries, translating
+// missing datasets to ErrNotFound
+func responseError(res *Message) error {
+	err := PayloadError(res)
+	if err != nil && err.Error() == repo.ErrNotFound.Error() {
+		return ErrNotFound
+	}
+	return err
+}
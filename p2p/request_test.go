@@ -0,0 +1,104 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string { return "i/o timeout" }
+func (timeoutErr) Timeout() bool { return true }
+
+func TestRequestError(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		ctx    context.Context
+		err    error
+		expect error
+	}{
+		{expired, fmt.Errorf("stream reset"), ErrTimeout},
+		{cancelled, fmt.Errorf("stream reset"), context.Canceled},
+		{context.Background(), timeoutErr{}, ErrTimeout},
+		{context.Background(), fmt.Errorf("protocol not supported"), ErrProtocolUnsupported},
+		{context.Background(), fmt.Errorf("dial attempt failed: connection refused"), ErrRefused},
+	}
+
+	for i, c := range cases {
+		if got := requestError(c.ctx, c.err); got != c.expect {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	cases := []struct {
+		res    *Message
+		expect string
+	}{
+		{&Message{Type: MtDatasetInfo, Phase: MpResponse}, ""},
+		{&Message{Type: MtDatasetInfo, Phase: MpError, Payload: fmt.Errorf(repo.ErrNotFound.Error())}, ErrNotFound.Error()},
+		{&Message{Type: MtDatasetInfo, Phase: MpError, Payload: fmt.Errorf("oh noes")}, "oh noes"},
+	}
+
+	for i, c := range cases {
+		err := responseError(c.res)
+		if !(err == nil && c.expect == "" || err != nil && err.Error() == c.expect) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.expect, err)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	cases := []struct {
+		n      *QriNode
+		expect time.Duration
+	}{
+		{&QriNode{}, 0},
+		{&QriNode{requestTimeout: time.Second}, time.Second},
+		{&QriNode{requestTimeout: time.Second, requestRetries: 2, retryBackoff: time.Millisecond * 500}, time.Millisecond * 4500},
+	}
+
+	for i, c := range cases {
+		if got := c.n.retryBudget(); got != c.expect {
+			t.Errorf("case %d budget mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	n := &QriNode{ctx: context.Background(), requestTimeout: time.Minute}
+
+	ctx, cancel := n.requestContext(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected the node's request timeout to set a deadline")
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = n.requestContext(parent)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Second {
+		t.Errorf("expected a context's own deadline to be kept")
+	}
+
+	// each attempt is bounded by the node's timeout, so a request with a long
+	// deadline can retry attempts that time out
+	long, cancelLong := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLong()
+	ctx, cancel = n.requestContext(long)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected the node's request timeout to bound an attempt")
+	}
+}
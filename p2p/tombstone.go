@@ -0,0 +1,141 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+// Tombstone notifies peers that dataset versions have been removed.
+// An empty Ref.Path means the entire dataset history was removed
+type Tombstone struct {
+	Ref repo.DatasetRef
+	// Paths lists the removed versions
+	Paths   []string
+	Removed time.Time
+}
+
+// recordListings notes that refs were listed to a peer, so it can be sent
+// tombstones if they're removed
+func (n *QriNode) recordListings(pid peer.ID, refs []repo.DatasetRef) {
+	if err := n.Repo.PutListings(pid.Pretty(), refs); err != nil {
+		n.log.Infof("error recording listings to %s: %s", pid.Pretty(), err.Error())
+	}
+}
+
+// AnnounceTombstone sends a tombstone to every peer that's been given the
+// dataset in a listing. It returns the number of peers that acknowledged it
+func (n *QriNode) AnnounceTombstone(ctx context.Context, t *Tombstone) (int, error) {
+	if n.Host == nil {
+		return 0, fmt.Errorf("node isn't connected to the network")
+	}
+	ids, err := n.Repo.RefListings(t.Ref)
+	if err != nil {
+		return 0, fmt.Errorf("error getting listings: %s", err.Error())
+	}
+	peers := make([]peer.ID, 0, len(ids))
+	for _, id := range ids {
+		pid, err := peer.IDB58Decode(id)
+		if err != nil {
+			n.log.Infof("invalid listed peer id %s: %s", id, err.Error())
+			continue
+		}
+		peers = append(peers, pid)
+	}
+
+	sent := 0
+	msg := &Message{Type: MtTombstone, Payload: t, HangUp: true}
+	for res := range n.Broadcast(ctx, peers, msg, BroadcastParams{}) {
+		if res.Err != nil {
+			n.log.Infof("error sending tombstone to %s: %s", res.Peer.Pretty(), res.Err.Error())
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// handleTombstoneRequest drops cached versions of a removed dataset. Peers
+// can only remove their own datasets, & only versions this node knows are
+// in the dataset's history
+func (n *QriNode) handleTombstoneRequest(pid peer.ID, r *Message) *Message {
+	t, ok := r.Payload.(*Tombstone)
+	if !ok {
+		n.log.Info("tombstone request has no tombstone")
+		return nil
+	}
+	if err := n.checkTombstone(pid, t); err != nil {
+		n.log.Infof("rejecting tombstone from %s: %s", pid.Pretty(), err.Error())
+		return &Message{
+			Type:    MtTombstone,
+			Phase:   MpError,
+			Payload: err,
+		}
+	}
+
+	n.log.Infof("peer %s removed %s", pid.Pretty(), t.Ref)
+	for _, path := range t.Paths {
+		if err := n.Repo.Cache().DeleteDataset(datastore.NewKey(path)); err != nil && err != repo.ErrNotFound {
+			n.log.Infof("error removing cached dataset %s: %s", path, err.Error())
+		}
+	}
+
+	return &Message{
+		Type:  MtTombstone,
+		Phase: MpResponse,
+	}
+}
+
+// checkTombstone errors unless a tombstone was sent by the peer that owns
+// the dataset, & every removed path is in the dataset's known history
+func (n *QriNode) checkTombstone(pid peer.ID, t *Tombstone) error {
+	p, err := n.Repo.Peers().GetPeer(pid)
+	if err != nil || p == nil {
+		return fmt.Errorf("unknown peer")
+	}
+	if p.Peername == "" || p.Peername != t.Ref.Peername {
+		return fmt.Errorf("peer %s doesn't own %s", p.Peername, t.Ref)
+	}
+	if id, err := n.Repo.Peers().IPFSPeerID(t.Ref.Peername); err == nil && id != pid {
+		return fmt.Errorf("peer %s doesn't own %s", p.Peername, t.Ref)
+	}
+
+	history, err := n.knownHistory(t.Ref)
+	if err != nil {
+		return err
+	}
+	for _, path := range t.Paths {
+		if !history[path] {
+			return fmt.Errorf("%s isn't a known version of %s", path, t.Ref)
+		}
+	}
+	return nil
+}
+
+// knownHistory gives the paths of the versions of a dataset this node has a
+// record of, walking back from the head of the dataset in its repo
+func (n *QriNode) knownHistory(ref repo.DatasetRef) (map[string]bool, error) {
+	head, err := n.Repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		return nil, fmt.Errorf("unknown dataset %s/%s", ref.Peername, ref.Name)
+	}
+
+	history := map[string]bool{}
+	path := head.Path
+	for path != "" && path != "/" && !history[path] {
+		history[path] = true
+		ds, err := n.Repo.Cache().GetDataset(datastore.NewKey(path))
+		if err != nil {
+			if ds, err = n.Repo.GetDataset(datastore.NewKey(path)); err != nil {
+				break
+			}
+		}
+		path = ds.PreviousPath
+	}
+	return history, nil
+}
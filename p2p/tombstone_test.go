@@ -0,0 +1,52 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
+)
+
+func TestCheckTombstone(t *testing.T) {
+	r, err := NewTestRepo()
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+	owner, other := peer.ID("owner"), peer.ID("other")
+	r.Peers().PutPeer(owner, &profile.Profile{Peername: "them"})
+	r.Peers().PutPeer(other, &profile.Profile{Peername: "someone_else"})
+
+	if err := r.PutRef(repo.DatasetRef{Peername: "them", Name: "movies", Path: "/map/v2"}); err != nil {
+		t.Errorf("error putting ref: %s", err.Error())
+		return
+	}
+	r.Cache().PutDataset(datastore.NewKey("/map/v2"), &dataset.Dataset{PreviousPath: "/map/v1"})
+	r.Cache().PutDataset(datastore.NewKey("/map/v1"), &dataset.Dataset{})
+
+	n := &QriNode{Repo: r}
+	movies := repo.DatasetRef{Peername: "them", Name: "movies"}
+	cases := []struct {
+		pid peer.ID
+		t   *Tombstone
+		err string
+	}{
+		{owner, &Tombstone{Ref: movies, Paths: []string{"/map/v1"}}, ""},
+		{owner, &Tombstone{Ref: movies, Paths: []string{"/map/v1", "/map/v2"}}, ""},
+		{peer.ID("stranger"), &Tombstone{Ref: movies, Paths: []string{"/map/v1"}}, "unknown peer"},
+		{other, &Tombstone{Ref: movies, Paths: []string{"/map/v1"}}, "peer someone_else doesn't own them/movies"},
+		{owner, &Tombstone{Ref: movies, Paths: []string{"/map/v1", "/map/elsewhere"}}, "/map/elsewhere isn't a known version of them/movies"},
+		{owner, &Tombstone{Ref: repo.DatasetRef{Peername: "them", Name: "cities"}, Paths: []string{"/map/v1"}}, "unknown dataset them/cities"},
+	}
+
+	for i, c := range cases {
+		err := n.checkTombstone(c.pid, c.t)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
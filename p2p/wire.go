@@ -0,0 +1,312 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// wirePayload is the cbor form of a payload type the cbor codec can't
+// encode field by field. Unexported fields, like those of time.Time &
+// datastore.Key, are carried as strings. Datasets are carried in their own
+// json encoding, which commit signatures cover, so they arrive exactly as
+// they were sent
+type wirePayload interface {
+	// set fills in the wire form of a payload
+	set(payload interface{}) error
+	// payload gives the payload a wire form carries
+	payload() (interface{}, error)
+}
+
+// wireRef is the wire form of a repo.DatasetRef
+type wireRef struct {
+	Peername        string
+	Name            string
+	Path            string
+	Dataset         []byte
+	SignatureStatus string
+	Visibility      string
+	AsOf            string
+}
+
+func newWireRef(ref repo.DatasetRef) (wireRef, error) {
+	w := wireRef{
+		Peername:        ref.Peername,
+		Name:            ref.Name,
+		Path:            ref.Path,
+		SignatureStatus: string(ref.SignatureStatus),
+		Visibility:      ref.Visibility,
+	}
+	if ref.AsOf != nil {
+		w.AsOf = encodeTime(*ref.AsOf)
+	}
+	if ref.Dataset != nil {
+		data, err := json.Marshal(ref.Dataset)
+		if err != nil {
+			return w, fmt.Errorf("error encoding dataset: %s", err.Error())
+		}
+		w.Dataset = data
+	}
+	return w, nil
+}
+
+func (w wireRef) ref() (repo.DatasetRef, error) {
+	ref := repo.DatasetRef{
+		Peername:        w.Peername,
+		Name:            w.Name,
+		Path:            w.Path,
+		SignatureStatus: repo.SignatureStatus(w.SignatureStatus),
+		Visibility:      w.Visibility,
+	}
+	if w.AsOf != "" {
+		t, err := decodeTime(w.AsOf)
+		if err != nil {
+			return ref, err
+		}
+		ref.AsOf = &t
+	}
+	if len(w.Dataset) > 0 {
+		ref.Dataset = &dataset.Dataset{}
+		if err := json.Unmarshal(w.Dataset, ref.Dataset); err != nil {
+			return ref, fmt.Errorf("error decoding dataset: %s", err.Error())
+		}
+	}
+	return ref, nil
+}
+
+func (w *wireRef) set(payload interface{}) (err error) {
+	switch ref := payload.(type) {
+	case repo.DatasetRef:
+		*w, err = newWireRef(ref)
+	case *repo.DatasetRef:
+		*w, err = newWireRef(*ref)
+	default:
+		err = fmt.Errorf("unexpected payload type %T", payload)
+	}
+	return
+}
+
+func (w *wireRef) payload() (interface{}, error) {
+	ref, err := w.ref()
+	return &ref, err
+}
+
+// wireRefs is the wire form of a list of dataset references
+type wireRefs []wireRef
+
+func (w *wireRefs) set(payload interface{}) error {
+	var refs []repo.DatasetRef
+	switch p := payload.(type) {
+	case []repo.DatasetRef:
+		refs = p
+	case *[]repo.DatasetRef:
+		refs = *p
+	default:
+		return fmt.Errorf("unexpected payload type %T", payload)
+	}
+
+	*w = make(wireRefs, len(refs))
+	for i, ref := range refs {
+		wr, err := newWireRef(ref)
+		if err != nil {
+			return err
+		}
+		(*w)[i] = wr
+	}
+	return nil
+}
+
+func (w *wireRefs) payload() (interface{}, error) {
+	refs := make([]repo.DatasetRef, len(*w))
+	for i, wr := range *w {
+		ref, err := wr.ref()
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ref
+	}
+	return &refs, nil
+}
+
+// wireProfile is the wire form of a profile.Profile
+type wireProfile struct {
+	ID          string
+	Created     string
+	Updated     string
+	Peername    string
+	Type        int
+	Email       string
+	Name        string
+	Description string
+	HomeURL     string
+	Color       string
+	Thumb       string
+	Profile     string
+	Poster      string
+	Twitter     string
+	Addresses   []string
+}
+
+func newWireProfile(p *profile.Profile) wireProfile {
+	return wireProfile{
+		ID:          p.ID,
+		Created:     encodeTime(p.Created),
+		Updated:     encodeTime(p.Updated),
+		Peername:    p.Peername,
+		Type:        int(p.Type),
+		Email:       p.Email,
+		Name:        p.Name,
+		Description: p.Description,
+		HomeURL:     p.HomeURL,
+		Color:       p.Color,
+		Thumb:       p.Thumb.String(),
+		Profile:     p.Profile.String(),
+		Poster:      p.Poster.String(),
+		Twitter:     p.Twitter,
+		Addresses:   p.Addresses,
+	}
+}
+
+func (w wireProfile) profile() (*profile.Profile, error) {
+	p := &profile.Profile{
+		ID:          w.ID,
+		Peername:    w.Peername,
+		Type:        profile.UserType(w.Type),
+		Email:       w.Email,
+		Name:        w.Name,
+		Description: w.Description,
+		HomeURL:     w.HomeURL,
+		Color:       w.Color,
+		Thumb:       decodeKey(w.Thumb),
+		Profile:     decodeKey(w.Profile),
+		Poster:      decodeKey(w.Poster),
+		Twitter:     w.Twitter,
+		Addresses:   w.Addresses,
+	}
+	var err error
+	if p.Created, err = decodeTime(w.Created); err != nil {
+		return nil, err
+	}
+	if p.Updated, err = decodeTime(w.Updated); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (w *wireProfile) set(payload interface{}) error {
+	switch p := payload.(type) {
+	case profile.Profile:
+		*w = newWireProfile(&p)
+	case *profile.Profile:
+		*w = newWireProfile(p)
+	default:
+		return fmt.Errorf("unexpected payload type %T", payload)
+	}
+	return nil
+}
+
+func (w *wireProfile) payload() (interface{}, error) {
+	return w.profile()
+}
+
+// wireProfiles is the wire form of a list of profiles
+type wireProfiles []wireProfile
+
+func (w *wireProfiles) set(payload interface{}) error {
+	var profiles []*profile.Profile
+	switch p := payload.(type) {
+	case []*profile.Profile:
+		profiles = p
+	case *[]*profile.Profile:
+		profiles = *p
+	default:
+		return fmt.Errorf("unexpected payload type %T", payload)
+	}
+
+	*w = make(wireProfiles, len(profiles))
+	for i, p := range profiles {
+		(*w)[i] = newWireProfile(p)
+	}
+	return nil
+}
+
+func (w *wireProfiles) payload() (interface{}, error) {
+	profiles := make([]*profile.Profile, len(*w))
+	for i, wp := range *w {
+		p, err := wp.profile()
+		if err != nil {
+			return nil, err
+		}
+		profiles[i] = p
+	}
+	return &profiles, nil
+}
+
+// wireTombstone is the wire form of a Tombstone
+type wireTombstone struct {
+	Ref     wireRef
+	Paths   []string
+	Removed string
+}
+
+func (w *wireTombstone) set(payload interface{}) (err error) {
+	var t Tombstone
+	switch p := payload.(type) {
+	case Tombstone:
+		t = p
+	case *Tombstone:
+		t = *p
+	default:
+		return fmt.Errorf("unexpected payload type %T", payload)
+	}
+
+	w.Paths = t.Paths
+	w.Removed = encodeTime(t.Removed)
+	w.Ref, err = newWireRef(t.Ref)
+	return
+}
+
+func (w *wireTombstone) payload() (interface{}, error) {
+	t := &Tombstone{Paths: w.Paths}
+	var err error
+	if t.Ref, err = w.Ref.ref(); err != nil {
+		return nil, err
+	}
+	if t.Removed, err = decodeTime(w.Removed); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// encodeTime gives the wire form of a time, zero times are empty
+func encodeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func decodeTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return t, fmt.Errorf("error decoding time: %s", err.Error())
+	}
+	return t, nil
+}
+
+// decodeKey gives the datastore key of a wire string, empty strings are
+// empty keys
+func decodeKey(s string) datastore.Key {
+	if s == "" {
+		return datastore.Key{}
+	}
+	return datastore.NewKey(s)
+}
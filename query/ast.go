@@ -0,0 +1,224 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is any expression that can be evaluated against a row
+type Expr interface {
+	String() string
+}
+
+// Literal is a constant value: a string, float64, bool or nil
+type Literal struct {
+	Value interface{}
+}
+
+func (l *Literal) String() string {
+	switch v := l.Value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ColumnRef refers to a column, optionally qualified by a table name or alias
+type ColumnRef struct {
+	Table string
+	Name  string
+}
+
+func (c *ColumnRef) String() string {
+	if c.Table != "" {
+		return c.Table + "." + c.Name
+	}
+	return c.Name
+}
+
+// Star selects all columns, optionally from a single table
+type Star struct {
+	Table string
+}
+
+func (s *Star) String() string {
+	if s.Table != "" {
+		return s.Table + ".*"
+	}
+	return "*"
+}
+
+// BinaryExpr is an infix operation
+type BinaryExpr struct {
+	Op          string
+	Left, Right Expr
+}
+
+func (b *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.Left, b.Op, b.Right)
+}
+
+// UnaryExpr is a prefix operation: NOT or negation
+type UnaryExpr struct {
+	Op string
+	X  Expr
+}
+
+func (u *UnaryExpr) String() string {
+	return fmt.Sprintf("(%s %s)", u.Op, u.X)
+}
+
+// IsNullExpr tests a value for NULL
+type IsNullExpr struct {
+	X   Expr
+	Not bool
+}
+
+func (e *IsNullExpr) String() string {
+	if e.Not {
+		return fmt.Sprintf("(%s IS NOT NULL)", e.X)
+	}
+	return fmt.Sprintf("(%s IS NULL)", e.X)
+}
+
+// InExpr tests a value for membership in a list
+type InExpr struct {
+	X    Expr
+	List []Expr
+	Not  bool
+}
+
+func (e *InExpr) String() string {
+	strs := make([]string, len(e.List))
+	for i, x := range e.List {
+		strs[i] = x.String()
+	}
+	op := "IN"
+	if e.Not {
+		op = "NOT IN"
+	}
+	return fmt.Sprintf("(%s %s (%s))", e.X, op, strings.Join(strs, ", "))
+}
+
+// FuncCall is a call to a scalar or aggregate function
+type FuncCall struct {
+	Name     string
+	Args     []Expr
+	Star     bool
+	Distinct bool
+}
+
+func (f *FuncCall) String() string {
+	if f.Star {
+		return f.Name + "(*)"
+	}
+	strs := make([]string, len(f.Args))
+	for i, a := range f.Args {
+		strs[i] = a.String()
+	}
+	if f.Distinct {
+		return fmt.Sprintf("%s(DISTINCT %s)", f.Name, strings.Join(strs, ", "))
+	}
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(strs, ", "))
+}
+
+// IsAggregate returns true if this function call aggregates over a group
+func (f *FuncCall) IsAggregate() bool {
+	return aggregates[f.Name]
+}
+
+// SelectField is a single projected expression with an optional alias
+type SelectField struct {
+	Expr  Expr
+	Alias string
+}
+
+// Name gives the column name this field will produce
+func (f SelectField) Name() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	if c, ok := f.Expr.(*ColumnRef); ok {
+		return c.Name
+	}
+	return f.Expr.String()
+}
+
+// TableRef names a source table, typically a dataset reference
+// in the form peername.dataset_name
+type TableRef struct {
+	Name  string
+	Alias string
+}
+
+// Join combines an additional table into the row set
+type Join struct {
+	Left  bool
+	Table TableRef
+	On    Expr
+}
+
+// OrderTerm is a single sort key
+type OrderTerm struct {
+	Expr Expr
+	Desc bool
+}
+
+// Select is a parsed SELECT statement
+type Select struct {
+	Distinct bool
+	Fields   []SelectField
+	From     TableRef
+	Joins    []Join
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []OrderTerm
+	// Limit & Offset are -1 when not specified
+	Limit  int
+	Offset int
+}
+
+// TableNames lists the names of all tables this statement reads from,
+// in order of appearance
+func (s *Select) TableNames() []string {
+	names := []string{s.From.Name}
+	for _, j := range s.Joins {
+		names = append(names, j.Table.Name)
+	}
+	return names
+}
+
+// hasAggregate reports weather an expression contains an aggregate function
+func hasAggregate(e Expr) bool {
+	switch x := e.(type) {
+	case *FuncCall:
+		if x.IsAggregate() {
+			return true
+		}
+		for _, a := range x.Args {
+			if hasAggregate(a) {
+				return true
+			}
+		}
+	case *BinaryExpr:
+		return hasAggregate(x.Left) || hasAggregate(x.Right)
+	case *UnaryExpr:
+		return hasAggregate(x.X)
+	case *IsNullExpr:
+		return hasAggregate(x.X)
+	case *InExpr:
+		if hasAggregate(x.X) {
+			return true
+		}
+		for _, l := range x.List {
+			if hasAggregate(l) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,599 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// column describes a single column in a row set. qualifiers holds every
+// name the column's table can be referred to by
+type column struct {
+	qualifiers []string
+	name       string
+}
+
+// scope is the set of columns visible to an expression
+type scope struct {
+	cols []column
+}
+
+// tableQualifiers lists the names a table reference answers to:
+// its alias, its full name, and the last dotted segment of its name
+func tableQualifiers(ref TableRef) []string {
+	q := []string{ref.Name}
+	if ref.Alias != "" {
+		q = append(q, ref.Alias)
+	}
+	if i := strings.LastIndex(ref.Name, "."); i >= 0 {
+		q = append(q, ref.Name[i+1:])
+	}
+	return q
+}
+
+func (c column) matches(table, name string) bool {
+	if !strings.EqualFold(c.name, name) {
+		return false
+	}
+	if table == "" {
+		return true
+	}
+	for _, q := range c.qualifiers {
+		if q == table {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve finds the index of a referenced column, preferring exact-case matches
+func (s *scope) resolve(ref *ColumnRef) (int, error) {
+	var matches, exact []int
+	for i, c := range s.cols {
+		if c.matches(ref.Table, ref.Name) {
+			matches = append(matches, i)
+			if c.name == ref.Name {
+				exact = append(exact, i)
+			}
+		}
+	}
+	if len(exact) > 0 {
+		matches = exact
+	}
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("unknown column: %s", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return -1, fmt.Errorf("ambiguous column reference: %s", ref)
+}
+
+// colIndex is an already-resolved column reference, produced when
+// expanding * selections
+type colIndex struct {
+	idx  int
+	name string
+}
+
+func (c *colIndex) String() string { return c.name }
+
+// rowContext binds a scope to a row, and optionally the group of rows
+// that row represents for evaluating aggregates
+type rowContext struct {
+	scope *scope
+	row   []interface{}
+	group [][]interface{}
+	agg   bool
+}
+
+func (c *rowContext) value(idx int) interface{} {
+	if idx < len(c.row) {
+		return c.row[idx]
+	}
+	return nil
+}
+
+// eval evaluates an expression in this context
+func (c *rowContext) eval(e Expr) (interface{}, error) {
+	switch x := e.(type) {
+	case *Literal:
+		return x.Value, nil
+	case *colIndex:
+		return c.value(x.idx), nil
+	case *ColumnRef:
+		idx, err := c.scope.resolve(x)
+		if err != nil {
+			return nil, err
+		}
+		return c.value(idx), nil
+	case *Star:
+		return nil, fmt.Errorf("cannot use * in an expression")
+	case *UnaryExpr:
+		v, err := c.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		switch x.Op {
+		case "NOT":
+			if v == nil {
+				return nil, nil
+			}
+			return !truthy(v), nil
+		case "-":
+			if v == nil {
+				return nil, nil
+			}
+			n, ok := toNumber(v)
+			if !ok {
+				return nil, fmt.Errorf("cannot negate non-numeric value: %v", v)
+			}
+			return -n, nil
+		}
+		return nil, fmt.Errorf("unknown unary operator: %s", x.Op)
+	case *BinaryExpr:
+		return c.evalBinary(x)
+	case *IsNullExpr:
+		v, err := c.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		return (v == nil) != x.Not, nil
+	case *InExpr:
+		v, err := c.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, nil
+		}
+		for _, le := range x.List {
+			lv, err := c.eval(le)
+			if err != nil {
+				return nil, err
+			}
+			if cmp, ok := compare(v, lv); ok && cmp == 0 {
+				return !x.Not, nil
+			}
+		}
+		return x.Not, nil
+	case *FuncCall:
+		if x.IsAggregate() {
+			if !c.agg {
+				return nil, fmt.Errorf("aggregate function %s used outside of aggregation", x.Name)
+			}
+			return c.evalAggregate(x)
+		}
+		args := make([]interface{}, len(x.Args))
+		for i, a := range x.Args {
+			v, err := c.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return scalars[x.Name](args)
+	}
+	return nil, fmt.Errorf("cannot evaluate expression: %s", e)
+}
+
+func (c *rowContext) evalBinary(x *BinaryExpr) (interface{}, error) {
+	l, err := c.eval(x.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit logical operators
+	switch x.Op {
+	case "AND":
+		if l != nil && !truthy(l) {
+			return false, nil
+		}
+		r, err := c.eval(x.Right)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil && !truthy(r) {
+			return false, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return true, nil
+	case "OR":
+		if l != nil && truthy(l) {
+			return true, nil
+		}
+		r, err := c.eval(x.Right)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil && truthy(r) {
+			return true, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return false, nil
+	}
+
+	r, err := c.eval(x.Right)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+
+	switch x.Op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		cmp, ok := compare(l, r)
+		if !ok {
+			return nil, nil
+		}
+		switch x.Op {
+		case "=":
+			return cmp == 0, nil
+		case "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "LIKE":
+		re, err := likePattern(toString(r))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(l)), nil
+	case "||":
+		return toString(l) + toString(r), nil
+	case "+", "-", "*", "/", "%":
+		a, aok := toNumber(l)
+		b, bok := toNumber(r)
+		if !aok || !bok {
+			return nil, fmt.Errorf("operator %s requires numeric values, got %v and %v", x.Op, l, r)
+		}
+		switch x.Op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		case "/":
+			if b == 0 {
+				return nil, nil
+			}
+			return a / b, nil
+		default:
+			if b == 0 {
+				return nil, nil
+			}
+			return math.Mod(a, b), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operator: %s", x.Op)
+}
+
+// aggregates is the set of supported aggregate functions
+var aggregates = map[string]bool{
+	"COUNT": true,
+	"SUM":   true,
+	"AVG":   true,
+	"MIN":   true,
+	"MAX":   true,
+}
+
+func (c *rowContext) evalAggregate(fn *FuncCall) (interface{}, error) {
+	if fn.Star {
+		return float64(len(c.group)), nil
+	}
+	if len(fn.Args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly one argument", fn.Name)
+	}
+
+	var (
+		vals = make([]interface{}, 0, len(c.group))
+		seen = map[string]bool{}
+	)
+	for _, row := range c.group {
+		rc := &rowContext{scope: c.scope, row: row}
+		v, err := rc.eval(fn.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if fn.Distinct {
+			k := valueKey(v)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		vals = append(vals, v)
+	}
+
+	switch fn.Name {
+	case "COUNT":
+		return float64(len(vals)), nil
+	case "SUM", "AVG":
+		if len(vals) == 0 {
+			return nil, nil
+		}
+		sum := 0.0
+		for _, v := range vals {
+			n, ok := toNumber(v)
+			if !ok {
+				return nil, fmt.Errorf("%s requires numeric values, got %v", fn.Name, v)
+			}
+			sum += n
+		}
+		if fn.Name == "AVG" {
+			return sum / float64(len(vals)), nil
+		}
+		return sum, nil
+	case "MIN", "MAX":
+		var res interface{}
+		for _, v := range vals {
+			if res == nil {
+				res = v
+				continue
+			}
+			cmp, ok := compare(v, res)
+			if ok && (fn.Name == "MIN" && cmp < 0 || fn.Name == "MAX" && cmp > 0) {
+				res = v
+			}
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unknown aggregate: %s", fn.Name)
+}
+
+// scalars maps upper-cased function names to implementations
+var scalars = map[string]func(args []interface{}) (interface{}, error){
+	"UPPER": func(args []interface{}) (interface{}, error) {
+		return stringFunc("UPPER", args, strings.ToUpper)
+	},
+	"LOWER": func(args []interface{}) (interface{}, error) {
+		return stringFunc("LOWER", args, strings.ToLower)
+	},
+	"TRIM": func(args []interface{}) (interface{}, error) {
+		return stringFunc("TRIM", args, strings.TrimSpace)
+	},
+	"LENGTH": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("LENGTH takes exactly one argument")
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		return float64(len([]rune(toString(args[0])))), nil
+	},
+	"ABS": func(args []interface{}) (interface{}, error) {
+		return numberFunc("ABS", args, math.Abs)
+	},
+	"ROUND": func(args []interface{}) (interface{}, error) {
+		if len(args) == 0 || len(args) > 2 {
+			return nil, fmt.Errorf("ROUND takes one or two arguments")
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		n, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("ROUND requires a numeric value, got %v", args[0])
+		}
+		places := 0.0
+		if len(args) == 2 {
+			if places, ok = toNumber(args[1]); !ok {
+				return nil, fmt.Errorf("ROUND requires numeric precision, got %v", args[1])
+			}
+		}
+		pow := math.Pow(10, places)
+		return math.Floor(n*pow+0.5) / pow, nil
+	},
+	"COALESCE": func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	},
+}
+
+func stringFunc(name string, args []interface{}, fn func(string) string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly one argument", name)
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return fn(toString(args[0])), nil
+}
+
+func numberFunc(name string, args []interface{}, fn func(float64) float64) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly one argument", name)
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	n, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%s requires a numeric value, got %v", name, args[0])
+	}
+	return fn(n), nil
+}
+
+// Normalize converts go values into the small set of types the query
+// engine works with: float64, string, bool, nil, and their compositions
+func Normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case []interface{}:
+		for i, e := range x {
+			x[i] = Normalize(e)
+		}
+		return x
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = Normalize(e)
+		}
+		return x
+	}
+	return v
+}
+
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// compare orders two non-null values, returning false if they
+// cannot be compared. Numbers compare numerically, even when one side
+// is a numeric string
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	switch x := a.(type) {
+	case float64:
+		if y, ok := toNumber(b); ok {
+			return compareFloat(x, y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+		if y, ok := b.(float64); ok {
+			if xn, ok := toNumber(x); ok {
+				return compareFloat(xn, y), true
+			}
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	}
+	return strings.Compare(toString(a), toString(b)), true
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// orderValues compares values for sorting, placing nulls first
+func orderValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	cmp, _ := compare(a, b)
+	return cmp
+}
+
+// valueKey gives a string that uniquely identifies a value, for use in
+// grouping & deduplication
+func valueKey(v interface{}) string {
+	return fmt.Sprintf("%T:%v", v, v)
+}
+
+func rowKey(vals []interface{}) string {
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		strs[i] = valueKey(v)
+	}
+	return strings.Join(strs, "\x00")
+}
+
+// likePattern converts a SQL LIKE pattern to a case-insensitive regular expression
+func likePattern(pattern string) (*regexp.Regexp, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			buf.WriteString(".*")
+		case '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
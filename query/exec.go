@@ -0,0 +1,316 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Exec executes a parsed statement against a set of tables, keyed by the
+// table names used in the statement
+func Exec(sel *Select, tables map[string]*Table) (*Table, error) {
+	// work on a copy, callers may execute the same statement again
+	s := *sel
+	sel = &s
+
+	from, ok := tables[sel.From.Name]
+	if !ok {
+		return nil, fmt.Errorf("table not found: %s", sel.From.Name)
+	}
+
+	sc := &scope{}
+	sc.cols = append(sc.cols, tableColumns(sel.From, from)...)
+	rows := make([][]interface{}, len(from.Rows))
+	copy(rows, from.Rows)
+
+	for _, j := range sel.Joins {
+		right, ok := tables[j.Table.Name]
+		if !ok {
+			return nil, fmt.Errorf("table not found: %s", j.Table.Name)
+		}
+		var err error
+		if rows, err = join(sc, rows, j, right); err != nil {
+			return nil, err
+		}
+	}
+
+	if sel.Where != nil {
+		filtered := rows[:0:0]
+		for _, row := range rows {
+			v, err := (&rowContext{scope: sc, row: row}).eval(sel.Where)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating WHERE: %s", err.Error())
+			}
+			if truthy(v) {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	fields, err := expandFields(sc, sel.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxs, err := sourceContexts(sc, sel, fields, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Table{Columns: fieldNames(fields)}
+	out := make([][]interface{}, len(ctxs))
+	for i, ctx := range ctxs {
+		row := make([]interface{}, len(fields))
+		for j, f := range fields {
+			if row[j], err = ctx.eval(f.Expr); err != nil {
+				return nil, err
+			}
+		}
+		out[i] = row
+	}
+
+	if len(sel.OrderBy) > 0 {
+		if out, err = orderRows(sel.OrderBy, res.Columns, ctxs, out); err != nil {
+			return nil, err
+		}
+	}
+
+	if sel.Distinct {
+		seen := map[string]bool{}
+		deduped := out[:0:0]
+		for _, row := range out {
+			k := rowKey(row)
+			if !seen[k] {
+				seen[k] = true
+				deduped = append(deduped, row)
+			}
+		}
+		out = deduped
+	}
+
+	if sel.Offset > 0 {
+		if sel.Offset > len(out) {
+			sel.Offset = len(out)
+		}
+		out = out[sel.Offset:]
+	}
+	if sel.Limit >= 0 && sel.Limit < len(out) {
+		out = out[:sel.Limit]
+	}
+
+	res.Rows = out
+	return res, nil
+}
+
+func tableColumns(ref TableRef, t *Table) []column {
+	q := tableQualifiers(ref)
+	cols := make([]column, len(t.Columns))
+	for i, name := range t.Columns {
+		cols[i] = column{qualifiers: q, name: name}
+	}
+	return cols
+}
+
+// join combines rows with a right-hand table using a nested loop,
+// adding the right table's columns to the scope
+func join(sc *scope, rows [][]interface{}, j Join, right *Table) ([][]interface{}, error) {
+	width := len(sc.cols)
+	sc.cols = append(sc.cols, tableColumns(j.Table, right)...)
+
+	joined := [][]interface{}{}
+	for _, l := range rows {
+		matched := false
+		for _, r := range right.Rows {
+			row := make([]interface{}, 0, len(sc.cols))
+			row = append(append(row, pad(l, width)...), r...)
+			v, err := (&rowContext{scope: sc, row: row}).eval(j.On)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating JOIN condition: %s", err.Error())
+			}
+			if truthy(v) {
+				matched = true
+				joined = append(joined, row)
+			}
+		}
+		if !matched && j.Left {
+			joined = append(joined, pad(l, len(sc.cols)))
+		}
+	}
+	return joined, nil
+}
+
+// pad extends a row with nulls to a given width
+func pad(row []interface{}, width int) []interface{} {
+	if len(row) >= width {
+		return row[:width]
+	}
+	padded := make([]interface{}, width)
+	copy(padded, row)
+	return padded
+}
+
+// expandFields replaces * selections with resolved column references
+func expandFields(sc *scope, fields []SelectField) ([]SelectField, error) {
+	// count column names to qualify duplicates that * would produce
+	counts := map[string]int{}
+	for _, c := range sc.cols {
+		counts[c.name]++
+	}
+
+	expanded := []SelectField{}
+	for _, f := range fields {
+		star, ok := f.Expr.(*Star)
+		if !ok {
+			expanded = append(expanded, f)
+			continue
+		}
+		found := false
+		for i, c := range sc.cols {
+			if star.Table != "" && !c.matches(star.Table, c.name) {
+				continue
+			}
+			found = true
+			name := c.name
+			if counts[name] > 1 {
+				name = c.qualifiers[len(c.qualifiers)-1] + "." + name
+			}
+			expanded = append(expanded, SelectField{Expr: &colIndex{idx: i, name: c.name}, Alias: name})
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown table: %s", star.Table)
+		}
+	}
+	return expanded, nil
+}
+
+func fieldNames(fields []SelectField) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// sourceContexts produces one context per output row. Aggregate
+// queries produce one context per group
+func sourceContexts(sc *scope, sel *Select, fields []SelectField, rows [][]interface{}) ([]*rowContext, error) {
+	aggregate := len(sel.GroupBy) > 0 || sel.Having != nil
+	for _, f := range fields {
+		if hasAggregate(f.Expr) {
+			aggregate = true
+		}
+	}
+
+	if !aggregate {
+		ctxs := make([]*rowContext, len(rows))
+		for i, row := range rows {
+			ctxs[i] = &rowContext{scope: sc, row: row}
+		}
+		return ctxs, nil
+	}
+
+	var (
+		groups = map[string]*rowContext{}
+		ctxs   = []*rowContext{}
+	)
+	for _, row := range rows {
+		key := make([]interface{}, len(sel.GroupBy))
+		for i, g := range sel.GroupBy {
+			v, err := (&rowContext{scope: sc, row: row}).eval(g)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating GROUP BY: %s", err.Error())
+			}
+			key[i] = v
+		}
+		k := rowKey(key)
+		if groups[k] == nil {
+			groups[k] = &rowContext{scope: sc, row: row, agg: true}
+			ctxs = append(ctxs, groups[k])
+		}
+		groups[k].group = append(groups[k].group, row)
+	}
+
+	// aggregating without grouping always yields a single row
+	if len(sel.GroupBy) == 0 && len(ctxs) == 0 {
+		ctxs = append(ctxs, &rowContext{scope: sc, agg: true})
+	}
+
+	if sel.Having != nil {
+		filtered := ctxs[:0:0]
+		for _, ctx := range ctxs {
+			v, err := ctx.eval(sel.Having)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating HAVING: %s", err.Error())
+			}
+			if truthy(v) {
+				filtered = append(filtered, ctx)
+			}
+		}
+		ctxs = filtered
+	}
+	return ctxs, nil
+}
+
+// orderRows sorts output rows. Order terms may refer to output column
+// names, 1-indexed output positions, or any expression on the source rows
+func orderRows(terms []OrderTerm, columns []string, ctxs []*rowContext, out [][]interface{}) ([][]interface{}, error) {
+	type keyed struct {
+		keys []interface{}
+		row  []interface{}
+	}
+
+	rows := make([]keyed, len(out))
+	for i, row := range out {
+		keys := make([]interface{}, len(terms))
+		for j, t := range terms {
+			v, err := orderKey(t.Expr, columns, ctxs[i], row)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating ORDER BY: %s", err.Error())
+			}
+			keys[j] = v
+		}
+		rows[i] = keyed{keys: keys, row: row}
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		for j, t := range terms {
+			cmp := orderValues(rows[a].keys[j], rows[b].keys[j])
+			if cmp == 0 {
+				continue
+			}
+			if t.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	sorted := make([][]interface{}, len(rows))
+	for i, r := range rows {
+		sorted[i] = r.row
+	}
+	return sorted, nil
+}
+
+func orderKey(e Expr, columns []string, ctx *rowContext, row []interface{}) (interface{}, error) {
+	switch x := e.(type) {
+	case *ColumnRef:
+		if x.Table == "" {
+			for i, name := range columns {
+				if name == x.Name {
+					return row[i], nil
+				}
+			}
+		}
+	case *Literal:
+		if f, ok := x.Value.(float64); ok {
+			i := int(f)
+			if float64(i) != f || i < 1 || i > len(row) {
+				return nil, fmt.Errorf("position %v is not in select list", f)
+			}
+			return row[i-1], nil
+		}
+	}
+	return ctx.eval(e)
+}
@@ -0,0 +1,126 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterOps lists predicate operators, longest first so parsing
+// matches ">=" before ">"
+var filterOps = []string{">=", "<=", "!=", "~", "=", ">", "<"}
+
+// Predicate is a single comparison of a column against a constant. Predicates
+// filter rows without the overhead of parsing & planning a full statement
+type Predicate struct {
+	Column string `json:"column"`
+	// Op is one of =, !=, <, <=, >, >= or ~ (contains)
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// ParsePredicate reads a predicate in the form [column][op][value], eg:
+//     city=toronto
+//     pop>=1000000
+//     name~ann
+// numeric values are parsed as numbers, "null" as a null value
+func ParsePredicate(str string) (*Predicate, error) {
+	idx, op := -1, ""
+	for _, o := range filterOps {
+		if i := strings.Index(str, o); i > 0 && (idx == -1 || i < idx) {
+			idx, op = i, o
+		}
+	}
+	if idx == -1 {
+		return nil, fmt.Errorf("invalid predicate '%s'. expected [column][op][value], where op is one of: %s", str, strings.Join(filterOps, " "))
+	}
+	// an op found earlier in the string may be the prefix of a longer op
+	for _, o := range filterOps {
+		if len(o) > len(op) && strings.HasPrefix(str[idx:], o) {
+			op = o
+		}
+	}
+
+	p := &Predicate{
+		Column: strings.TrimSpace(str[:idx]),
+		Op:     op,
+	}
+	raw := strings.TrimSpace(str[idx+len(op):])
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		p.Value = f
+	} else if raw == "null" {
+		p.Value = nil
+	} else {
+		p.Value = raw
+	}
+	return p, nil
+}
+
+// String implements the stringer interface
+func (p *Predicate) String() string {
+	if p.Value == nil {
+		return p.Column + p.Op + "null"
+	}
+	return p.Column + p.Op + toString(p.Value)
+}
+
+// Match tests a value against the predicate
+func (p *Predicate) Match(v interface{}) bool {
+	if p.Value == nil || v == nil {
+		switch p.Op {
+		case "=":
+			return p.Value == nil && v == nil
+		case "!=":
+			return (p.Value == nil) != (v == nil)
+		}
+		return false
+	}
+
+	if p.Op == "~" {
+		return strings.Contains(strings.ToLower(toString(v)), strings.ToLower(toString(p.Value)))
+	}
+
+	cmp, ok := compare(v, p.Value)
+	if !ok {
+		return false
+	}
+	switch p.Op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// SortKey orders rows by a single column
+type SortKey struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
+}
+
+// ParseSortKey reads a column name, a "-" prefix sorts descending
+func ParseSortKey(str string) SortKey {
+	if strings.HasPrefix(str, "-") {
+		return SortKey{Column: str[1:], Desc: true}
+	}
+	return SortKey{Column: strings.TrimPrefix(str, "+")}
+}
+
+// CompareValues orders two normalized values, placing nulls first
+func CompareValues(a, b interface{}) int {
+	return orderValues(a, b)
+}
+
+// RowKey gives a string that uniquely identifies a row of normalized values
+func RowKey(row []interface{}) string {
+	return rowKey(row)
+}
@@ -0,0 +1,161 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenType enumerates the kinds of lexical tokens in a statement
+type tokenType int
+
+const (
+	tEOF tokenType = iota
+	tIdent
+	tKeyword
+	tNumber
+	tString
+	tOperator
+	tComma
+	tDot
+	tLParen
+	tRParen
+	tStar
+)
+
+// token is a single lexical item
+type token struct {
+	Type tokenType
+	Text string
+	Pos  int
+}
+
+func (t token) String() string {
+	if t.Type == tEOF {
+		return "end of statement"
+	}
+	return fmt.Sprintf("'%s'", t.Text)
+}
+
+// keywords are reserved words, stored upper-cased
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "AS": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "OUTER": true, "ON": true,
+	"GROUP": true, "BY": true, "HAVING": true, "ORDER": true, "ASC": true,
+	"DESC": true, "LIMIT": true, "OFFSET": true, "AND": true, "OR": true,
+	"NOT": true, "NULL": true, "IS": true, "TRUE": true, "FALSE": true,
+	"LIKE": true, "IN": true, "BETWEEN": true,
+}
+
+// lex splits a statement into tokens
+func lex(stmt string) ([]token, error) {
+	var (
+		toks  []token
+		runes = []rune(stmt)
+		i     = 0
+	)
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// line comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			text := string(runes[start:i])
+			if keywords[strings.ToUpper(text)] {
+				toks = append(toks, token{tKeyword, strings.ToUpper(text), start})
+			} else {
+				toks = append(toks, token{tIdent, text, start})
+			}
+		case r == '"' || r == '`':
+			// quoted identifier
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				i++
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated quoted identifier at position %d", start)
+			}
+			toks = append(toks, token{tIdent, string(runes[start+1 : i]), start})
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{tNumber, string(runes[start:i]), start})
+		case r == '\'':
+			start := i
+			i++
+			buf := []rune{}
+			for {
+				if i == len(runes) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if runes[i] == '\'' {
+					// '' escapes a single quote
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						buf = append(buf, '\'')
+						i += 2
+						continue
+					}
+					break
+				}
+				buf = append(buf, runes[i])
+				i++
+			}
+			toks = append(toks, token{tString, string(buf), start})
+			i++
+		case r == ',':
+			toks = append(toks, token{tComma, ",", i})
+			i++
+		case r == '.':
+			toks = append(toks, token{tDot, ".", i})
+			i++
+		case r == '(':
+			toks = append(toks, token{tLParen, "(", i})
+			i++
+		case r == ')':
+			toks = append(toks, token{tRParen, ")", i})
+			i++
+		case r == '*':
+			toks = append(toks, token{tStar, "*", i})
+			i++
+		case r == ';':
+			i++
+		default:
+			start := i
+			for _, op := range []string{"<=", ">=", "<>", "!=", "=", "<", ">", "+", "-", "/", "%", "||"} {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					toks = append(toks, token{tOperator, op, start})
+					i += len([]rune(op))
+					break
+				}
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+
+	toks = append(toks, token{tEOF, "", len(runes)})
+	return toks, nil
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isIdentPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
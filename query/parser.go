@@ -0,0 +1,563 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse reads a SQL SELECT statement into a Select
+func Parse(stmt string) (*Select, error) {
+	toks, err := lex(stmt)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	sel, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if !p.at(tEOF) {
+		return nil, p.errorf("unexpected %s", p.peek())
+	}
+	return sel, nil
+}
+
+// parser is a recursive-descent parser over a slice of tokens
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.Type != tEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) at(t tokenType) bool {
+	return p.peek().Type == t
+}
+
+func (p *parser) atKeyword(kws ...string) bool {
+	t := p.peek()
+	if t.Type != tKeyword {
+		return false
+	}
+	for _, kw := range kws {
+		if t.Text == kw {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKeyword consumes a keyword if it's next
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.atKeyword(kw) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.errorf("expected %s, got %s", kw, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) expect(t tokenType, desc string) (token, error) {
+	if !p.at(t) {
+		return token{}, p.errorf("expected %s, got %s", desc, p.peek())
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.peek().Pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	sel := &Select{Limit: -1, Offset: -1}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	sel.Distinct = p.acceptKeyword("DISTINCT")
+
+	for {
+		f, err := p.parseSelectField()
+		if err != nil {
+			return nil, err
+		}
+		sel.Fields = append(sel.Fields, f)
+		if !p.at(tComma) {
+			break
+		}
+		p.next()
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	sel.From = from
+
+	for p.atKeyword("JOIN", "INNER", "LEFT") {
+		j := Join{}
+		if p.acceptKeyword("LEFT") {
+			j.Left = true
+			p.acceptKeyword("OUTER")
+		} else {
+			p.acceptKeyword("INNER")
+		}
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+		if j.Table, err = p.parseTableRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		if j.On, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		sel.Joins = append(sel.Joins, j)
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if sel.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			sel.GroupBy = append(sel.GroupBy, e)
+			if !p.at(tComma) {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.acceptKeyword("HAVING") {
+		if sel.Having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			term := OrderTerm{Expr: e}
+			if p.acceptKeyword("DESC") {
+				term.Desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			sel.OrderBy = append(sel.OrderBy, term)
+			if !p.at(tComma) {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		if sel.Limit, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if sel.Offset, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sel, nil
+}
+
+func (p *parser) parseInt() (int, error) {
+	t, err := p.expect(tNumber, "number")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(t.Text)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid non-negative integer: %s", t.Text)
+	}
+	return i, nil
+}
+
+func (p *parser) parseSelectField() (SelectField, error) {
+	f := SelectField{}
+	if p.at(tStar) {
+		p.next()
+		f.Expr = &Star{}
+		return f, nil
+	}
+	// table.* form
+	if p.at(tIdent) {
+		start := p.pos
+		parts := []string{p.next().Text}
+		for p.at(tDot) {
+			p.next()
+			if p.at(tStar) {
+				p.next()
+				f.Expr = &Star{Table: strings.Join(parts, ".")}
+				return f, nil
+			}
+			t, err := p.expect(tIdent, "identifier")
+			if err != nil {
+				return f, err
+			}
+			parts = append(parts, t.Text)
+		}
+		p.pos = start
+	}
+
+	e, err := p.parseExpr()
+	if err != nil {
+		return f, err
+	}
+	f.Expr = e
+	if p.acceptKeyword("AS") {
+		t, err := p.expect(tIdent, "alias")
+		if err != nil {
+			return f, err
+		}
+		f.Alias = t.Text
+	} else if p.at(tIdent) {
+		f.Alias = p.next().Text
+	}
+	return f, nil
+}
+
+// parseTableRef reads a dotted table name with an optional alias
+func (p *parser) parseTableRef() (TableRef, error) {
+	ref := TableRef{}
+	t, err := p.expect(tIdent, "table name")
+	if err != nil {
+		return ref, err
+	}
+	parts := []string{t.Text}
+	for p.at(tDot) {
+		p.next()
+		t, err := p.expect(tIdent, "table name")
+		if err != nil {
+			return ref, err
+		}
+		parts = append(parts, t.Text)
+	}
+	ref.Name = strings.Join(parts, ".")
+
+	if p.acceptKeyword("AS") {
+		t, err := p.expect(tIdent, "alias")
+		if err != nil {
+			return ref, err
+		}
+		ref.Alias = t.Text
+	} else if p.at(tIdent) {
+		ref.Alias = p.next().Text
+	}
+	return ref, nil
+}
+
+// expression grammar, lowest to highest precedence:
+// OR, AND, NOT, comparison, additive, multiplicative, unary, primary
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: "NOT", X: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.at(tOperator) && isComparisonOp(p.peek().Text):
+		op := p.next().Text
+		if op == "<>" {
+			op = "!="
+		}
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Op: op, Left: left, Right: right}, nil
+	case p.atKeyword("IS"):
+		p.next()
+		not := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &IsNullExpr{X: left, Not: not}, nil
+	case p.atKeyword("NOT", "LIKE", "IN", "BETWEEN"):
+		not := p.acceptKeyword("NOT")
+		switch {
+		case p.acceptKeyword("LIKE"):
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			var e Expr = &BinaryExpr{Op: "LIKE", Left: left, Right: right}
+			if not {
+				e = &UnaryExpr{Op: "NOT", X: e}
+			}
+			return e, nil
+		case p.acceptKeyword("IN"):
+			if _, err := p.expect(tLParen, "'('"); err != nil {
+				return nil, err
+			}
+			in := &InExpr{X: left, Not: not}
+			for {
+				e, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				in.List = append(in.List, e)
+				if !p.at(tComma) {
+					break
+				}
+				p.next()
+			}
+			if _, err := p.expect(tRParen, "')'"); err != nil {
+				return nil, err
+			}
+			return in, nil
+		case p.acceptKeyword("BETWEEN"):
+			lo, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AND"); err != nil {
+				return nil, err
+			}
+			hi, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			var e Expr = &BinaryExpr{
+				Op:    "AND",
+				Left:  &BinaryExpr{Op: ">=", Left: left, Right: lo},
+				Right: &BinaryExpr{Op: "<=", Left: left, Right: hi},
+			}
+			if not {
+				e = &UnaryExpr{Op: "NOT", X: e}
+			}
+			return e, nil
+		}
+		return nil, p.errorf("expected LIKE, IN or BETWEEN after NOT, got %s", p.peek())
+	}
+
+	return left, nil
+}
+
+func isComparisonOp(op string) bool {
+	switch op {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(tOperator) && (p.peek().Text == "+" || p.peek().Text == "-" || p.peek().Text == "||") {
+		op := p.next().Text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(tStar) || p.at(tOperator) && (p.peek().Text == "/" || p.peek().Text == "%") {
+		op := p.next().Text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.at(tOperator) && p.peek().Text == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: "-", X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.Type {
+	case tNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.Text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", t.Text)
+		}
+		return &Literal{Value: f}, nil
+	case tString:
+		p.next()
+		return &Literal{Value: t.Text}, nil
+	case tLParen:
+		p.next()
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case tKeyword:
+		switch t.Text {
+		case "NULL":
+			p.next()
+			return &Literal{Value: nil}, nil
+		case "TRUE":
+			p.next()
+			return &Literal{Value: true}, nil
+		case "FALSE":
+			p.next()
+			return &Literal{Value: false}, nil
+		}
+	case tIdent:
+		p.next()
+		if p.at(tLParen) {
+			return p.parseFuncCall(t.Text)
+		}
+		parts := []string{t.Text}
+		for p.at(tDot) {
+			p.next()
+			id, err := p.expect(tIdent, "identifier")
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, id.Text)
+		}
+		return &ColumnRef{
+			Table: strings.Join(parts[:len(parts)-1], "."),
+			Name:  parts[len(parts)-1],
+		}, nil
+	}
+	return nil, p.errorf("unexpected %s", t)
+}
+
+func (p *parser) parseFuncCall(name string) (Expr, error) {
+	p.next() // consume '('
+	fn := &FuncCall{Name: strings.ToUpper(name)}
+	if !aggregates[fn.Name] && scalars[fn.Name] == nil {
+		return nil, fmt.Errorf("unknown function: %s", name)
+	}
+
+	if p.at(tStar) {
+		p.next()
+		fn.Star = true
+	} else if !p.at(tRParen) {
+		fn.Distinct = p.acceptKeyword("DISTINCT")
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.Args = append(fn.Args, e)
+			if !p.at(tComma) {
+				break
+			}
+			p.next()
+		}
+	}
+	if _, err := p.expect(tRParen, "')'"); err != nil {
+		return nil, err
+	}
+	if fn.Star && fn.Name != "COUNT" {
+		return nil, fmt.Errorf("%s(*) is not supported", fn.Name)
+	}
+	return fn, nil
+}
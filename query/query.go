@@ -0,0 +1,22 @@
+// Package query implements a small SQL engine for querying qri datasets.
+// Statements are parsed into a Select tree & executed against in-memory
+// tables. The engine has no knowledge of datasets, callers are expected
+// to load & normalize rows for every table a statement names, which can
+// be listed with Select.TableNames
+package query
+
+// Table is an in-memory set of rows with named columns. Row values
+// must be normalized, see Normalize
+type Table struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Run parses & executes a statement against a set of named tables
+func Run(stmt string, tables map[string]*Table) (*Table, error) {
+	sel, err := Parse(stmt)
+	if err != nil {
+		return nil, err
+	}
+	return Exec(sel, tables)
+}
@@ -0,0 +1,186 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testTables() map[string]*Table {
+	return map[string]*Table{
+		"peer.movies": {
+			Columns: []string{"title", "duration", "director_id"},
+			Rows: [][]interface{}{
+				{"Avatar", 178.0, 1.0},
+				{"Spectre", 148.0, 2.0},
+				{"Tangled", 100.0, 3.0},
+				{"Titanic", 194.0, 1.0},
+				{"Untitled", nil, nil},
+			},
+		},
+		"peer.directors": {
+			Columns: []string{"id", "name"},
+			Rows: [][]interface{}{
+				{1.0, "James Cameron"},
+				{2.0, "Sam Mendes"},
+				{4.0, "Nobody"},
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		stmt   string
+		expect string
+		err    string
+	}{
+		{"select title from peer.movies limit 2", `{"columns":["title"],"rows":[["Avatar"],["Spectre"]]}`, ""},
+		{"select title, duration from peer.movies where duration > 150 order by duration desc",
+			`{"columns":["title","duration"],"rows":[["Titanic",194],["Avatar",178]]}`, ""},
+		{"select * from peer.movies where title like 't%' and duration between 90 and 120",
+			`{"columns":["title","duration","director_id"],"rows":[["Tangled",100,3]]}`, ""},
+		{"select m.title, d.name from peer.movies m join peer.directors d on m.director_id = d.id order by m.title",
+			`{"columns":["title","name"],"rows":[["Avatar","James Cameron"],["Spectre","Sam Mendes"],["Titanic","James Cameron"]]}`, ""},
+		{"select movies.title, directors.name from peer.movies left join peer.directors on director_id = id where directors.name is null",
+			`{"columns":["title","name"],"rows":[["Tangled",null],["Untitled",null]]}`, ""},
+		{"select d.name, count(*) as n, max(m.duration) longest from peer.movies m join peer.directors d on m.director_id = d.id group by d.name order by n desc, d.name",
+			`{"columns":["name","n","longest"],"rows":[["James Cameron",2,194],["Sam Mendes",1,148]]}`, ""},
+		{"select count(*), count(duration), avg(duration) from peer.movies",
+			`{"columns":["COUNT(*)","COUNT(duration)","AVG(duration)"],"rows":[[5,4,155]]}`, ""},
+		{"select director_id, sum(duration) total from peer.movies group by director_id having total > 150",
+			`{"columns":["director_id","total"],"rows":[]}`, "error evaluating HAVING: unknown column: total"},
+		{"select director_id, sum(duration) total from peer.movies group by director_id having sum(duration) > 150",
+			`{"columns":["director_id","total"],"rows":[[1,372]]}`, ""},
+		{"select distinct director_id from peer.movies where director_id is not null order by 1",
+			`{"columns":["director_id"],"rows":[[1],[2],[3]]}`, ""},
+		{"select upper(title) as t from peer.movies where director_id in (2, 3) order by t limit 1 offset 1",
+			`{"columns":["t"],"rows":[["TANGLED"]]}`, ""},
+		{"select title, duration / 60 hours from peer.movies where title = 'Avatar'",
+			`{"columns":["title","hours"],"rows":[["Avatar",2.966666666666667]]}`, ""},
+
+		{"select title from peer.nope", "", "table not found: peer.nope"},
+		{"select nope from peer.movies", "", "unknown column: nope"},
+		{"select id from peer.movies join peer.directors on director_id = id join peer.directors on director_id = id", "", "error evaluating JOIN condition: ambiguous column reference: id"},
+		{"select title, count(*) from peer.movies where count(*) > 1", "", "error evaluating WHERE: aggregate function COUNT used outside of aggregation"},
+		{"select from peer.movies", "", "syntax error at position 7: unexpected 'FROM'"},
+		{"select title from peer.movies where", "", "syntax error at position 35: unexpected end of statement"},
+		{"select frob(title) from peer.movies", "", "unknown function: frob"},
+		{"select title from peer.movies limit -1", "", "syntax error at position 36: expected number, got '-'"},
+	}
+
+	for i, c := range cases {
+		got, err := Run(c.stmt, testTables())
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("case %d error marshaling result: %s", i, err.Error())
+			continue
+		}
+		if string(data) != c.expect {
+			t.Errorf("case %d result mismatch.\nexpected: %s\ngot:      %s", i, c.expect, string(data))
+		}
+	}
+}
+
+func TestExecKeepsStatement(t *testing.T) {
+	sel, err := Parse("select title from peer.movies offset 10")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := Exec(sel, testTables()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if sel.Offset != 10 {
+		t.Errorf("expected executing a statement to leave its offset unchanged. expected: 10, got: %d", sel.Offset)
+	}
+}
+
+func TestParseTableNames(t *testing.T) {
+	sel, err := Parse("SELECT * FROM b5.comics c JOIN b5.movies AS m ON c.id = m.id LEFT OUTER JOIN peer.a ON a.id = c.id")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []string{"b5.comics", "b5.movies", "peer.a"}
+	got := sel.TableNames()
+	if len(got) != len(expect) {
+		t.Fatalf("length mismatch. expected: %d, got: %d", len(expect), len(got))
+	}
+	for i, name := range expect {
+		if got[i] != name {
+			t.Errorf("index %d mismatch. expected: %s, got: %s", i, name, got[i])
+		}
+	}
+	if !sel.Joins[1].Left {
+		t.Errorf("expected second join to be a left join")
+	}
+}
+
+func TestParsePredicate(t *testing.T) {
+	cases := []struct {
+		str    string
+		expect string
+		err    string
+	}{
+		{"city=toronto", "city=toronto", ""},
+		{"pop>=1000", "pop>=1000", ""},
+		{"pop >= 1000", "pop>=1000", ""},
+		{"a<=b", "a<=b", ""},
+		{"name~ann", "name~ann", ""},
+		{"x!=null", "x!=null", ""},
+		{"url=http://a.com?b=c", "url=http://a.com?b=c", ""},
+		{"=foo", "", "invalid predicate '=foo'. expected [column][op][value], where op is one of: >= <= != ~ = > <"},
+		{"nope", "", "invalid predicate 'nope'. expected [column][op][value], where op is one of: >= <= != ~ = > <"},
+	}
+
+	for i, c := range cases {
+		got, err := ParsePredicate(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && got.String() != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got.String())
+		}
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	cases := []struct {
+		pred  string
+		val   interface{}
+		match bool
+	}{
+		{"a=toronto", "toronto", true},
+		{"a=toronto", "chicago", false},
+		{"a=10", 10.0, true},
+		{"a=10", "10", true},
+		{"a>5", 10.0, true},
+		{"a>5", 2.0, false},
+		{"a<=5", 5.0, true},
+		{"a!=5", 5.0, false},
+		{"a~RON", "toronto", true},
+		{"a~york", "toronto", false},
+		{"a=null", nil, true},
+		{"a!=null", nil, false},
+		{"a>5", nil, false},
+		{"a=true", true, true},
+	}
+
+	for i, c := range cases {
+		p, err := ParsePredicate(c.pred)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if got := p.Match(c.val); got != c.match {
+			t.Errorf("case %d %s match %v mismatch. expected: %t, got: %t", i, c.pred, c.val, c.match, got)
+		}
+	}
+}
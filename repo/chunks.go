@@ -0,0 +1,352 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+)
+
+// chunksMagic prefixes a chunk manifest, which is stored in place of the
+// data file of a chunked dataset
+const chunksMagic = "qri-chunks/1\n"
+
+// ChunkConfig sets the sizes of data chunks in bytes. Chunks end on row
+// boundaries, so sizes are approximate. Chunks never exceed MaxSize: a chunk
+// that reaches it is cut at its last row boundary, or mid-row if it holds
+// less than MinSize of complete rows
+type ChunkConfig struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+}
+
+// DefaultChunkConfig is the chunk configuration for chunked datasets
+var DefaultChunkConfig = ChunkConfig{
+	MinSize: 256 << 10,
+	AvgSize: 1 << 20,
+	MaxSize: 4 << 20,
+}
+
+// ChunkManifest lists the chunks that make up chunked data, in order
+type ChunkManifest struct {
+	// Length of the complete data in bytes
+	Length int     `json:"length"`
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Chunk is a piece of chunked data
+type Chunk struct {
+	Path   string `json:"path"`
+	Length int    `json:"length"`
+}
+
+// gear is a table of random values for the gear rolling hash
+var gear [256]uint64
+
+func init() {
+	// splitmix64, seeded with a constant so chunk boundaries are stable
+	// across processes
+	x := uint64(0x6a09e667f3bcc908)
+	for i := range gear {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+// SplitChunks splits data into chunks on row boundaries with content-
+// defined chunking. Boundaries depend only on the bytes leading up to them,
+// so an edit only changes the chunks around it, and appending rows leaves
+// all but the last chunk unchanged. Chunks are cut at MaxSize even if they
+// don't end a row
+func SplitChunks(r io.Reader, cfg ChunkConfig, chunk func([]byte) error) error {
+	// a boundary is found when the top bits of the hash are all zero, which
+	// happens once every AvgSize bytes on average
+	bits := uint(0)
+	for 1<<bits < cfg.AvgSize {
+		bits++
+	}
+	shift := 64 - bits
+
+	br := bufio.NewReader(r)
+	buf := &bytes.Buffer{}
+	var hash uint64
+	// pending is set once a boundary is found, the chunk ends at the end
+	// of the row
+	pending := false
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		buf.WriteByte(b)
+		hash = (hash << 1) + gear[b]
+		if buf.Len() >= cfg.MinSize && hash>>shift == 0 {
+			pending = true
+		}
+
+		if b == '\n' && pending {
+			if err := chunk(buf.Bytes()); err != nil {
+				return err
+			}
+			buf = &bytes.Buffer{}
+			pending = false
+		} else if buf.Len() >= cfg.MaxSize {
+			// cut at the last row boundary, carrying the rest of the row
+			// into the next chunk
+			data := buf.Bytes()
+			cut := bytes.LastIndexByte(data, '\n') + 1
+			if cut < cfg.MinSize {
+				cut = len(data)
+			}
+			if err := chunk(data[:cut]); err != nil {
+				return err
+			}
+			buf = bytes.NewBuffer(append([]byte{}, data[cut:]...))
+			pending = false
+		}
+	}
+	if buf.Len() > 0 {
+		return chunk(buf.Bytes())
+	}
+	return nil
+}
+
+// WriteChunks splits a file into chunks, putting each chunk in the store.
+// It returns a file holding the manifest of chunks, named like the
+// original file
+func WriteChunks(store cafs.Filestore, file cafs.File, cfg ChunkConfig, pin bool) (cafs.File, error) {
+	m := &ChunkManifest{Chunks: []Chunk{}}
+	err := SplitChunks(file, cfg, func(data []byte) error {
+		key, err := store.Put(memfs.NewMemfileBytes(fmt.Sprintf("chunk_%d", len(m.Chunks)), data), pin)
+		if err != nil {
+			return fmt.Errorf("error putting chunk: %s", err.Error())
+		}
+		m.Chunks = append(m.Chunks, Chunk{Path: key.String(), Length: len(data)})
+		m.Length += len(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return memfs.NewMemfileBytes(file.FileName(), append([]byte(chunksMagic), data...)), nil
+}
+
+// ReadChunkManifest reads the manifest of chunked data. ok is false for
+// data that isn't chunked
+func ReadChunkManifest(data []byte) (m *ChunkManifest, ok bool, err error) {
+	if !bytes.HasPrefix(data, []byte(chunksMagic)) {
+		return nil, false, nil
+	}
+	m = &ChunkManifest{}
+	if err := json.Unmarshal(data[len(chunksMagic):], m); err != nil {
+		return nil, true, fmt.Errorf("error reading chunk manifest: %s", err.Error())
+	}
+	return m, true, nil
+}
+
+// DataChunks gives the paths of the chunks a dataset's data is stored in,
+// nil if data isn't chunked. Chunks are stored apart from the dataset, so
+// they have to be pinned, fetched & deleted on their own
+func DataChunks(store cafs.Filestore, ds *dataset.Dataset) ([]string, error) {
+	if ds == nil || ds.DataPath == "" {
+		return nil, nil
+	}
+	f, err := store.Get(datastore.NewKey(ds.DataPath))
+	if err != nil {
+		return nil, fmt.Errorf("error getting data: %s", err.Error())
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(len(chunksMagic)); string(head) != chunksMagic {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading chunk manifest: %s", err.Error())
+	}
+	m, _, err := ReadChunkManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(m.Chunks))
+	for i, c := range m.Chunks {
+		paths[i] = c.Path
+	}
+	return paths, nil
+}
+
+// IsChunked checks if a dataset's data is stored in chunks
+func IsChunked(store cafs.Filestore, ds *dataset.Dataset) (bool, error) {
+	if ds == nil || ds.DataPath == "" {
+		return false, nil
+	}
+	f, err := store.Get(datastore.NewKey(ds.DataPath))
+	if err != nil {
+		return false, fmt.Errorf("error getting data: %s", err.Error())
+	}
+	defer f.Close()
+	head, _ := bufio.NewReaderSize(f, len(chunksMagic)).Peek(len(chunksMagic))
+	return string(head) == chunksMagic, nil
+}
+
+// OpenChunks reads chunked data as a single file, streaming chunks from the
+// store as they're read. Files that aren't chunked read as they are
+func OpenChunks(store cafs.Filestore, f cafs.File) (cafs.File, error) {
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(len(chunksMagic))
+	if string(head) != chunksMagic {
+		return readerFile{File: f, r: br}, nil
+	}
+
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading chunk manifest: %s", err.Error())
+	}
+	m, _, err := ReadChunkManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	return readerFile{File: f, r: &chunkReader{store: store, chunks: m.Chunks}}, nil
+}
+
+// chunkReader reads chunks in order, opening each as it's reached
+type chunkReader struct {
+	store  cafs.Filestore
+	chunks []Chunk
+	cur    cafs.File
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			f, err := r.store.Get(datastore.NewKey(r.chunks[0].Path))
+			if err != nil {
+				return 0, fmt.Errorf("error getting chunk %s: %s", r.chunks[0].Path, err.Error())
+			}
+			r.cur, r.chunks = f, r.chunks[1:]
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// NewChunkingStore wraps a store, writing the file named filename as
+// chunks & a manifest
+func NewChunkingStore(store cafs.Filestore, filename string, cfg ChunkConfig) cafs.Filestore {
+	write := func(file cafs.File, pin bool) (cafs.File, error) {
+		return WriteChunks(store, file, cfg, pin)
+	}
+	read := func(file cafs.File) (cafs.File, error) {
+		return OpenChunks(store, file)
+	}
+	return &dataStore{Filestore: store, filenames: []string{filename}, write: write, read: read}
+}
+
+// DedupStats compares the size of data across versions of a dataset to the
+// size actually stored, counting chunks shared between versions once
+type DedupStats struct {
+	Versions int `json:"versions"`
+	// Size is the sum of the data size of every version
+	Size int `json:"size"`
+	// StoredSize is the size of unique data across versions
+	StoredSize int `json:"storedSize"`
+	// Chunks is the number of unique chunks across versions
+	Chunks int `json:"chunks"`
+}
+
+// Ratio gives the deduplication ratio, Size / StoredSize
+func (s DedupStats) Ratio() float64 {
+	if s.StoredSize == 0 {
+		return 1
+	}
+	return float64(s.Size) / float64(s.StoredSize)
+}
+
+// Dedup calculates deduplication stats for a list of dataset versions
+func Dedup(store cafs.Filestore, versions []*dataset.Dataset) (*DedupStats, error) {
+	stats := &DedupStats{}
+	seen := map[string]bool{}
+	for _, ds := range versions {
+		if ds == nil || ds.DataPath == "" {
+			continue
+		}
+		stats.Versions++
+
+		f, err := store.Get(datastore.NewKey(ds.DataPath))
+		if err != nil {
+			return nil, fmt.Errorf("error getting data: %s", err.Error())
+		}
+		m, err := readManifest(f, ds)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		stats.Size += m.Length
+		for _, c := range m.Chunks {
+			if !seen[c.Path] {
+				seen[c.Path] = true
+				stats.StoredSize += c.Length
+				stats.Chunks++
+			}
+		}
+	}
+	return stats, nil
+}
+
+// readManifest reads the chunk manifest of a data file. unchunked data is
+// treated as a single chunk
+func readManifest(f cafs.File, ds *dataset.Dataset) (*ChunkManifest, error) {
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(len(chunksMagic))
+	if string(head) == chunksMagic {
+		data, err := ioutil.ReadAll(br)
+		if err != nil {
+			return nil, fmt.Errorf("error reading chunk manifest: %s", err.Error())
+		}
+		m, _, err := ReadChunkManifest(data)
+		return m, err
+	}
+
+	length := 0
+	if ds.Structure != nil && ds.Structure.Length > 0 {
+		length = ds.Structure.Length
+	} else {
+		n, err := io.Copy(ioutil.Discard, br)
+		if err != nil {
+			return nil, fmt.Errorf("error reading data: %s", err.Error())
+		}
+		length = int(n)
+	}
+	return &ChunkManifest{Length: length, Chunks: []Chunk{{Path: ds.DataPath, Length: length}}}, nil
+}
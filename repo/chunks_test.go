@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+)
+
+var testChunkConfig = ChunkConfig{MinSize: 64, AvgSize: 256, MaxSize: 1024}
+
+func testRows(start, end int) []byte {
+	buf := &bytes.Buffer{}
+	for i := start; i < end; i++ {
+		fmt.Fprintf(buf, "%d,row number %d,%d\n", i, i, i*i)
+	}
+	return buf.Bytes()
+}
+
+func splitAll(t *testing.T, data []byte) [][]byte {
+	chunks := [][]byte{}
+	if err := SplitChunks(bytes.NewReader(data), testChunkConfig, func(c []byte) error {
+		chunks = append(chunks, append([]byte{}, c...))
+		return nil
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+	return chunks
+}
+
+func TestSplitChunks(t *testing.T) {
+	data := testRows(0, 500)
+	chunks := splitAll(t, data)
+	if len(chunks) < 2 {
+		t.Fatalf("expected data to split into multiple chunks, got %d", len(chunks))
+	}
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Errorf("expected chunks to join to the original data")
+	}
+	for i, c := range chunks {
+		if c[len(c)-1] != '\n' {
+			t.Errorf("chunk %d doesn't end on a row boundary", i)
+		}
+		if i < len(chunks)-1 && len(c) < testChunkConfig.MinSize {
+			t.Errorf("chunk %d is smaller than the minimum size: %d", i, len(c))
+		}
+		if len(c) > testChunkConfig.MaxSize {
+			t.Errorf("chunk %d is larger than the maximum size: %d", i, len(c))
+		}
+	}
+
+	// rows longer than the maximum size are cut mid-row
+	long := bytes.Repeat([]byte("a"), testChunkConfig.MaxSize*3+10)
+	longChunks := splitAll(t, long)
+	if len(longChunks) != 4 {
+		t.Errorf("expected a long row to split into 4 chunks, got %d", len(longChunks))
+	}
+	for i, c := range longChunks {
+		if len(c) > testChunkConfig.MaxSize {
+			t.Errorf("long row chunk %d is larger than the maximum size: %d", i, len(c))
+		}
+	}
+	if !bytes.Equal(bytes.Join(longChunks, nil), long) {
+		t.Errorf("expected long row chunks to join to the original data")
+	}
+
+	// appending rows only changes the last chunk
+	appended := splitAll(t, append(append([]byte{}, data...), testRows(500, 510)...))
+	for i := 0; i < len(chunks)-1; i++ {
+		if !bytes.Equal(chunks[i], appended[i]) {
+			t.Errorf("expected chunk %d to be unchanged by appending rows", i)
+		}
+	}
+}
+
+func TestChunkedStore(t *testing.T) {
+	store := memfs.NewMapstore()
+	data := testRows(0, 500)
+
+	manifest, err := WriteChunks(store, memfs.NewMemfileBytes("data.csv", data), testChunkConfig, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mdata, err := ioutil.ReadAll(manifest)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	m, ok, err := ReadChunkManifest(mdata)
+	if err != nil || !ok {
+		t.Fatalf("expected a chunk manifest, got error: %v", err)
+	}
+	if m.Length != len(data) {
+		t.Errorf("manifest length mismatch. expected: %d, got: %d", len(data), m.Length)
+	}
+
+	mpath, err := store.Put(memfs.NewMemfileBytes("data.csv", mdata), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	paths, err := DataChunks(store, &dataset.Dataset{DataPath: mpath.String()})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(paths) != len(m.Chunks) {
+		t.Fatalf("expected %d chunk paths, got %d", len(m.Chunks), len(paths))
+	}
+	for i, c := range m.Chunks {
+		if paths[i] != c.Path {
+			t.Errorf("chunk %d path mismatch. expected: %s, got: %s", i, c.Path, paths[i])
+		}
+	}
+
+	f, err := OpenChunks(store, memfs.NewMemfileBytes("data.csv", mdata))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expected chunked data to read as the original data")
+	}
+
+	plain, err := OpenChunks(store, memfs.NewMemfileBytes("data.csv", data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, _ = ioutil.ReadAll(plain); !bytes.Equal(got, data) {
+		t.Errorf("expected unchunked data to read as is")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	store := memfs.NewMapstore()
+	versions := []*dataset.Dataset{}
+	for _, data := range [][]byte{testRows(0, 500), testRows(0, 510)} {
+		manifest, err := WriteChunks(store, memfs.NewMemfileBytes("data.csv", data), testChunkConfig, false)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		key, err := store.Put(manifest, false)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		versions = append(versions, &dataset.Dataset{DataPath: key.String()})
+	}
+
+	stats, err := Dedup(store, versions)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	size := len(testRows(0, 500)) + len(testRows(0, 510))
+	if stats.Versions != 2 || stats.Size != size {
+		t.Errorf("stats mismatch. expected 2 versions of %d bytes, got %d versions of %d bytes", size, stats.Versions, stats.Size)
+	}
+	if stats.Ratio() <= 1.5 {
+		t.Errorf("expected versions to share most chunks, got ratio: %f", stats.Ratio())
+	}
+
+	if _, err := store.Get(datastore.NewKey(versions[0].DataPath)); err != nil {
+		t.Errorf("error getting manifest: %s", err.Error())
+	}
+}
@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/klauspost/compress/zstd"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+const (
+	// CompressionNone stores data as is
+	CompressionNone = "none"
+	// CompressionGzip compresses data with gzip
+	CompressionGzip = "gzip"
+	// CompressionZstd compresses data with zstandard
+	CompressionZstd = "zstd"
+)
+
+// compressed streams start with the magic number of their codec
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ValidCompression checks a compression name is supported
+func ValidCompression(name string) error {
+	switch name {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	}
+	return fmt.Errorf("unsupported compression '%s', must be one of %s, %s or %s", name, CompressionNone, CompressionGzip, CompressionZstd)
+}
+
+// Compress compresses data as a plain gzip or zstd stream
+func Compress(name string, r io.Reader) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch name {
+	case CompressionGzip:
+		w = gzip.NewWriter(buf)
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(buf)
+		if err != nil {
+			return nil, fmt.Errorf("error allocating compressor: %s", err.Error())
+		}
+		w = zw
+	default:
+		return ioutil.ReadAll(r)
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, fmt.Errorf("error compressing data: %s", err.Error())
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing data: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// SetCompression records the compression data is stored with in a
+// structure. The dataset package has no zstd type, zstd data is recorded as
+// uncompressed & identified by the zstd frame magic number when it's read
+func SetCompression(st *dataset.Structure, name string) {
+	if st == nil {
+		return
+	}
+	if name == CompressionGzip {
+		st.Compression = compression.Gzip
+		return
+	}
+	st.Compression = compression.None
+}
+
+// structureCompression gives the compression a dataset's data is stored
+// with, read from its structure. br is the start of uncompressed data, which
+// is checked for a zstd frame
+func structureCompression(ds *dataset.Dataset, br *bufio.Reader) string {
+	if ds != nil && ds.Structure != nil && ds.Structure.Compression == compression.Gzip {
+		return CompressionGzip
+	}
+	if head, _ := br.Peek(len(zstdMagic)); bytes.Equal(head, zstdMagic) {
+		return CompressionZstd
+	}
+	return CompressionNone
+}
+
+// detectCompression identifies compressed data by the magic number of its
+// codec, for reading files without their dataset
+func detectCompression(br *bufio.Reader) string {
+	head, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return CompressionGzip
+	case bytes.Equal(head, zstdMagic):
+		return CompressionZstd
+	}
+	return CompressionNone
+}
+
+// DataCompression gives the compression a dataset's data is stored with.
+// compressed data may be encrypted, which takes the repo's keystore to read
+func DataCompression(r Repo, ds *dataset.Dataset) (string, error) {
+	if ds == nil || ds.DataPath == "" {
+		return CompressionNone, nil
+	}
+	if ds.Structure != nil && ds.Structure.Compression == compression.Gzip {
+		return CompressionGzip, nil
+	}
+	f, err := r.Store().Get(datastore.NewKey(ds.DataPath))
+	if err != nil {
+		return "", fmt.Errorf("error getting data: %s", err.Error())
+	}
+	defer f.Close()
+	if f, err = OpenChunks(r.Store(), f); err != nil {
+		return "", err
+	}
+	if f, err = DecryptFile(r, f); err != nil {
+		return "", err
+	}
+	return structureCompression(ds, bufio.NewReader(f)), nil
+}
+
+// Decompress streams the compressed data of a dataset as uncompressed data,
+// reading it with the compression recorded in the dataset's structure.
+// Uncompressed data reads as it is
+func Decompress(ds *dataset.Dataset, f cafs.File) (cafs.File, error) {
+	br := bufio.NewReader(f)
+	return decompress(structureCompression(ds, br), f, br)
+}
+
+// decompressFile streams a compressed file as uncompressed data, detecting
+// its compression from the data
+func decompressFile(f cafs.File) (cafs.File, error) {
+	br := bufio.NewReader(f)
+	return decompress(detectCompression(br), f, br)
+}
+
+func decompress(name string, f cafs.File, br *bufio.Reader) (cafs.File, error) {
+	switch name {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data: %s", err.Error())
+		}
+		return decompressedFile{File: f, r: gz}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data: %s", err.Error())
+		}
+		return decompressedFile{File: f, r: zr.IOReadCloser()}, nil
+	}
+	return readerFile{File: f, r: br}, nil
+}
+
+// decompressedFile reads a file through a decompressor, closing both
+type decompressedFile struct {
+	cafs.File
+	r io.ReadCloser
+}
+
+func (f decompressedFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+func (f decompressedFile) Close() error {
+	f.r.Close()
+	return f.File.Close()
+}
+
+// NewCompressingStore wraps a store, compressing the file named filename
+// with compression. Files are read back by detecting their compression, so
+// previous versions stored with other compression read as they were written
+func NewCompressingStore(store cafs.Filestore, filename, compression string) (cafs.Filestore, error) {
+	if err := ValidCompression(compression); err != nil {
+		return nil, err
+	}
+	write := func(file cafs.File, pin bool) (cafs.File, error) {
+		data, err := Compress(compression, file)
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
+		return memfs.NewMemfileBytes(file.FileName(), data), nil
+	}
+	return &dataStore{Filestore: store, filenames: []string{filename}, write: write, read: decompressFile}, nil
+}
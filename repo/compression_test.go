@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/analytics"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestCompress(t *testing.T) {
+	data := testRows(0, 200)
+	cases := []struct {
+		compression string
+		head        []byte
+	}{
+		{CompressionNone, data[:2]},
+		{CompressionGzip, gzipMagic},
+		{CompressionZstd, zstdMagic},
+	}
+
+	for i, c := range cases {
+		compressed, err := Compress(c.compression, bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("case %d error compressing: %s", i, err.Error())
+			continue
+		}
+		// compressed data is a plain stream other tools can read
+		if !bytes.HasPrefix(compressed, c.head) {
+			t.Errorf("case %d expected compressed data to start with %x", i, c.head)
+		}
+
+		ds := &dataset.Dataset{Structure: &dataset.Structure{}}
+		SetCompression(ds.Structure, c.compression)
+		reads := map[string]func() (cafs.File, error){
+			"structure": func() (cafs.File, error) {
+				return Decompress(ds, memfs.NewMemfileBytes("data.csv", compressed))
+			},
+			"detected": func() (cafs.File, error) {
+				return decompressFile(memfs.NewMemfileBytes("data.csv", compressed))
+			},
+		}
+		for name, read := range reads {
+			f, err := read()
+			if err != nil {
+				t.Errorf("case %d %s error decompressing: %s", i, name, err.Error())
+				continue
+			}
+			decompressed, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				t.Errorf("case %d %s error reading decompressed data: %s", i, name, err.Error())
+				continue
+			}
+			if !bytes.Equal(decompressed, data) {
+				t.Errorf("case %d %s decompressed data doesn't match original", i, name)
+			}
+		}
+	}
+}
+
+func TestValidCompression(t *testing.T) {
+	cases := []struct {
+		compression string
+		err         string
+	}{
+		{CompressionNone, ""},
+		{CompressionGzip, ""},
+		{CompressionZstd, ""},
+		{"lz4", "unsupported compression 'lz4', must be one of none, gzip or zstd"},
+	}
+
+	for i, c := range cases {
+		err := ValidCompression(c.compression)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestDataCompression(t *testing.T) {
+	store := memfs.NewMapstore()
+	r, err := NewMemRepo(&profile.Profile{Peername: "lucille"}, store, MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data := testRows(0, 50)
+
+	for i, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+		cs, err := NewCompressingStore(store, "data.csv", compression)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		key, err := cs.Put(memfs.NewMemfileBytes("data.csv", data), false)
+		if err != nil {
+			t.Errorf("case %d error putting data: %s", i, err.Error())
+			continue
+		}
+		ds := &dataset.Dataset{DataPath: key.String(), Structure: &dataset.Structure{}}
+		SetCompression(ds.Structure, compression)
+		got, err := DataCompression(r, ds)
+		if err != nil {
+			t.Errorf("case %d error reading compression: %s", i, err.Error())
+			continue
+		}
+		if got != compression {
+			t.Errorf("case %d compression mismatch. expected: %s, got: %s", i, compression, got)
+		}
+
+		f, err := cs.Get(datastore.NewKey(key.String()))
+		if err != nil {
+			t.Errorf("case %d error getting data: %s", i, err.Error())
+			continue
+		}
+		read, _ := ioutil.ReadAll(f)
+		if !bytes.Equal(read, data) {
+			t.Errorf("case %d expected compressing store to read uncompressed data", i)
+		}
+	}
+
+	if _, err := NewCompressingStore(store, "data.csv", "lz4"); err == nil {
+		t.Errorf("expected unsupported compression to error")
+	}
+}
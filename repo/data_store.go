@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+)
+
+// StoreOptions configure how CreateDataset stores a dataset's data
+type StoreOptions struct {
+	// KeyID encrypts data with a data key from the repo's keystore
+	KeyID string
+	// Chunked stores data as content-defined chunks
+	Chunked bool
+	// Compression is one of CompressionNone, CompressionGzip or
+	// CompressionZstd
+	Compression string
+}
+
+// StoreOption sets one of StoreOptions. options compose, a dataset can be
+// compressed, encrypted & chunked at once
+type StoreOption func(o *StoreOptions)
+
+// Encrypted encrypts data & the meta, structure, commit & transform
+// components with the data key keyID
+func Encrypted(keyID string) StoreOption {
+	return func(o *StoreOptions) {
+		o.KeyID = keyID
+	}
+}
+
+// Chunked stores data as content-defined chunks so versions share
+// unchanged chunks
+func Chunked() StoreOption {
+	return func(o *StoreOptions) {
+		o.Chunked = true
+	}
+}
+
+// Compressed compresses data, checksums are still computed over
+// uncompressed data
+func Compressed(compression string) StoreOption {
+	return func(o *StoreOptions) {
+		o.Compression = compression
+	}
+}
+
+// DataStore wraps store to write data the way opts ask for, Repo
+// implementations use it in CreateDataset. Data is compressed, then
+// encrypted, then chunked, so chunk manifests are stored in the clear & can
+// be pinned & fetched without the data key. Compressed & encrypted data
+// shares fewer chunks between versions. The compression of data is recorded
+// in the structure of ds
+func DataStore(store cafs.Filestore, ks Keystore, ds *dataset.Dataset, data cafs.File, opts ...StoreOption) (cafs.Filestore, error) {
+	o := &StoreOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if ds != nil {
+		SetCompression(ds.Structure, o.Compression)
+	}
+	filename := ""
+	if data != nil {
+		filename = data.FileName()
+	}
+
+	var err error
+	if o.Chunked {
+		store = NewChunkingStore(store, filename, DefaultChunkConfig)
+	}
+	if o.KeyID != "" {
+		if store, err = NewEncryptingStore(store, ks, o.KeyID, filename); err != nil {
+			return nil, err
+		}
+	}
+	if o.Compression != "" && o.Compression != CompressionNone {
+		if store, err = NewCompressingStore(store, filename, o.Compression); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// dataStore wraps a store, transforming the files named filenames with
+// write as they're put & reading files back with read. dsfs compares new
+// versions to previous ones through the store it writes to, so read has to
+// undo write
+type dataStore struct {
+	cafs.Filestore
+	filenames []string
+	write     func(file cafs.File, pin bool) (cafs.File, error)
+	read      func(file cafs.File) (cafs.File, error)
+}
+
+// Get reads files through read
+func (s *dataStore) Get(key datastore.Key) (cafs.File, error) {
+	f, err := s.Filestore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsDirectory() {
+		return f, nil
+	}
+	return s.read(f)
+}
+
+// Put writes the data file through write
+func (s *dataStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	f, err := s.transform(file, pin)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	return s.Filestore.Put(f, pin)
+}
+
+// NewAdder gives an adder that writes the data file through write
+func (s *dataStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	adder, err := s.Filestore.NewAdder(pin, wrap)
+	if err != nil {
+		return nil, err
+	}
+	return &dataAdder{Adder: adder, s: s, pin: pin}, nil
+}
+
+func (s *dataStore) transform(file cafs.File, pin bool) (cafs.File, error) {
+	if file.IsDirectory() || !s.transforms(file.FileName()) {
+		return file, nil
+	}
+	return s.write(file, pin)
+}
+
+// transforms checks if write applies to the file named name
+func (s *dataStore) transforms(name string) bool {
+	for _, f := range s.filenames {
+		if f != "" && f == name {
+			return true
+		}
+	}
+	return false
+}
+
+type dataAdder struct {
+	cafs.Adder
+	s   *dataStore
+	pin bool
+}
+
+// AddFile writes the data file through write before it's added
+func (a *dataAdder) AddFile(file cafs.File) error {
+	f, err := a.s.transform(file, a.pin)
+	if err != nil {
+		return err
+	}
+	return a.Adder.AddFile(f)
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/analytics"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestDataStore(t *testing.T) {
+	store := memfs.NewMapstore()
+	r, err := NewMemRepo(&profile.Profile{Peername: "lucille"}, store, MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pk, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.SetPrivateKey(pk); err != nil {
+		t.Fatal(err.Error())
+	}
+	keyID, err := r.NewDataKey()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	data := testRows(0, 2000)
+	cases := []struct {
+		opts        []StoreOption
+		keyID       string
+		chunked     bool
+		compression string
+	}{
+		{nil, "", false, CompressionNone},
+		{[]StoreOption{Compressed(CompressionNone)}, "", false, CompressionNone},
+		{[]StoreOption{Encrypted(keyID)}, keyID, false, CompressionNone},
+		{[]StoreOption{Chunked()}, "", true, CompressionNone},
+		{[]StoreOption{Compressed(CompressionGzip)}, "", false, CompressionGzip},
+		{[]StoreOption{Encrypted(keyID), Chunked()}, keyID, true, CompressionNone},
+		{[]StoreOption{Chunked(), Compressed(CompressionZstd)}, "", true, CompressionZstd},
+		{[]StoreOption{Compressed(CompressionZstd), Encrypted(keyID), Chunked()}, keyID, true, CompressionZstd},
+	}
+
+	for i, c := range cases {
+		file := memfs.NewMemfileBytes("data.csv", data)
+		st := &dataset.Structure{}
+		ds, err := DataStore(store, r, &dataset.Dataset{Structure: st}, file, c.opts...)
+		if err != nil {
+			t.Errorf("case %d error allocating store: %s", i, err.Error())
+			continue
+		}
+		key, err := ds.Put(file, false)
+		if err != nil {
+			t.Errorf("case %d error putting data: %s", i, err.Error())
+			continue
+		}
+		stored := &dataset.Dataset{DataPath: key.String(), Structure: st}
+		if gzipped := st.Compression == compression.Gzip; gzipped != (c.compression == CompressionGzip) {
+			t.Errorf("case %d expected structure to record gzip compression: %t", i, !gzipped)
+		}
+
+		if got, err := DataKeyID(store, stored); err != nil || got != c.keyID {
+			t.Errorf("case %d key id mismatch. expected: '%s', got: '%s', err: %v", i, c.keyID, got, err)
+		}
+		if got, err := IsChunked(store, stored); err != nil || got != c.chunked {
+			t.Errorf("case %d chunked mismatch. expected: %t, got: %t, err: %v", i, c.chunked, got, err)
+		}
+		if got, err := DataCompression(r, stored); err != nil || got != c.compression {
+			t.Errorf("case %d compression mismatch. expected: %s, got: %s, err: %v", i, c.compression, got, err)
+		}
+
+		f, err := ds.Get(datastore.NewKey(key.String()))
+		if err != nil {
+			t.Errorf("case %d error getting data: %s", i, err.Error())
+			continue
+		}
+		if read, _ := ioutil.ReadAll(f); !bytes.Equal(read, data) {
+			t.Errorf("case %d expected store to read data back as it was written", i)
+		}
+		f, err = LoadData(r, stored)
+		if err != nil {
+			t.Errorf("case %d error loading data: %s", i, err.Error())
+			continue
+		}
+		if read, _ := ioutil.ReadAll(f); !bytes.Equal(read, data) {
+			t.Errorf("case %d expected LoadData to read data back as it was written", i)
+		}
+	}
+
+	// components are encrypted, but not compressed or chunked. files other
+	// than data & components are written as they are
+	ds, err := DataStore(store, r, nil, memfs.NewMemfileBytes("data.csv", data), Encrypted(keyID), Chunked(), Compressed(CompressionGzip))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	meta := []byte(`{"title":"rows"}`)
+	key, err := ds.Put(memfs.NewMemfileBytes("meta.json", meta), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f, err := store.Get(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if read, _ := ioutil.ReadAll(f); bytes.Contains(read, []byte("rows")) {
+		t.Errorf("expected components to be encrypted")
+	}
+	if f, err = NewDecryptingStore(store, r).Get(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	if read, _ := ioutil.ReadAll(f); !bytes.Equal(read, meta) {
+		t.Errorf("expected decrypting store to read components back as they were written")
+	}
+
+	doc := []byte(`{"meta":"/map/meta"}`)
+	if key, err = ds.Put(memfs.NewMemfileBytes("dataset.json", doc), false); err != nil {
+		t.Fatal(err.Error())
+	}
+	if f, err = store.Get(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	if read, _ := ioutil.ReadAll(f); !bytes.Equal(read, doc) {
+		t.Errorf("expected other files to be stored as they are")
+	}
+}
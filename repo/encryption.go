@@ -0,0 +1,385 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// envelopeMagic prefixes encrypted content. An envelope is the magic
+// string, the id of the data key, a newline, a nonce & the sealed content
+const envelopeMagic = "qri-enc/1:"
+
+// dataKeySize is the length of data keys in bytes, selecting AES-256
+const dataKeySize = 32
+
+// Keystore keeps the symmetric keys dataset content is encrypted with.
+// Keys are stored wrapped with the repo's key pair, so they're only usable
+// with the repo's private key.
+// A dataset's data, stats & meta, structure, commit & transform components
+// are encrypted. The dataset document only references components & data,
+// it's stored in the clear so encrypted datasets can be pinned & removed
+// without their key
+type Keystore interface {
+	// NewDataKey generates & stores a new data key, returning its id
+	NewDataKey() (id string, err error)
+	// DataKey unwraps a stored data key
+	DataKey(id string) ([]byte, error)
+	// ShareDataKey wraps a data key to a peer's public key, the returned
+	// string can be given to ImportDataKey in the peer's repo
+	ShareDataKey(id string, pub crypto.PubKey) (string, error)
+	// ImportDataKey stores a data key shared by another peer
+	ImportDataKey(shared string) (id string, err error)
+}
+
+// WrappedKeyStore persists wrapped data keys for a Keystore
+type WrappedKeyStore interface {
+	PutWrappedKey(id string, wrapped []byte) error
+	WrappedKey(id string) ([]byte, error)
+}
+
+// NewDataKey generates a data key, storing it wrapped with the public half
+// of pk. It's a helper for Keystore implementations
+func NewDataKey(pk crypto.PrivKey, ks WrappedKeyStore) (string, error) {
+	if pk == nil {
+		return "", fmt.Errorf("private key is required to encrypt data")
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("error generating data key: %s", err.Error())
+	}
+	idBytes := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, idBytes); err != nil {
+		return "", fmt.Errorf("error generating data key id: %s", err.Error())
+	}
+	id := hex.EncodeToString(idBytes)
+
+	wrapped, err := wrapKey(pk.GetPublic(), key)
+	if err != nil {
+		return "", err
+	}
+	if err := ks.PutWrappedKey(id, wrapped); err != nil {
+		return "", fmt.Errorf("error storing data key: %s", err.Error())
+	}
+	return id, nil
+}
+
+// DataKey unwraps a stored data key with pk. It's a helper for Keystore
+// implementations
+func DataKey(pk crypto.PrivKey, ks WrappedKeyStore, id string) ([]byte, error) {
+	if pk == nil {
+		return nil, fmt.Errorf("private key is required to decrypt data")
+	}
+	wrapped, err := ks.WrappedKey(id)
+	if err == ErrNotFound {
+		return nil, fmt.Errorf("no key for encrypted data %s, the dataset owner needs to share it", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting data key: %s", err.Error())
+	}
+	return unwrapKey(pk, wrapped)
+}
+
+// ShareDataKey re-wraps a stored data key to a peer's public key. It's a
+// helper for Keystore implementations
+func ShareDataKey(pk crypto.PrivKey, ks WrappedKeyStore, id string, pub crypto.PubKey) (string, error) {
+	key, err := DataKey(pk, ks, id)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := wrapKey(pub, key)
+	if err != nil {
+		return "", err
+	}
+	return id + "." + base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// ImportDataKey stores a key another peer wrapped to pk's public key. The
+// key is checked by unwrapping before it's stored. It's a helper for
+// Keystore implementations
+func ImportDataKey(pk crypto.PrivKey, ks WrappedKeyStore, shared string) (string, error) {
+	parts := strings.Split(shared, ".")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", fmt.Errorf("malformed shared key")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("error decoding shared key: %s", err.Error())
+	}
+	if pk == nil {
+		return "", fmt.Errorf("private key is required to import a data key")
+	}
+	if _, err := unwrapKey(pk, wrapped); err != nil {
+		return "", fmt.Errorf("shared key wasn't wrapped for this peer: %s", err.Error())
+	}
+	if err := ks.PutWrappedKey(parts[0], wrapped); err != nil {
+		return "", fmt.Errorf("error storing data key: %s", err.Error())
+	}
+	return parts[0], nil
+}
+
+// wrapKey encrypts a data key to a public key. only key types that support
+// encryption (RSA) can wrap keys
+func wrapKey(pub crypto.PubKey, key []byte) ([]byte, error) {
+	enc, ok := pub.(interface {
+		Encrypt([]byte) ([]byte, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("public key type doesn't support encryption")
+	}
+	wrapped, err := enc.Encrypt(key)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key: %s", err.Error())
+	}
+	return wrapped, nil
+}
+
+// unwrapKey decrypts a data key wrapped with wrapKey
+func unwrapKey(pk crypto.PrivKey, wrapped []byte) ([]byte, error) {
+	dec, ok := pk.(interface {
+		Decrypt([]byte) ([]byte, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("private key type doesn't support decryption")
+	}
+	key, err := dec.Decrypt(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key: %s", err.Error())
+	}
+	return key, nil
+}
+
+// Encrypt seals content with a data key, prefixing the key id so readers
+// know which key to decrypt with
+func Encrypt(key []byte, id string, content []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %s", err.Error())
+	}
+
+	buf := bytes.NewBufferString(envelopeMagic + id + "\n")
+	buf.Write(nonce)
+	buf.Write(gcm.Seal(nil, nonce, content, []byte(id)))
+	return buf.Bytes(), nil
+}
+
+// Decrypt opens content sealed with Encrypt. keys gets a data key by id
+func Decrypt(keys func(id string) ([]byte, error), data []byte) ([]byte, error) {
+	id, sealed, ok := splitEnvelope(data)
+	if !ok {
+		return nil, fmt.Errorf("data isn't encrypted")
+	}
+	key, err := keys(id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	content, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %s", err.Error())
+	}
+	return content, nil
+}
+
+// EnvelopeKeyID gives the id of the key content is encrypted with. ok is
+// false for unencrypted content
+func EnvelopeKeyID(data []byte) (id string, ok bool) {
+	id, _, ok = splitEnvelope(data)
+	return
+}
+
+func splitEnvelope(data []byte) (id string, sealed []byte, ok bool) {
+	if !bytes.HasPrefix(data, []byte(envelopeMagic)) {
+		return "", nil, false
+	}
+	rest := data[len(envelopeMagic):]
+	nl := bytes.IndexByte(rest, '\n')
+	if nl < 0 {
+		return "", nil, false
+	}
+	return string(rest[:nl]), rest[nl+1:], true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating cipher: %s", err.Error())
+	}
+	return cipher.NewGCM(block)
+}
+
+// DataKeyID gives the id of the key a dataset's data is encrypted with, or
+// an empty string for unencrypted data
+func DataKeyID(store cafs.Filestore, ds *dataset.Dataset) (string, error) {
+	if ds == nil || ds.DataPath == "" {
+		return "", nil
+	}
+	f, err := store.Get(datastore.NewKey(ds.DataPath))
+	if err != nil {
+		return "", fmt.Errorf("error getting data: %s", err.Error())
+	}
+	defer f.Close()
+	// encrypted data may be chunked
+	if f, err = OpenChunks(store, f); err != nil {
+		return "", err
+	}
+	// key ids are short, the header fits well within a small read
+	head, _ := bufio.NewReaderSize(f, 128).Peek(128)
+	if id, ok := EnvelopeKeyID(head); ok {
+		return id, nil
+	}
+	return "", nil
+}
+
+// LoadData loads the data of a dataset, streaming chunked data across
+// chunks, decrypting encrypted data with keys from the repo's keystore &
+// decompressing compressed data, undoing DataStore in reverse. Use it in
+// place of dsfs.LoadData
+func LoadData(r Repo, ds *dataset.Dataset) (cafs.File, error) {
+	f, err := dsfs.LoadData(r.Store(), ds)
+	if err != nil {
+		return nil, err
+	}
+	if f, err = OpenChunks(r.Store(), f); err != nil {
+		return nil, err
+	}
+	if f, err = DecryptFile(r, f); err != nil {
+		return nil, err
+	}
+	return Decompress(ds, f)
+}
+
+// DecryptFile decrypts a file read from a store if it's encrypted.
+// Unencrypted files read as they are
+func DecryptFile(ks Keystore, f cafs.File) (cafs.File, error) {
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(len(envelopeMagic))
+	if string(head) != envelopeMagic {
+		return readerFile{File: f, r: br}, nil
+	}
+
+	defer f.Close()
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading encrypted data: %s", err.Error())
+	}
+	content, err := Decrypt(ks.DataKey, data)
+	if err != nil {
+		return nil, err
+	}
+	return memfs.NewMemfileBytes(f.FileName(), content), nil
+}
+
+// readerFile reads a file through a different reader, keeping the file's
+// name & Close
+type readerFile struct {
+	cafs.File
+	r io.Reader
+}
+
+func (f readerFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// encryptedComponents are the files of a dataset package encrypted along
+// with its data
+var encryptedComponents = []string{
+	dsfs.PackageFileMeta.String(),
+	dsfs.PackageFileStructure.String(),
+	dsfs.PackageFileCommit.String(),
+	dsfs.PackageFileTransform.String(),
+}
+
+// NewEncryptingStore wraps a store, encrypting the file named filename &
+// dataset components with the data key id. filename can be empty to only
+// encrypt components, eg. when data is written back as it's stored
+func NewEncryptingStore(store cafs.Filestore, ks Keystore, id string, filename string) (cafs.Filestore, error) {
+	key, err := ks.DataKey(id)
+	if err != nil {
+		return nil, err
+	}
+	write := func(file cafs.File, pin bool) (cafs.File, error) {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading data: %s", err.Error())
+		}
+		file.Close()
+		sealed, err := Encrypt(key, id, content)
+		if err != nil {
+			return nil, err
+		}
+		return memfs.NewMemfileBytes(file.FileName(), sealed), nil
+	}
+	read := func(file cafs.File) (cafs.File, error) {
+		return DecryptFile(ks, file)
+	}
+	filenames := append([]string{filename}, encryptedComponents...)
+	return &dataStore{Filestore: store, filenames: filenames, write: write, read: read}, nil
+}
+
+// NewDecryptingStore wraps a store, decrypting encrypted files as they're
+// read with keys from ks. Files are written as they are
+func NewDecryptingStore(store cafs.Filestore, ks Keystore) cafs.Filestore {
+	read := func(file cafs.File) (cafs.File, error) {
+		return DecryptFile(ks, file)
+	}
+	return &dataStore{Filestore: store, read: read}
+}
+
+// LoadDataset loads a dataset, decrypting the components of encrypted
+// datasets with keys from the repo's keystore. Use it in place of
+// dsfs.LoadDataset
+func LoadDataset(r Repo, path datastore.Key) (*dataset.Dataset, error) {
+	return dsfs.LoadDataset(NewDecryptingStore(r.Store(), r), path)
+}
+
+// LoadDatasetDocument loads the dataset document at path without reading
+// its components, which are only readable with the data key of encrypted
+// datasets. Components are references to their paths
+func LoadDatasetDocument(store cafs.Filestore, path datastore.Key) (*dataset.Dataset, error) {
+	f, err := store.Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	defer f.Close()
+	ds := &dataset.Dataset{}
+	if err := json.NewDecoder(f).Decode(ds); err != nil {
+		return nil, fmt.Errorf("error decoding dataset: %s", err.Error())
+	}
+	return ds, nil
+}
+
+// IsEncrypted checks if the dataset at path is encrypted
+func IsEncrypted(store cafs.Filestore, path datastore.Key) (bool, error) {
+	ds, err := LoadDatasetDocument(store, path)
+	if err != nil {
+		return false, err
+	}
+	id, err := DataKeyID(store, ds)
+	return id != "", err
+}
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	keys := func(id string) ([]byte, error) {
+		if id != "a" {
+			return nil, fmt.Errorf("no key %s", id)
+		}
+		return key, nil
+	}
+
+	content := []byte("a,b,c\n1,2,3\n")
+	sealed, err := Encrypt(key, "a", content)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if bytes.Contains(sealed, content) {
+		t.Errorf("expected content to be encrypted")
+	}
+	if id, ok := EnvelopeKeyID(sealed); !ok || id != "a" {
+		t.Errorf("key id mismatch. expected: a, got: %s", id)
+	}
+	if _, ok := EnvelopeKeyID(content); ok {
+		t.Errorf("expected plain content to have no key id")
+	}
+
+	got, err := Decrypt(keys, sealed)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("content mismatch. expected: %s, got: %s", content, got)
+	}
+
+	other, err := Encrypt(key, "b", content)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cases := []struct {
+		data []byte
+		err  string
+	}{
+		{content, "data isn't encrypted"},
+		{other, "no key b"},
+		{append(sealed[:len(sealed)-1:len(sealed)-1], 0), "error decrypting data: cipher: message authentication failed"},
+	}
+	for i, c := range cases {
+		_, err := Decrypt(keys, c.data)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestShareDataKey(t *testing.T) {
+	owner, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	friend, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ownerKeys, friendKeys := NewMemKeys(), NewMemKeys()
+
+	id, err := NewDataKey(owner, ownerKeys)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	key, err := DataKey(owner, ownerKeys, id)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expect := fmt.Sprintf("no key for encrypted data %s, the dataset owner needs to share it", id)
+	if _, err := DataKey(friend, friendKeys, id); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%s'", expect, err)
+	}
+
+	shared, err := ShareDataKey(owner, ownerKeys, id, friend.GetPublic())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := ImportDataKey(owner, ownerKeys, shared); err == nil {
+		t.Errorf("expected importing a key shared with another peer to error")
+	}
+	if _, err := ImportDataKey(friend, friendKeys, "nope"); err == nil || err.Error() != "malformed shared key" {
+		t.Errorf("expected malformed shared key error, got: %s", err)
+	}
+
+	got, err := ImportDataKey(friend, friendKeys, shared)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != id {
+		t.Errorf("imported key id mismatch. expected: %s, got: %s", id, got)
+	}
+	friendKey, err := DataKey(friend, friendKeys, id)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(key, friendKey) {
+		t.Errorf("expected shared key to match the owner's key")
+	}
+}
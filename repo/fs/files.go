@@ -58,6 +58,14 @@ const (
 	FileSearchIndex
 	// FileChangeRequests is a file of change requests
 	FileChangeRequests
+	// FileStats indexes dataset stats components by dataset path
+	FileStats
+	// FileVisibility records private & shared dataset references
+	FileVisibility
+	// FileKeys holds wrapped keys for encrypted dataset content
+	FileKeys
+	// FileListings records the peers datasets have been listed to
+	FileListings
 )
 
 var paths = map[File]string{
@@ -74,6 +82,10 @@ var paths = map[File]string{
 	FileAnalytics:      "/analytics.json",
 	FileSearchIndex:    "/index.bleve",
 	FileChangeRequests: "/change_requests.json",
+	FileStats:          "/stats.json",
+	FileVisibility:     "/visibility.json",
+	FileKeys:           "/keys.json",
+	FileListings:       "/listings.json",
 }
 
 // Filepath gives the relative filepath to a repofile
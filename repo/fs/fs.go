@@ -30,7 +30,11 @@ type Repo struct {
 	Refstore
 	QueryLog
 	ChangeRequests
+	Stats
+	Visibility
+	Listings
 
+	keys      Keys
 	analytics Analytics
 	peers     PeerStore
 	cache     Datasets
@@ -51,16 +55,23 @@ func NewRepo(store cafs.Filestore, base, id string) (repo.Repo, error) {
 		store:    store,
 		basepath: bp,
 
-		Datasets:       NewDatasets(base, FileDatasets, store),
-		Refstore:       Refstore{basepath: bp, store: store},
+		Refstore:       Refstore{basepath: bp, store: store, visibility: NewVisibility(base, FileVisibility)},
 		QueryLog:       NewQueryLog(base, FileQueryLogs, store),
 		ChangeRequests: NewChangeRequests(base, FileChangeRequests),
+		Stats:          NewStats(base, FileStats),
+		Visibility:     NewVisibility(base, FileVisibility),
+		Listings:       NewListings(base, FileListings),
 
+		keys:      NewKeys(base, FileKeys),
 		analytics: NewAnalytics(base),
 		peers:     PeerStore{bp},
 		cache:     NewDatasets(base, FileCache, nil),
 	}
 
+	// datasets missing from the datasets file are loaded from the store,
+	// decrypting encrypted components with this repo's keys
+	r.Datasets = NewDatasets(base, FileDatasets, repo.NewDecryptingStore(store, r))
+
 	if index, err := search.LoadIndex(bp.filepath(FileSearchIndex)); err == nil {
 		r.index = index
 		r.Refstore.index = index
@@ -195,6 +206,49 @@ func (r *Repo) Search(p repo.SearchParams) ([]repo.DatasetRef, error) {
 	return refs, nil
 }
 
+// PutRefVisibility records the visibility of a reference, adding public
+// datasets back to the search index. Datasets made private are removed from
+// the index with Unindex, encrypted datasets are never indexed
+func (r *Repo) PutRefVisibility(ref repo.DatasetRef, v repo.Visibility) error {
+	if err := r.Visibility.PutRefVisibility(ref, v); err != nil {
+		return err
+	}
+	if r.index == nil || v.Private {
+		return nil
+	}
+
+	got, err := r.GetRef(ref)
+	if err != nil {
+		// visibility can be set before a reference is added
+		return nil
+	}
+	path := datastore.NewKey(got.Path)
+	if encrypted, err := repo.IsEncrypted(r.store, path); err != nil || encrypted {
+		return err
+	}
+	ds, err := dsfs.LoadDataset(r.store, path)
+	if err != nil {
+		return err
+	}
+	return r.index.Index(got.Path, ds)
+}
+
+// Unindex removes a reference from this repo's search index
+func (r *Repo) Unindex(ref repo.DatasetRef) error {
+	if r.index == nil {
+		return nil
+	}
+	if ref.Path == "" {
+		got, err := r.GetRef(ref)
+		if err != nil {
+			// references that aren't added aren't indexed
+			return nil
+		}
+		ref = got
+	}
+	return r.index.Delete(ref.Path)
+}
+
 // UpdateSearchIndex refreshes this repos search index
 func (r *Repo) UpdateSearchIndex(store cafs.Filestore) error {
 	return search.IndexRepo(r, r.index)
@@ -221,8 +275,38 @@ func (r *Repo) SavePeers(p map[string]*profile.Profile) error {
 }
 
 // CreateDataset initializes a dataset from a dataset pointer and data file
-func (r *Repo) CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool) (path datastore.Key, err error) {
-	return dsfs.CreateDataset(r.store, ds, data, r.pk, pin)
+func (r *Repo) CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool, opts ...repo.StoreOption) (path datastore.Key, err error) {
+	store, err := repo.DataStore(r.store, r, ds, data, opts...)
+	if err != nil {
+		return
+	}
+	return repo.CreateSignedDataset(r.pk, r.store, store, ds, data, pin)
+}
+
+// RewriteDataset writes a changed version of a dataset already in the
+// store, re-signing its commit
+func (r *Repo) RewriteDataset(ds *dataset.Dataset, pin bool) (datastore.Key, error) {
+	return repo.RewriteSignedDataset(r.pk, r, ds, pin)
+}
+
+// NewDataKey generates a data key for encrypting dataset content
+func (r *Repo) NewDataKey() (string, error) {
+	return repo.NewDataKey(r.pk, r.keys)
+}
+
+// DataKey unwraps a data key
+func (r *Repo) DataKey(id string) ([]byte, error) {
+	return repo.DataKey(r.pk, r.keys, id)
+}
+
+// ShareDataKey wraps a data key to a peer's public key
+func (r *Repo) ShareDataKey(id string, pub crypto.PubKey) (string, error) {
+	return repo.ShareDataKey(r.pk, r.keys, id, pub)
+}
+
+// ImportDataKey stores a data key shared by another peer
+func (r *Repo) ImportDataKey(shared string) (string, error) {
+	return repo.ImportDataKey(r.pk, r.keys, shared)
 }
 
 // Destroy destroys this repository
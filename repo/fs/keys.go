@@ -0,0 +1,62 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Keys is a file-based implementation of the repo.WrappedKeyStore
+// interface. Keys are only stored wrapped, the file is useless without the
+// repo's private key
+type Keys struct {
+	basepath
+	file File
+}
+
+// NewKeys allocates a new file-based Keys instance
+func NewKeys(base string, file File) Keys {
+	return Keys{basepath: basepath(base), file: file}
+}
+
+// PutWrappedKey stores a wrapped data key
+func (k Keys) PutWrappedKey(id string, wrapped []byte) error {
+	keys, err := k.keys()
+	if err != nil {
+		return err
+	}
+	keys[id] = wrapped
+	return k.saveFile(keys, k.file)
+}
+
+// WrappedKey gets a wrapped data key
+func (k Keys) WrappedKey(id string) ([]byte, error) {
+	keys, err := k.keys()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, ok := keys[id]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return wrapped, nil
+}
+
+func (k Keys) keys() (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	data, err := ioutil.ReadFile(k.filepath(k.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return keys, fmt.Errorf("error loading keys: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return keys, fmt.Errorf("error unmarshaling keys: %s", err.Error())
+	}
+	return keys, nil
+}
@@ -0,0 +1,81 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Listings is a file-based implementation of the repo.ListingStore interface
+type Listings struct {
+	basepath
+	file File
+}
+
+// NewListings allocates a new file-based Listings instance
+func NewListings(base string, file File) Listings {
+	return Listings{basepath: basepath(base), file: file}
+}
+
+// PutListings records that refs were listed to a peer
+func (s Listings) PutListings(peerID string, refs []repo.DatasetRef) error {
+	listings, err := s.listings()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, ref := range refs {
+		key := repo.VisibilityKey(ref)
+		if !contains(listings[key], peerID) {
+			listings[key] = append(listings[key], peerID)
+			sort.Strings(listings[key])
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.saveFile(listings, s.file)
+}
+
+// RefListings gives the ids of the peers a reference has been listed to
+func (s Listings) RefListings(ref repo.DatasetRef) ([]string, error) {
+	listings, err := s.listings()
+	if err != nil {
+		return nil, err
+	}
+	ids := listings[repo.VisibilityKey(ref)]
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids, nil
+}
+
+func (s Listings) listings() (map[string][]string, error) {
+	listings := map[string][]string{}
+	data, err := ioutil.ReadFile(s.filepath(s.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return listings, nil
+		}
+		return listings, fmt.Errorf("error loading listings: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &listings); err != nil {
+		return listings, fmt.Errorf("error unmarshaling listings: %s", err.Error())
+	}
+	return listings, nil
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,9 @@ type Refstore struct {
 	index search.Index
 	// filestore for checking dataset integrity
 	store cafs.Filestore
+	// private references aren't added to the search index, neither are
+	// encrypted datasets
+	visibility Visibility
 }
 
 // PutRef adds a reference to the store
@@ -51,14 +54,25 @@ func (n Refstore) PutRef(put repo.DatasetRef) (err error) {
 	}
 
 	names = append(names, put)
+	encrypted := false
 	if n.store != nil {
-		ds, err = dsfs.LoadDataset(n.store, datastore.NewKey(put.Path))
-		if err != nil {
+		// components of encrypted datasets can't be read without the data key
+		if encrypted, err = repo.IsEncrypted(n.store, datastore.NewKey(put.Path)); err != nil {
 			return err
 		}
+		if !encrypted {
+			if ds, err = dsfs.LoadDataset(n.store, datastore.NewKey(put.Path)); err != nil {
+				return err
+			}
+		}
+	}
+
+	v, err := n.visibility.RefVisibility(put)
+	if err != nil {
+		return err
 	}
 
-	if n.index != nil {
+	if n.index != nil && !v.Private && !encrypted {
 		batch := n.index.NewBatch()
 		err = batch.Index(put.Path, ds)
 		if err != nil {
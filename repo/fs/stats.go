@@ -0,0 +1,61 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// Stats is a file-based implementation of the repo.StatsStore interface
+type Stats struct {
+	basepath
+	file File
+}
+
+// NewStats allocates a new file-based Stats instance
+func NewStats(base string, file File) Stats {
+	return Stats{basepath: basepath(base), file: file}
+}
+
+// PutStatsPath records the stats path for a dataset
+func (s Stats) PutStatsPath(dsPath, statsPath datastore.Key) error {
+	paths, err := s.paths()
+	if err != nil {
+		return err
+	}
+	paths[dsPath.String()] = statsPath.String()
+	return s.saveFile(paths, s.file)
+}
+
+// StatsPath gets the stats path for a dataset
+func (s Stats) StatsPath(dsPath datastore.Key) (datastore.Key, error) {
+	paths, err := s.paths()
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	p, ok := paths[dsPath.String()]
+	if !ok {
+		return datastore.NewKey(""), repo.ErrNotFound
+	}
+	return datastore.NewKey(p), nil
+}
+
+func (s Stats) paths() (map[string]string, error) {
+	paths := map[string]string{}
+	data, err := ioutil.ReadFile(s.filepath(s.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return paths, nil
+		}
+		return paths, fmt.Errorf("error loading stats: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return paths, fmt.Errorf("error unmarshaling stats: %s", err.Error())
+	}
+	return paths, nil
+}
@@ -0,0 +1,66 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Visibility is a file-based implementation of the repo.VisibilityStore
+// interface. Only non-public references are recorded
+type Visibility struct {
+	basepath
+	file File
+}
+
+// NewVisibility allocates a new file-based Visibility instance
+func NewVisibility(base string, file File) Visibility {
+	return Visibility{basepath: basepath(base), file: file}
+}
+
+// PutRefVisibility records the visibility of a reference
+func (s Visibility) PutRefVisibility(ref repo.DatasetRef, v repo.Visibility) error {
+	if ref.Peername == "" {
+		return repo.ErrPeernameRequired
+	} else if ref.Name == "" {
+		return repo.ErrNameRequired
+	}
+	refs, err := s.refs()
+	if err != nil {
+		return err
+	}
+	if v.Private {
+		refs[repo.VisibilityKey(ref)] = v
+	} else {
+		delete(refs, repo.VisibilityKey(ref))
+	}
+	return s.saveFile(refs, s.file)
+}
+
+// RefVisibility gets the visibility of a reference
+func (s Visibility) RefVisibility(ref repo.DatasetRef) (repo.Visibility, error) {
+	refs, err := s.refs()
+	if err != nil {
+		return repo.Visibility{}, err
+	}
+	return refs[repo.VisibilityKey(ref)], nil
+}
+
+func (s Visibility) refs() (map[string]repo.Visibility, error) {
+	refs := map[string]repo.Visibility{}
+	data, err := ioutil.ReadFile(s.filepath(s.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return refs, fmt.Errorf("error loading visibility: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return refs, fmt.Errorf("error unmarshaling visibility: %s", err.Error())
+	}
+	return refs, nil
+}
@@ -154,7 +154,7 @@ func (nl NodeList) nodesFromDatasetRef(r Repo, ref *DatasetRef) *dsgraph.Node {
 	}
 
 	if ds.Transform != nil && ds.Transform.Path().String() != "" {
-		if q, err := dsfs.LoadTransform(r.Store(), ds.Transform.Path()); err == nil {
+		if q, err := dsfs.LoadTransform(NewDecryptingStore(r.Store(), r), ds.Transform.Path()); err == nil {
 			trans := nl.node(dsgraph.NtTransform, ds.Transform.Path().String())
 			for _, ref := range q.Resources {
 				trans.AddLinks(dsgraph.Link{
@@ -180,7 +180,7 @@ func (nl NodeList) nodesFromDatasetRef(r Repo, ref *DatasetRef) *dsgraph.Node {
 // Yes, this potentially a very expensive function to call, use sparingly.
 func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error) (bool, error)) error {
 	pll := walkParallelism
-	store := r.Store()
+	store := NewDecryptingStore(r.Store(), r)
 	count, err := r.RefCount()
 	if err != nil {
 		return err
@@ -0,0 +1,245 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset/dsgraph"
+)
+
+const (
+	// LineageFormatJSON encodes lineage as a json graph of nodes & edges
+	LineageFormatJSON = "json"
+	// LineageFormatDOT encodes lineage as a graphviz DOT digraph
+	LineageFormatDOT = "dot"
+	// LineageFormatMermaid encodes lineage as a mermaid flowchart
+	LineageFormatMermaid = "mermaid"
+)
+
+// LineageGraph is the subset of a repo graph that connects a dataset to the
+// datasets it was derived from & the datasets derived from it
+type LineageGraph struct {
+	// Root is the path of the dataset lineage was calculated for
+	Root  string         `json:"root"`
+	Nodes []*LineageNode `json:"nodes"`
+	Edges []*LineageEdge `json:"edges"`
+}
+
+// LineageNode is a dataset in a lineage graph
+type LineageNode struct {
+	Path string `json:"path"`
+	// Name is the peername/name reference for a path, if known
+	Name string `json:"name,omitempty"`
+	// Depth is the number of transforms between this node & the root.
+	// upstream inputs are negative, downstream dependents positive
+	Depth int `json:"depth"`
+}
+
+// Label gives a human-readable identifier for a node
+func (n *LineageNode) Label() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Path
+}
+
+// LineageEdge connects an input dataset to a dataset derived from it
+type LineageEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Transform is the path to the transform that connects From & To
+	Transform string `json:"transform,omitempty"`
+}
+
+// Lineage calculates the lineage of the dataset at path, following
+// transforms upstream to their inputs & downstream to their outputs.
+// depth limits the number of transforms followed in each direction,
+// depth <= 0 follows all of them
+func Lineage(r Repo, path string, depth int) (*LineageGraph, error) {
+	nodes, err := r.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("error getting repo graph: %s", err.Error())
+	}
+	if n := nodes[path]; n == nil || n.Type != dsgraph.NtDataset {
+		return nil, ErrNotFound
+	}
+
+	names := map[string]string{}
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting reference count: %s", err.Error())
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error getting references: %s", err.Error())
+	}
+	for _, ref := range refs {
+		names[ref.Path] = ref.Peername + "/" + ref.Name
+	}
+
+	inputs := map[string][]*LineageEdge{}
+	dependents := map[string][]*LineageEdge{}
+	for dsPath, node := range nodes {
+		if node.Type != dsgraph.NtDataset {
+			continue
+		}
+		for _, l := range node.Links {
+			if l.To.Type != dsgraph.NtTransform {
+				continue
+			}
+			for _, il := range l.To.Links {
+				if il.To.Type != dsgraph.NtDataset {
+					continue
+				}
+				e := &LineageEdge{From: il.To.Path, To: dsPath, Transform: l.To.Path}
+				inputs[dsPath] = append(inputs[dsPath], e)
+				dependents[il.To.Path] = append(dependents[il.To.Path], e)
+			}
+		}
+	}
+
+	g := &LineageGraph{Root: path}
+	depths := map[string]int{path: 0}
+	edges := map[LineageEdge]bool{}
+
+	// walk breadth-first so each node is recorded at its shortest distance
+	walk := func(edgesOf map[string][]*LineageEdge, dir int) {
+		frontier := []string{path}
+		for d := 1; len(frontier) > 0 && (depth <= 0 || d <= depth); d++ {
+			next := []string{}
+			for _, p := range frontier {
+				for _, e := range edgesOf[p] {
+					edges[*e] = true
+					to := e.From
+					if dir > 0 {
+						to = e.To
+					}
+					if _, seen := depths[to]; !seen {
+						depths[to] = d * dir
+						next = append(next, to)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+	walk(inputs, -1)
+	walk(dependents, 1)
+
+	for p, d := range depths {
+		g.Nodes = append(g.Nodes, &LineageNode{Path: p, Name: names[p], Depth: d})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].Depth == g.Nodes[j].Depth {
+			return g.Nodes[i].Path < g.Nodes[j].Path
+		}
+		return g.Nodes[i].Depth < g.Nodes[j].Depth
+	})
+
+	for e := range edges {
+		e := e
+		g.Edges = append(g.Edges, &e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From == g.Edges[j].From {
+			return g.Edges[i].To < g.Edges[j].To
+		}
+		return g.Edges[i].From < g.Edges[j].From
+	})
+
+	return g, nil
+}
+
+// Filter removes nodes a peer can't see from a lineage graph, along with the
+// edges that connect them. Paths that aren't a version of a named dataset in
+// the repo can't be seen by other peers
+func (g *LineageGraph) Filter(r Repo, peerID string) error {
+	visible := map[string]bool{}
+	nodes := make([]*LineageNode, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		ref, err := VersionRef(r, n.Path)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		ok, err := CanView(r, ref, peerID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			visible[n.Path] = true
+			nodes = append(nodes, n)
+		}
+	}
+
+	edges := make([]*LineageEdge, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		if visible[e.From] && visible[e.To] {
+			edges = append(edges, e)
+		}
+	}
+	g.Nodes, g.Edges = nodes, edges
+	return nil
+}
+
+// Node gets a node by path, nil if not found
+func (g *LineageGraph) Node(path string) *LineageNode {
+	for _, n := range g.Nodes {
+		if n.Path == path {
+			return n
+		}
+	}
+	return nil
+}
+
+// Encode writes a lineage graph in one of the LineageFormat formats
+func (g *LineageGraph) Encode(format string) ([]byte, error) {
+	switch format {
+	case LineageFormatJSON:
+		return json.MarshalIndent(g, "", "  ")
+	case LineageFormatDOT:
+		return g.dot(), nil
+	case LineageFormatMermaid:
+		return g.mermaid(), nil
+	}
+	return nil, fmt.Errorf("unsupported lineage format: '%s'", format)
+}
+
+func (g *LineageGraph) dot() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("digraph lineage {\n  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		style := ""
+		if n.Path == g.Root {
+			style = ", style=bold"
+		}
+		fmt.Fprintf(buf, "  %q [label=%q%s];\n", n.Path, n.Label(), style)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(buf, "  %q -> %q;\n", e.From, e.To)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (g *LineageGraph) mermaid() []byte {
+	// mermaid ids can't contain slashes, so nodes are numbered
+	ids := map[string]string{}
+	buf := &bytes.Buffer{}
+	buf.WriteString("graph LR\n")
+	for i, n := range g.Nodes {
+		ids[n.Path] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(buf, "  %s[\"%s\"]\n", ids[n.Path], strings.Replace(n.Label(), "\"", "#quot;", -1))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(buf, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	if id, ok := ids[g.Root]; ok {
+		fmt.Fprintf(buf, "  style %s stroke-width:3px\n", id)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineage(t *testing.T) {
+	r, err := makeTestRepo()
+	if err != nil {
+		t.Errorf("error making test repo: %s", err.Error())
+		return
+	}
+	ds2, err := r.GetRef(DatasetRef{Peername: "peer", Name: "ds2"})
+	if err != nil {
+		t.Errorf("error getting ds2 ref: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		path  string
+		depth int
+		nodes int
+		edges int
+		err   string
+	}{
+		{"/not/a/dataset", 0, 0, 0, "repo: not found"},
+		{ds2.Path, 0, 3, 2, ""},
+		{"/path/to/a", 0, 2, 1, ""},
+		{"/path/to/a", 1, 2, 1, ""},
+	}
+
+	for i, c := range cases {
+		g, err := Lineage(r, c.path, c.depth)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if len(g.Nodes) != c.nodes {
+			t.Errorf("case %d node count mismatch. expected: %d, got: %d", i, c.nodes, len(g.Nodes))
+		}
+		if len(g.Edges) != c.edges {
+			t.Errorf("case %d edge count mismatch. expected: %d, got: %d", i, c.edges, len(g.Edges))
+		}
+	}
+
+	g, err := Lineage(r, ds2.Path, 0)
+	if err != nil {
+		t.Errorf("error calculating lineage: %s", err.Error())
+		return
+	}
+	if n := g.Node("/path/to/a"); n == nil || n.Depth != -1 {
+		t.Errorf("expected /path/to/a to be an upstream input, got: %v", n)
+	}
+	if n := g.Node(ds2.Path); n == nil || n.Name != "peer/ds2" {
+		t.Errorf("expected root to be named peer/ds2, got: %v", n)
+	}
+
+	formats := []struct {
+		format string
+		expect string
+		err    string
+	}{
+		{LineageFormatDOT, "\"/path/to/a\" -> \"" + ds2.Path + "\";", ""},
+		{LineageFormatMermaid, "n0 --> n2", ""},
+		{LineageFormatJSON, "\"root\": \"" + ds2.Path + "\"", ""},
+		{"png", "", "unsupported lineage format: 'png'"},
+	}
+	for i, c := range formats {
+		data, err := g.Encode(c.format)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("format case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if !strings.Contains(string(data), c.expect) {
+			t.Errorf("format case %d expected output to contain %s, got:\n%s", i, c.expect, string(data))
+		}
+	}
+}
+
+func TestLineageFilter(t *testing.T) {
+	r, err := makeTestRepo()
+	if err != nil {
+		t.Errorf("error making test repo: %s", err.Error())
+		return
+	}
+	ds2, err := r.GetRef(DatasetRef{Peername: "peer", Name: "ds2"})
+	if err != nil {
+		t.Errorf("error getting ds2 ref: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		private bool
+		peerID  string
+		nodes   int
+		edges   int
+	}{
+		// inputs aren't named datasets in the repo
+		{false, "", 1, 0},
+		{true, "", 0, 0},
+		{true, "QmFriend", 1, 0},
+	}
+
+	for i, c := range cases {
+		v := Visibility{Private: c.private}
+		if c.private {
+			v.SharedWith = []string{"QmFriend"}
+		}
+		if err := r.PutRefVisibility(ds2, v); err != nil {
+			t.Errorf("case %d error setting visibility: %s", i, err.Error())
+			continue
+		}
+		g, err := Lineage(r, ds2.Path, 0)
+		if err != nil {
+			t.Errorf("case %d error calculating lineage: %s", i, err.Error())
+			continue
+		}
+		if err := g.Filter(r, c.peerID); err != nil {
+			t.Errorf("case %d error filtering lineage: %s", i, err.Error())
+			continue
+		}
+		if len(g.Nodes) != c.nodes {
+			t.Errorf("case %d node count mismatch. expected: %d, got: %d", i, c.nodes, len(g.Nodes))
+		}
+		if len(g.Edges) != c.edges {
+			t.Errorf("case %d edge count mismatch. expected: %d, got: %d", i, c.edges, len(g.Edges))
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package repo
+
+// ListingStore records the peers dataset references have been listed to, so
+// they can be told when a dataset is removed. Listings apply to every version
+// of a dataset
+type ListingStore interface {
+	// PutListings records that refs were listed to a peer
+	PutListings(peerID string, refs []DatasetRef) error
+	// RefListings gives the ids of the peers a reference has been listed to
+	RefListings(ref DatasetRef) ([]string, error)
+}
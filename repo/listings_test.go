@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemListings(t *testing.T) {
+	s := NewMemListings()
+	movies := DatasetRef{Peername: "peer", Name: "movies"}
+	cities := DatasetRef{Peername: "peer", Name: "cities"}
+
+	if ids, err := s.RefListings(movies); err != nil || len(ids) != 0 {
+		t.Errorf("expected no listings. got: %v, err: %s", ids, err)
+	}
+	if err := s.PutListings("QmB", []DatasetRef{movies, cities}); err != nil {
+		t.Errorf("error putting listings: %s", err.Error())
+		return
+	}
+	if err := s.PutListings("QmA", []DatasetRef{movies}); err != nil {
+		t.Errorf("error putting listings: %s", err.Error())
+		return
+	}
+	// listings apply to all versions of a dataset
+	ids, err := s.RefListings(DatasetRef{Peername: "peer", Name: "movies", Path: "/map/QmFoo"})
+	if err != nil {
+		t.Errorf("error getting listings: %s", err.Error())
+		return
+	}
+	if !reflect.DeepEqual(ids, []string{"QmA", "QmB"}) {
+		t.Errorf("listings mismatch. expected: [QmA QmB], got: %v", ids)
+	}
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"sync"
+)
+
+// MemKeys is an in-memory implementation of the WrappedKeyStore interface
+type MemKeys struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewMemKeys allocates a MemKeys instance
+func NewMemKeys() *MemKeys {
+	return &MemKeys{keys: map[string][]byte{}}
+}
+
+// PutWrappedKey stores a wrapped data key
+func (s *MemKeys) PutWrappedKey(id string, wrapped []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = wrapped
+	return nil
+}
+
+// WrappedKey gets a wrapped data key
+func (s *MemKeys) WrappedKey(id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.keys[id]; ok {
+		return k, nil
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemListings is an in-memory implementation of the ListingStore interface
+type MemListings struct {
+	mu    sync.Mutex
+	peers map[string]map[string]bool
+}
+
+// NewMemListings allocates a MemListings instance
+func NewMemListings() *MemListings {
+	return &MemListings{peers: map[string]map[string]bool{}}
+}
+
+// PutListings records that refs were listed to a peer
+func (s *MemListings) PutListings(peerID string, refs []DatasetRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ref := range refs {
+		key := VisibilityKey(ref)
+		if s.peers[key] == nil {
+			s.peers[key] = map[string]bool{}
+		}
+		s.peers[key][peerID] = true
+	}
+	return nil
+}
+
+// RefListings gives the ids of the peers a reference has been listed to
+func (s *MemListings) RefListings(ref DatasetRef) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := []string{}
+	for id := range s.peers[VisibilityKey(ref)] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
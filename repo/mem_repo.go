@@ -6,7 +6,6 @@ import (
 	"github.com/qri-io/analytics"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/qri/repo/profile"
 )
@@ -20,6 +19,10 @@ type MemRepo struct {
 	*MemRefstore
 	*MemQueryLog
 	MemChangeRequests
+	*MemStats
+	*MemVisibility
+	*MemListings
+	keys      *MemKeys
 	profile   *profile.Profile
 	peers     Peers
 	cache     MemDatasets
@@ -34,6 +37,10 @@ func NewMemRepo(p *profile.Profile, store cafs.Filestore, ps Peers, a analytics.
 		MemRefstore:       &MemRefstore{},
 		MemQueryLog:       &MemQueryLog{},
 		MemChangeRequests: MemChangeRequests{},
+		MemStats:          NewMemStats(),
+		MemVisibility:     NewMemVisibility(),
+		MemListings:       NewMemListings(),
+		keys:              NewMemKeys(),
 		profile:           p,
 		peers:             ps,
 		analytics:         a,
@@ -84,8 +91,12 @@ func (r *MemRepo) Analytics() analytics.Analytics {
 }
 
 // CreateDataset initializes a dataset from a dataset pointer and data file
-func (r *MemRepo) CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool) (path datastore.Key, err error) {
-	path, err = dsfs.CreateDataset(r.store, ds, data, r.pk, pin)
+func (r *MemRepo) CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool, opts ...StoreOption) (path datastore.Key, err error) {
+	store, err := DataStore(r.store, r, ds, data, opts...)
+	if err != nil {
+		return
+	}
+	path, err = CreateSignedDataset(r.pk, r.store, store, ds, data, pin)
 	if err != nil {
 		return
 	}
@@ -93,3 +104,29 @@ func (r *MemRepo) CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool) (
 	err = r.PutDataset(path, ds)
 	return
 }
+
+// RewriteDataset writes a changed version of a dataset already in the
+// store, re-signing its commit
+func (r *MemRepo) RewriteDataset(ds *dataset.Dataset, pin bool) (datastore.Key, error) {
+	return RewriteSignedDataset(r.pk, r, ds, pin)
+}
+
+// NewDataKey generates a data key for encrypting dataset content
+func (r *MemRepo) NewDataKey() (string, error) {
+	return NewDataKey(r.pk, r.keys)
+}
+
+// DataKey unwraps a data key
+func (r *MemRepo) DataKey(id string) ([]byte, error) {
+	return DataKey(r.pk, r.keys, id)
+}
+
+// ShareDataKey wraps a data key to a peer's public key
+func (r *MemRepo) ShareDataKey(id string, pub crypto.PubKey) (string, error) {
+	return ShareDataKey(r.pk, r.keys, id, pub)
+}
+
+// ImportDataKey stores a data key shared by another peer
+func (r *MemRepo) ImportDataKey(shared string) (string, error) {
+	return ImportDataKey(r.pk, r.keys, shared)
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// MemStats is an in-memory implementation of the StatsStore interface
+type MemStats struct {
+	mu    sync.Mutex
+	paths map[string]datastore.Key
+}
+
+// NewMemStats allocates a MemStats instance
+func NewMemStats() *MemStats {
+	return &MemStats{paths: map[string]datastore.Key{}}
+}
+
+// PutStatsPath records the stats path for a dataset
+func (s *MemStats) PutStatsPath(dsPath, statsPath datastore.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths[dsPath.String()] = statsPath
+	return nil
+}
+
+// StatsPath gets the stats path for a dataset
+func (s *MemStats) StatsPath(dsPath datastore.Key) (datastore.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.paths[dsPath.String()]; ok {
+		return p, nil
+	}
+	return datastore.NewKey(""), ErrNotFound
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"sync"
+)
+
+// MemVisibility is an in-memory implementation of the VisibilityStore interface
+type MemVisibility struct {
+	mu   sync.Mutex
+	refs map[string]Visibility
+}
+
+// NewMemVisibility allocates a MemVisibility instance
+func NewMemVisibility() *MemVisibility {
+	return &MemVisibility{refs: map[string]Visibility{}}
+}
+
+// PutRefVisibility records the visibility of a reference
+func (s *MemVisibility) PutRefVisibility(ref DatasetRef, v Visibility) error {
+	if ref.Peername == "" {
+		return ErrPeernameRequired
+	} else if ref.Name == "" {
+		return ErrNameRequired
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !v.Private {
+		delete(s.refs, VisibilityKey(ref))
+		return nil
+	}
+	s.refs[VisibilityKey(ref)] = v
+	return nil
+}
+
+// RefVisibility gets the visibility of a reference
+func (s *MemVisibility) RefVisibility(ref DatasetRef) (Visibility, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refs[VisibilityKey(ref)], nil
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/mr-tron/base58/base58"
 	"github.com/multiformats/go-multihash"
 	"github.com/qri-io/dataset"
@@ -37,6 +39,16 @@ type DatasetRef struct {
 	Path string `json:"path,omitempty"`
 	// Dataset is a pointer to the dataset being referenced
 	Dataset *dataset.Dataset `json:"dataset,omitempty"`
+	// SignatureStatus is set when the dataset commit signature has been
+	// checked. It's a property of the dataset, and isn't stored with refs
+	SignatureStatus SignatureStatus `json:"signatureStatus,omitempty"`
+	// Visibility is one of VisibilityPublic, VisibilityPrivate or
+	// VisibilityShared, set when listing datasets. It's stored in the repo's
+	// VisibilityStore, not with refs
+	Visibility string `json:"visibility,omitempty"`
+	// AsOf selects the latest version committed on or before a time.
+	// CanonicalizeDatasetRef resolves AsOf to a path
+	AsOf *time.Time `json:"asOf,omitempty"`
 }
 
 // String implements the Stringer interface for DatasetRef
@@ -47,6 +59,8 @@ func (r DatasetRef) String() (s string) {
 	}
 	if r.Path != "" {
 		s += "@" + r.Path
+	} else if r.AsOf != nil {
+		s += "@{" + r.AsOf.Format(time.RFC3339) + "}"
 	}
 	return s
 }
@@ -80,6 +94,12 @@ var (
 	// peernameShorthandPathRegex looks for dataset references in the form:
 	// peername/dataset_name
 	peernameShorthandPathRegex = regexp.MustCompile(`(\w+)/(\w+)$`)
+	// asOfRegex looks for timestamp selectors in the form:
+	// peername/dataset_name@{2018-03-01}
+	asOfRegex = regexp.MustCompile(`^(.+)@\{([^}]*)\}$`)
+	// asOfLayouts are the accepted timestamp selector formats. times without
+	// a zone are UTC
+	asOfLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
 )
 
 // ParseDatasetRef decodes a dataset reference from a string value
@@ -103,6 +123,11 @@ var (
 //     peername
 //     hash
 //
+// a timestamp selector picks the latest version committed on or before a
+// time, dates without a time select the end of that day:
+//     peer_name/dataset_name@{2018-03-01}
+//     peer_name/dataset_name@{2018-03-01T12:00:00Z}
+//
 // see tests for more exmples
 //
 // dataset names & hashes are disambiguated by checking if the input
@@ -114,6 +139,21 @@ var (
 func ParseDatasetRef(ref string) (DatasetRef, error) {
 	if ref == "" {
 		return DatasetRef{}, fmt.Errorf("cannot parse empty string as dataset reference")
+	} else if asOfRegex.MatchString(ref) {
+		matches := asOfRegex.FindStringSubmatch(ref)
+		asOf, err := parseAsOf(matches[2])
+		if err != nil {
+			return DatasetRef{}, err
+		}
+		r, err := ParseDatasetRef(matches[1])
+		if err != nil {
+			return DatasetRef{}, err
+		}
+		if r.Path != "" || r.Name == "" {
+			return DatasetRef{}, fmt.Errorf("timestamp selectors require a peername/name reference, got: '%s'", matches[1])
+		}
+		r.AsOf = &asOf
+		return r, nil
 	} else if strings.HasPrefix(ref, "/ipfs/") {
 		return DatasetRef{
 			Path: ref,
@@ -149,6 +189,20 @@ func ParseDatasetRef(ref string) (DatasetRef, error) {
 	}, nil
 }
 
+// parseAsOf parses a timestamp selector. dates select the last instant of
+// the day, so a version committed any time that day is included
+func parseAsOf(s string) (time.Time, error) {
+	for _, layout := range asOfLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			if layout == "2006-01-02" {
+				t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp selector: '%s'. use a date (2006-01-02) or RFC3339 time", s)
+}
+
 // TODO - this could be more robust?
 func stripProtocol(ref string) string {
 	if strings.HasPrefix(ref, "/ipfs/") {
@@ -175,6 +229,10 @@ func CanonicalizeDatasetRef(r Repo, ref *DatasetRef) error {
 		return err
 	}
 
+	if ref.AsOf != nil {
+		return resolveAsOf(r, ref)
+	}
+
 	// Proactively attempt to find dataset path
 	if ref.Path == "" {
 		if got, err := r.GetRef(*ref); err == nil {
@@ -185,6 +243,31 @@ func CanonicalizeDatasetRef(r Repo, ref *DatasetRef) error {
 	return nil
 }
 
+// resolveAsOf sets the path of a reference with a timestamp selector by
+// walking back from the latest version to the first version committed on
+// or before the selected time
+func resolveAsOf(r Repo, ref *DatasetRef) error {
+	head, err := r.GetRef(DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		return fmt.Errorf("error getting reference '%s/%s': %s", ref.Peername, ref.Name, err.Error())
+	}
+
+	path := head.Path
+	for path != "" && path != "/" {
+		ds, err := LoadDataset(r, datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error loading dataset '%s': %s", path, err.Error())
+		}
+		if ds.Commit != nil && !ds.Commit.Timestamp.After(*ref.AsOf) {
+			ref.Path = path
+			ref.AsOf = nil
+			return nil
+		}
+		path = ds.PreviousPath
+	}
+	return fmt.Errorf("no version of %s/%s was committed on or before %s", ref.Peername, ref.Name, ref.AsOf.Format(time.RFC3339))
+}
+
 // CanonicalizePeername uses a repo to replace aliases with
 // canonical peernames. basically, this thing replaces "me" with the proper peername.
 func CanonicalizePeername(r Repo, peername *string) error {
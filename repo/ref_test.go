@@ -1,10 +1,15 @@
 package repo
 
 import (
+	"fmt"
+	"testing"
+	"time"
+
 	"github.com/qri-io/analytics"
 	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo/profile"
-	"testing"
 )
 
 func TestParseDatasetRef(t *testing.T) {
@@ -192,3 +197,96 @@ func TestCanonicalize(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDatasetRefAsOf(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect string
+		err    string
+	}{
+		{"peer/name@{2018-03-01}", "peer/name@{2018-03-01T23:59:59Z}", ""},
+		{"peer/name@{2018-03-01T12:30:00Z}", "peer/name@{2018-03-01T12:30:00Z}", ""},
+		{"peer/name@{2018-03-01T12:30:00-05:00}", "peer/name@{2018-03-01T12:30:00-05:00}", ""},
+		{"peer/name@{2018-03-01T12:30}", "peer/name@{2018-03-01T12:30:00Z}", ""},
+		{"peer/name@{yesterday}", "", "invalid timestamp selector: 'yesterday'. use a date (2006-01-02) or RFC3339 time"},
+		{"/ipfs/QmHash@{2018-03-01}", "", "timestamp selectors require a peername/name reference, got: '/ipfs/QmHash'"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseDatasetRef(c.input)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.AsOf == nil {
+			t.Errorf("case %d expected AsOf to be set", i)
+			continue
+		}
+		if got.String() != c.expect {
+			t.Errorf("case %d expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestCanonicalizeAsOf(t *testing.T) {
+	store := memfs.NewMapstore()
+	r, err := NewMemRepo(&profile.Profile{Peername: "lucille"}, store, MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Errorf("error allocating mem repo: %s", err.Error())
+		return
+	}
+
+	// write three versions, one on the first of each month
+	paths := []string{}
+	prev := ""
+	for i, month := range []time.Month{time.January, time.February, time.March} {
+		ds := &dataset.Dataset{
+			Commit:       &dataset.Commit{Title: fmt.Sprintf("version %d", i), Timestamp: time.Date(2018, month, 1, 12, 0, 0, 0, time.UTC)},
+			PreviousPath: prev,
+		}
+		path, err := dsfs.WriteDataset(store, ds, memfs.NewMemfileBytes("data.csv", []byte(fmt.Sprintf("a\n%d\n", i))), true)
+		if err != nil {
+			t.Errorf("error writing dataset: %s", err.Error())
+			return
+		}
+		prev = path.String()
+		paths = append(paths, prev)
+	}
+	if err := r.PutRef(DatasetRef{Peername: "lucille", Name: "ball", Path: prev}); err != nil {
+		t.Errorf("error putting ref: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		input string
+		path  string
+		err   string
+	}{
+		{"me/ball@{2018-04-01}", paths[2], ""},
+		{"me/ball@{2018-03-01}", paths[2], ""},
+		{"me/ball@{2018-03-01T11:00:00Z}", paths[1], ""},
+		{"me/ball@{2018-02-15}", paths[1], ""},
+		{"me/ball@{2018-01-01}", paths[0], ""},
+		{"me/ball@{2017-12-31}", "", "no version of lucille/ball was committed on or before 2017-12-31T23:59:59Z"},
+		{"me/missing@{2018-01-01}", "", "error getting reference 'lucille/missing': repo: not found"},
+	}
+
+	for i, c := range cases {
+		ref, err := ParseDatasetRef(c.input)
+		if err != nil {
+			t.Errorf("case %d unexpected dataset ref parse error: %s", i, err.Error())
+			continue
+		}
+		err = CanonicalizeDatasetRef(r, &ref)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if ref.Path != c.path {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, c.path, ref.Path)
+		}
+	}
+}
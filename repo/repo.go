@@ -51,8 +51,15 @@ type Repo interface {
 	Refstore
 	// CreateDataset initializes a dataset from a dataset pointer and data file
 	// It's not part of the Datasets interface because creating a dataset requires
-	// access to this repos store & private key
-	CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool) (path datastore.Key, err error)
+	// access to this repos store & private key. opts can compress, encrypt &
+	// chunk data as it's stored, see DataStore
+	CreateDataset(ds *dataset.Dataset, data cafs.File, pin bool, opts ...StoreOption) (path datastore.Key, err error)
+	// RewriteDataset writes a changed version of a dataset already in the
+	// store, re-signing its commit. Data is written back as it's stored
+	RewriteDataset(ds *dataset.Dataset, pin bool) (path datastore.Key, err error)
+	// Keystore keeps keys for encrypted dataset content. Keystores use the
+	// repo's private key, set with SetPrivateKey
+	Keystore
 	// Repos also serve as a store of dataset information.
 	// It's important that this store maintain sync with any underlying filestore.
 	// (which is why we might want to kill this in favor of just having a cache?)
@@ -63,6 +70,12 @@ type Repo interface {
 	QueryLog
 	// ChangeRequets gives this repo's change request store
 	ChangeRequestStore
+	// StatsStore links datasets to their stats components
+	StatsStore
+	// VisibilityStore controls which peers can see this repo's datasets
+	VisibilityStore
+	// ListingStore records which peers this repo's datasets were listed to
+	ListingStore
 	// A repository must maintain profile information about the owner of this dataset.
 	// The value returned by Profile() should represent the peer.
 	Profile() (*profile.Profile, error)
@@ -123,6 +136,14 @@ type QueryLog interface {
 	QueryLogItem(q *QueryLogItem) (*QueryLogItem, error)
 }
 
+// StatsStore records the path to a stats component for a dataset. Stats
+// are calculated from dataset data & stored in the repo's filestore, this
+// store only indexes them by dataset path
+type StatsStore interface {
+	PutStatsPath(dsPath, statsPath datastore.Key) error
+	StatsPath(dsPath datastore.Key) (datastore.Key, error)
+}
+
 // SearchParams encapsulates parameters provided to Searchable.Search
 type SearchParams struct {
 	Q             string
@@ -134,6 +155,13 @@ type Searchable interface {
 	Search(p SearchParams) ([]DatasetRef, error)
 }
 
+// Indexer is an opt-in interface for repos that keep a search index of
+// dataset references
+type Indexer interface {
+	// Unindex removes a reference from the search index
+	Unindex(ref DatasetRef) error
+}
+
 // DatasetsQuery is a convenience function to read all query results & parse into a
 // map[string]*dataset.Dataset.
 func DatasetsQuery(dss Datasets, q query.Query) (map[string]*dataset.Dataset, error) {
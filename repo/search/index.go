@@ -103,12 +103,16 @@ func buildIndexMapping() (mapping.IndexMapping, error) {
 	return indexMapping, nil
 }
 
-// IndexRepo calculates an index for a given repository
+// IndexRepo calculates an index for a given repository. Private datasets
+// aren't indexed
 func IndexRepo(r repo.Repo, i bleve.Index) error {
 	refs, err := r.References(-1, 0)
 	if err != nil {
 		return err
 	}
+	if refs, err = repo.VisibleRefs(r, refs, ""); err != nil {
+		return err
+	}
 	return indexDatasetRefs(r.Store(), i, refs)
 }
 
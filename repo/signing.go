@@ -0,0 +1,342 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/mr-tron/base58/base58"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// SignatureStatus describes the result of checking a commit signature
+type SignatureStatus string
+
+const (
+	// SignatureValid is a commit signed by the key of its author
+	SignatureValid SignatureStatus = "valid"
+	// SignatureUnsigned is a commit with no signature
+	SignatureUnsigned SignatureStatus = "unsigned"
+	// SignatureInvalid is a commit with a signature that doesn't match
+	// its contents or author
+	SignatureInvalid SignatureStatus = "invalid"
+)
+
+// DataChecksum calculates the checksum dsfs records in a dataset structure
+func DataChecksum(data []byte) (string, error) {
+	mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+	return mh.B58String(), nil
+}
+
+// PeerIDFromPubKey derives the peer id for a public key
+func PeerIDFromPubKey(pub crypto.PubKey) (string, error) {
+	data, err := pub.Bytes()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	mhb, err := multihash.Encode(sum[:], multihash.SHA2_256)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(mhb), nil
+}
+
+// CreateSignedDataset creates a dataset with dsfs, signing its commit with
+// a private key. dsfs fills in commit titles, timestamps & structure details
+// as it writes, so the dataset is first prepared in memory, reading previous
+// versions from store, then signed & written once. store is the store the
+// dataset is written to, base is the store it wraps, if any. unchanged data
+// is read from base & written back as it's stored. data is read to
+// calculate its checksum. ds is updated to the signed dataset
+func CreateSignedDataset(pk crypto.PrivKey, base, store cafs.Filestore, ds *dataset.Dataset, data cafs.File, pin bool) (datastore.Key, error) {
+	if pk == nil {
+		return datastore.NewKey(""), fmt.Errorf("private key is required to sign a commit")
+	}
+
+	var (
+		raw      []byte
+		checksum string
+		dataPath = ds.DataPath
+	)
+	if data != nil {
+		var err error
+		if raw, err = ioutil.ReadAll(data); err != nil {
+			return datastore.NewKey(""), fmt.Errorf("error reading data: %s", err.Error())
+		}
+		data.Close()
+		if checksum, err = DataChecksum(raw); err != nil {
+			return datastore.NewKey(""), fmt.Errorf("error calculating data checksum: %s", err.Error())
+		}
+	} else if ds.Structure != nil {
+		checksum = ds.Structure.Checksum
+	}
+	dataFile := func() cafs.File {
+		if data == nil {
+			return nil
+		}
+		return memfs.NewMemfileBytes(data.FileName(), raw)
+	}
+
+	if err := setAuthor(pk, ds); err != nil {
+		return datastore.NewKey(""), err
+	}
+	prep := &prepareStore{Filestore: store, mem: memfs.NewMapstore()}
+	prepPath, err := dsfs.CreateDataset(prep, ds, dataFile(), pk, false)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	prepared, err := dsfs.LoadDataset(prep, prepPath)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+
+	var path datastore.Key
+	if data != nil {
+		path, err = WriteSignedDataset(pk, store, prepared, dataFile(), checksum, pin)
+	} else {
+		// unchanged data is written back as it's stored
+		var stored cafs.File
+		if stored, err = base.Get(datastore.NewKey(dataPath)); err != nil {
+			return datastore.NewKey(""), fmt.Errorf("error loading data: %s", err.Error())
+		}
+		path, err = WriteSignedDataset(pk, store, prepared, stored, checksum, pin)
+	}
+	if err != nil {
+		return path, err
+	}
+
+	written, err := dsfs.LoadDataset(store, path)
+	if err != nil {
+		return path, fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	*ds = *written
+	return path, nil
+}
+
+// prepareStore writes files to memory, reading files it doesn't have from
+// the store it wraps. dsfs writes to it to prepare a dataset without
+// storing anything
+type prepareStore struct {
+	cafs.Filestore
+	mem cafs.Filestore
+}
+
+// Get reads files from memory, falling back to the wrapped store
+func (s *prepareStore) Get(key datastore.Key) (cafs.File, error) {
+	if has, err := s.mem.Has(key); err == nil && has {
+		return s.mem.Get(key)
+	}
+	return s.Filestore.Get(key)
+}
+
+// Has checks memory & the wrapped store for a file
+func (s *prepareStore) Has(key datastore.Key) (bool, error) {
+	if has, err := s.mem.Has(key); err == nil && has {
+		return true, nil
+	}
+	return s.Filestore.Has(key)
+}
+
+// Put writes a file to memory
+func (s *prepareStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	return s.mem.Put(file, false)
+}
+
+// NewAdder gives an adder that writes to memory
+func (s *prepareStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	return s.mem.NewAdder(false, wrap)
+}
+
+// WriteSignedDataset signs the commit of a complete dataset & writes it to a
+// store, use it to rewrite existing versions. checksum is the checksum of the
+// dataset's data before it's encrypted, chunked or compressed
+func WriteSignedDataset(pk crypto.PrivKey, store cafs.Filestore, ds *dataset.Dataset, data cafs.File, checksum string, pin bool) (datastore.Key, error) {
+	if err := SignCommit(pk, ds, checksum); err != nil {
+		return datastore.NewKey(""), err
+	}
+	return dsfs.WriteDataset(store, ds, data, pin)
+}
+
+// RewriteSignedDataset re-signs the commit of a dataset that's already in a
+// repo's store & writes it again, use it when a version changes, eg. when
+// its history is rewritten. Data is written back as it's stored
+func RewriteSignedDataset(pk crypto.PrivKey, r Repo, ds *dataset.Dataset, pin bool) (datastore.Key, error) {
+	f, err := LoadData(r, ds)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error loading data: %s", err.Error())
+	}
+	raw, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error reading data: %s", err.Error())
+	}
+	checksum, err := DataChecksum(raw)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error calculating data checksum: %s", err.Error())
+	}
+
+	stored, err := dsfs.LoadData(r.Store(), ds)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error loading data: %s", err.Error())
+	}
+	// components of encrypted datasets are encrypted again
+	store := r.Store()
+	keyID, err := DataKeyID(store, ds)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	if keyID != "" {
+		if store, err = NewEncryptingStore(store, r, keyID, ""); err != nil {
+			return datastore.NewKey(""), err
+		}
+	}
+	return WriteSignedDataset(pk, store, ds, stored, checksum, pin)
+}
+
+// SignCommit signs a dataset commit with a private key, setting the commit
+// author to the key's peer id and recording the public key & signature in the
+// commit. The signature covers a canonical encoding of the whole dataset
+// except the signature itself, and the checksum of its data
+func SignCommit(pk crypto.PrivKey, ds *dataset.Dataset, checksum string) error {
+	if pk == nil {
+		return fmt.Errorf("private key is required to sign a commit")
+	}
+	if err := setAuthor(pk, ds); err != nil {
+		return err
+	}
+	pubBytes, err := pk.GetPublic().Bytes()
+	if err != nil {
+		return fmt.Errorf("error encoding public key: %s", err.Error())
+	}
+
+	signable, err := commitSignableBytes(ds, checksum)
+	if err != nil {
+		return err
+	}
+	sig, err := pk.Sign(signable)
+	if err != nil {
+		return fmt.Errorf("error signing commit: %s", err.Error())
+	}
+	ds.Commit.Signature = base64.StdEncoding.EncodeToString(pubBytes) + "." + base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// setAuthor sets the commit author of a dataset to the peer id of a key
+func setAuthor(pk crypto.PrivKey, ds *dataset.Dataset) error {
+	id, err := PeerIDFromPubKey(pk.GetPublic())
+	if err != nil {
+		return fmt.Errorf("error calculating peer id: %s", err.Error())
+	}
+	if ds.Commit == nil {
+		ds.Commit = &dataset.Commit{}
+	}
+	// copy the author, commits assigned from a previous version share it
+	author := &dataset.User{}
+	if ds.Commit.Author != nil {
+		*author = *ds.Commit.Author
+	}
+	author.ID = id
+	ds.Commit.Author = author
+	return nil
+}
+
+// VerifyCommit checks the signature of a dataset commit. The signature must
+// be made by the key of the commit author over the dataset & the checksum of
+// data, which is recalculated. If data is nil the checksum recorded in the
+// dataset structure is used, which doesn't check data matches the dataset.
+// The returned error describes why a commit isn't valid
+func VerifyCommit(ds *dataset.Dataset, data []byte) (SignatureStatus, error) {
+	if ds == nil || ds.Commit == nil || ds.Commit.Signature == "" {
+		return SignatureUnsigned, fmt.Errorf("commit is not signed")
+	}
+	if ds.Commit.Author == nil || ds.Commit.Author.ID == "" {
+		return SignatureInvalid, fmt.Errorf("commit has no author")
+	}
+
+	parts := strings.Split(ds.Commit.Signature, ".")
+	if len(parts) != 2 {
+		return SignatureInvalid, fmt.Errorf("malformed signature")
+	}
+	pubBytes, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return SignatureInvalid, fmt.Errorf("error decoding public key: %s", err.Error())
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return SignatureInvalid, fmt.Errorf("error decoding signature: %s", err.Error())
+	}
+	pub, err := crypto.UnmarshalPublicKey(pubBytes)
+	if err != nil {
+		return SignatureInvalid, fmt.Errorf("error decoding public key: %s", err.Error())
+	}
+
+	id, err := PeerIDFromPubKey(pub)
+	if err != nil {
+		return SignatureInvalid, fmt.Errorf("error calculating peer id: %s", err.Error())
+	}
+	if id != ds.Commit.Author.ID {
+		return SignatureInvalid, fmt.Errorf("commit author %s didn't sign this commit, key belongs to %s", ds.Commit.Author.ID, id)
+	}
+
+	checksum := ""
+	if data != nil {
+		if checksum, err = DataChecksum(data); err != nil {
+			return SignatureInvalid, fmt.Errorf("error calculating data checksum: %s", err.Error())
+		}
+	} else if ds.Structure != nil {
+		checksum = ds.Structure.Checksum
+	}
+	signable, err := commitSignableBytes(ds, checksum)
+	if err != nil {
+		return SignatureInvalid, err
+	}
+	ok, err := pub.Verify(signable, sig)
+	if err != nil {
+		return SignatureInvalid, fmt.Errorf("error verifying signature: %s", err.Error())
+	}
+	if !ok {
+		return SignatureInvalid, fmt.Errorf("signature doesn't match commit")
+	}
+	return SignatureValid, nil
+}
+
+// commitSignableBytes gives the bytes a commit signature covers: the json
+// encoding of a dataset & its data checksum, less the commit signature &
+// the paths of the dataset & its components, which change when the dataset
+// is written. json objects encode with sorted keys, so the encoding is
+// canonical
+func commitSignableBytes(ds *dataset.Dataset, checksum string) ([]byte, error) {
+	data, err := json.Marshal(ds)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+
+	delete(doc, "path")
+	for _, v := range doc {
+		if component, ok := v.(map[string]interface{}); ok {
+			delete(component, "path")
+		}
+	}
+	if commit, ok := doc["commit"].(map[string]interface{}); ok {
+		delete(commit, "signature")
+	}
+	doc["dataChecksum"] = checksum
+	return json.Marshal(doc)
+}
@@ -0,0 +1,163 @@
+package repo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+func TestSignCommit(t *testing.T) {
+	pk, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Errorf("error generating key: %s", err.Error())
+		return
+	}
+	other, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Errorf("error generating key: %s", err.Error())
+		return
+	}
+
+	data := []byte("a,b\n1,2\n")
+	checksum, err := DataChecksum(data)
+	if err != nil {
+		t.Errorf("error calculating checksum: %s", err.Error())
+		return
+	}
+	sign := func(key crypto.PrivKey) *dataset.Dataset {
+		ds := &dataset.Dataset{
+			PreviousPath: "/map/prev",
+			Meta:         &dataset.Meta{Title: "numbers"},
+			Structure:    &dataset.Structure{Checksum: checksum},
+			Commit:       &dataset.Commit{Title: "initial commit", Timestamp: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}
+		if err := SignCommit(key, ds, checksum); err != nil {
+			t.Fatalf("error signing commit: %s", err.Error())
+		}
+		return ds
+	}
+
+	if err := SignCommit(nil, &dataset.Dataset{}, ""); err == nil {
+		t.Errorf("expected signing without a private key to error")
+	}
+
+	cases := []struct {
+		ds     func() *dataset.Dataset
+		data   []byte
+		status SignatureStatus
+		err    string
+	}{
+		{func() *dataset.Dataset { return &dataset.Dataset{} }, nil, SignatureUnsigned, "commit is not signed"},
+		{func() *dataset.Dataset { return sign(pk) }, data, SignatureValid, ""},
+		{func() *dataset.Dataset { return sign(pk) }, nil, SignatureValid, ""},
+		{func() *dataset.Dataset { return sign(pk) }, []byte("a,b\n1,3\n"), SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Structure.Checksum = "QmTampered"
+			return ds
+		}, data, SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.PreviousPath = "/map/other"
+			return ds
+		}, data, SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Meta.Title = "other numbers"
+			return ds
+		}, data, SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Commit.Title = "another commit"
+			return ds
+		}, data, SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Commit.Timestamp = ds.Commit.Timestamp.Add(time.Hour)
+			return ds
+		}, data, SignatureInvalid, "signature doesn't match commit"},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Commit.Author.ID = sign(other).Commit.Author.ID
+			return ds
+		}, data, SignatureInvalid, ""},
+		{func() *dataset.Dataset {
+			ds := sign(pk)
+			ds.Commit.Signature = "nope"
+			return ds
+		}, data, SignatureInvalid, "malformed signature"},
+	}
+
+	for i, c := range cases {
+		status, err := VerifyCommit(c.ds(), c.data)
+		if status != c.status {
+			t.Errorf("case %d status mismatch. expected: %s, got: %s", i, c.status, status)
+		}
+		if c.status == SignatureValid && err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+		} else if c.err != "" && (err == nil || err.Error() != c.err) {
+			t.Errorf("case %d error mismatch. expected: %s, got: %v", i, c.err, err)
+		}
+	}
+}
+
+func TestCreateSignedDataset(t *testing.T) {
+	pk, _, err := crypto.GenerateKeyPair(crypto.RSA, 1024)
+	if err != nil {
+		t.Errorf("error generating key: %s", err.Error())
+		return
+	}
+
+	data := []byte("a,b\n1,2\n")
+	st, err := detect.FromReader("data.csv", bytes.NewReader(data))
+	if err != nil {
+		t.Errorf("error detecting structure: %s", err.Error())
+		return
+	}
+	ds := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "numbers"},
+		Structure: st,
+		Commit:    &dataset.Commit{Title: "initial commit"},
+	}
+
+	store := memfs.NewMapstore()
+	counted := &countingStore{Filestore: store}
+	path, err := CreateSignedDataset(pk, store, counted, ds, memfs.NewMemfileBytes("data.csv", data), false)
+	if err != nil {
+		t.Errorf("error creating dataset: %s", err.Error())
+		return
+	}
+	if counted.adds != 1 {
+		t.Errorf("expected dataset to be written once, got: %d", counted.adds)
+	}
+
+	got, err := dsfs.LoadDataset(store, path)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	if status, err := VerifyCommit(got, data); status != SignatureValid {
+		t.Errorf("expected written dataset to be validly signed, got: %s: %v", status, err)
+	}
+	if ds.Commit.Signature != got.Commit.Signature {
+		t.Errorf("expected ds to be updated to the signed dataset")
+	}
+}
+
+// countingStore counts the adders used to write to a store
+type countingStore struct {
+	cafs.Filestore
+	adds int
+}
+
+func (s *countingStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	s.adds++
+	return s.Filestore.NewAdder(pin, wrap)
+}
@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+)
+
+const (
+	// VisibilityPublic datasets are listed to all peers
+	VisibilityPublic = "public"
+	// VisibilityPrivate datasets are only visible to this peer
+	VisibilityPrivate = "private"
+	// VisibilityShared datasets are private datasets shared with specific peers
+	VisibilityShared = "shared"
+)
+
+// Visibility controls which peers can see a dataset reference. The zero value
+// is public. Visibility is stored by reference name, so it applies to every
+// version of a dataset
+type Visibility struct {
+	// Private datasets are hidden from peers that aren't listed in SharedWith
+	Private bool `json:"private,omitempty"`
+	// SharedWith lists ids of peers that can see a private dataset
+	SharedWith []string `json:"sharedWith,omitempty"`
+}
+
+// String gives one of VisibilityPublic, VisibilityPrivate or VisibilityShared
+func (v Visibility) String() string {
+	if !v.Private {
+		return VisibilityPublic
+	}
+	if len(v.SharedWith) > 0 {
+		return VisibilityShared
+	}
+	return VisibilityPrivate
+}
+
+// VisibleTo checks if a peer can see a dataset. An empty peer id is an
+// anonymous viewer, who can only see public datasets
+func (v Visibility) VisibleTo(peerID string) bool {
+	if !v.Private {
+		return true
+	}
+	if peerID == "" {
+		return false
+	}
+	for _, id := range v.SharedWith {
+		if id == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibilityStore records the visibility of dataset references. References
+// without a recorded visibility are public
+type VisibilityStore interface {
+	PutRefVisibility(ref DatasetRef, v Visibility) error
+	RefVisibility(ref DatasetRef) (Visibility, error)
+}
+
+// VisibilityKey gives the key visibility is stored under for a reference
+func VisibilityKey(ref DatasetRef) string {
+	return ref.Peername + "/" + ref.Name
+}
+
+// CanView checks if a peer can see a reference in a repo. refs must be
+// complete with Peername & Name
+func CanView(r Repo, ref DatasetRef, peerID string) (bool, error) {
+	v, err := r.RefVisibility(ref)
+	if err != nil {
+		return false, fmt.Errorf("error getting visibility: %s", err.Error())
+	}
+	return v.VisibleTo(peerID), nil
+}
+
+// VisibleRefs filters a list of references to those a peer can see
+func VisibleRefs(r Repo, refs []DatasetRef, peerID string) ([]DatasetRef, error) {
+	visible := make([]DatasetRef, 0, len(refs))
+	for _, ref := range refs {
+		ok, err := CanView(r, ref, peerID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, ref)
+		}
+	}
+	return visible, nil
+}
+
+// VersionRef finds the reference a dataset path is a version of, walking the
+// history of every reference in a repo. The returned reference keeps the
+// given path. Paths that aren't a version of a named dataset give ErrNotFound
+func VersionRef(r Repo, path string) (DatasetRef, error) {
+	if path == "" {
+		return DatasetRef{}, ErrNotFound
+	}
+	if ref, err := r.GetRef(DatasetRef{Path: path}); err == nil {
+		return ref, nil
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return DatasetRef{}, err
+	}
+	if count == 0 {
+		return DatasetRef{}, ErrNotFound
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return DatasetRef{}, err
+	}
+	for _, ref := range refs {
+		p := ref.Path
+		for p != "" && p != "/" {
+			if p == path {
+				ref.Path = path
+				return ref, nil
+			}
+			ds, err := LoadDatasetDocument(r.Store(), datastore.NewKey(p))
+			if err != nil {
+				// earlier versions may not be stored locally
+				break
+			}
+			p = ds.PreviousPath
+		}
+	}
+	return DatasetRef{}, ErrNotFound
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"testing"
+)
+
+func TestVisibilityVisibleTo(t *testing.T) {
+	cases := []struct {
+		v       Visibility
+		peerID  string
+		str     string
+		visible bool
+	}{
+		{Visibility{}, "", VisibilityPublic, true},
+		{Visibility{}, "QmA", VisibilityPublic, true},
+		{Visibility{Private: true}, "", VisibilityPrivate, false},
+		{Visibility{Private: true}, "QmA", VisibilityPrivate, false},
+		{Visibility{Private: true, SharedWith: []string{"QmA"}}, "QmA", VisibilityShared, true},
+		{Visibility{Private: true, SharedWith: []string{"QmA"}}, "QmB", VisibilityShared, false},
+		{Visibility{Private: true, SharedWith: []string{"QmA"}}, "", VisibilityShared, false},
+	}
+
+	for i, c := range cases {
+		if got := c.v.String(); got != c.str {
+			t.Errorf("case %d string mismatch. expected: %s, got: %s", i, c.str, got)
+		}
+		if got := c.v.VisibleTo(c.peerID); got != c.visible {
+			t.Errorf("case %d visible mismatch. expected: %t, got: %t", i, c.visible, got)
+		}
+	}
+}
+
+func TestMemVisibility(t *testing.T) {
+	s := NewMemVisibility()
+	ref := DatasetRef{Peername: "peer", Name: "movies"}
+
+	if err := s.PutRefVisibility(DatasetRef{Name: "movies"}, Visibility{Private: true}); err != ErrPeernameRequired {
+		t.Errorf("expected missing peername error, got: %s", err)
+	}
+	if v, err := s.RefVisibility(ref); err != nil || v.Private {
+		t.Errorf("expected refs to default to public. got: %v, err: %s", v, err)
+	}
+	if err := s.PutRefVisibility(ref, Visibility{Private: true, SharedWith: []string{"QmA"}}); err != nil {
+		t.Errorf("error putting visibility: %s", err.Error())
+		return
+	}
+	// visibility applies to all versions of a dataset
+	if v, _ := s.RefVisibility(DatasetRef{Peername: "peer", Name: "movies", Path: "/map/QmFoo"}); v.String() != VisibilityShared {
+		t.Errorf("expected shared visibility, got: %s", v.String())
+	}
+	if err := s.PutRefVisibility(ref, Visibility{}); err != nil {
+		t.Errorf("error putting visibility: %s", err.Error())
+		return
+	}
+	if v, _ := s.RefVisibility(ref); v.Private {
+		t.Errorf("expected dataset to be public")
+	}
+}
@@ -0,0 +1,320 @@
+// Package stats calculates column-level statistics for dataset entries.
+// Statistics are accumulated one row at a time, so data of any size can be
+// described in a single pass with bounded memory.
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+const (
+	// TopKSize is the number of most-common values reported for each column
+	TopKSize = 10
+	// HistogramBins is the number of bins in numeric histograms
+	HistogramBins = 10
+	// topKCapacity is the number of values tracked when counting frequent
+	// values. counts are exact while a column has fewer distinct values
+	topKCapacity = 100
+	// sampleSize is the number of numeric values sampled for histograms.
+	// histograms are exact while a column has fewer numeric values
+	sampleSize = 1000
+)
+
+// Stats describes the contents of a dataset's entries
+type Stats struct {
+	Rows    int            `json:"rows"`
+	Columns []*ColumnStats `json:"columns"`
+}
+
+// Column gets stats for a column by name, nil if not found
+func (s *Stats) Column(name string) *ColumnStats {
+	for _, c := range s.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ColumnStats describes the values of a single column
+type ColumnStats struct {
+	Name string `json:"name"`
+	// Type is the json type of all non-null values in the column, one of
+	// number, string, boolean, object, array, null or mixed
+	Type string `json:"type"`
+	// Count is the number of non-null values
+	Count int `json:"count"`
+	Nulls int `json:"nulls"`
+	// Min & Max are numbers for numeric columns, strings for string columns
+	Min interface{} `json:"min,omitempty"`
+	Max interface{} `json:"max,omitempty"`
+	// Mean is only set for numeric columns
+	Mean *float64 `json:"mean,omitempty"`
+	// Distinct is an estimate of the number of distinct non-null values
+	Distinct uint64 `json:"distinct"`
+	// TopK lists the most common values, most frequent first
+	TopK      []ValueCount `json:"topK,omitempty"`
+	Histogram *Histogram   `json:"histogram,omitempty"`
+}
+
+// ValueCount pairs a value with the number of times it occurs
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+// Histogram buckets numeric values into equal-width bins. Edges holds bin
+// boundaries, so len(Edges) == len(Counts)+1
+type Histogram struct {
+	Edges  []float64 `json:"edges"`
+	Counts []int     `json:"counts"`
+}
+
+// Accumulator gathers stats as rows are written to it
+type Accumulator struct {
+	names   []string
+	columns map[string]*column
+	rows    int
+}
+
+// NewAccumulator creates an accumulator. columns names the fields of array
+// rows. Object rows add columns by key as they're encountered
+func NewAccumulator(columns []string) *Accumulator {
+	a := &Accumulator{columns: map[string]*column{}}
+	for _, name := range columns {
+		a.column(name)
+	}
+	return a
+}
+
+// WriteRow adds a row of normalized values. Rows are one of:
+// []interface{} with values in column order, map[string]interface{} or
+// a single value, which is counted as a column named "value"
+func (a *Accumulator) WriteRow(row interface{}) {
+	a.rows++
+	switch r := row.(type) {
+	case []interface{}:
+		for i, v := range r {
+			name := strconv.Itoa(i)
+			if i < len(a.names) {
+				name = a.names[i]
+			}
+			a.column(name).write(v)
+		}
+		// missing trailing values are null
+		for i := len(r); i < len(a.names); i++ {
+			a.columns[a.names[i]].write(nil)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(r))
+		for key := range r {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			a.column(key)
+		}
+		for _, name := range a.names {
+			a.columns[name].write(r[name])
+		}
+	default:
+		a.column("value").write(r)
+	}
+}
+
+// Stats gives the current stats for all rows written
+func (a *Accumulator) Stats() *Stats {
+	s := &Stats{Rows: a.rows, Columns: make([]*ColumnStats, len(a.names))}
+	for i, name := range a.names {
+		s.Columns[i] = a.columns[name].stats(name)
+	}
+	return s
+}
+
+func (a *Accumulator) column(name string) *column {
+	if c, ok := a.columns[name]; ok {
+		return c
+	}
+	c := newColumn()
+	// columns added part-way through have been null for all previous rows
+	if a.rows > 1 {
+		c.nulls = a.rows - 1
+	}
+	a.names = append(a.names, name)
+	a.columns[name] = c
+	return c
+}
+
+// column accumulates stats for a single column
+type column struct {
+	types map[string]int
+	nulls int
+
+	numCount       int
+	numMin, numMax float64
+	sum            float64
+	strCount       int
+	strMin, strMax string
+	sample         []float64
+	rand           *rand.Rand
+	hll            *hyperLogLog
+	top            *topK
+}
+
+func newColumn() *column {
+	return &column{
+		types: map[string]int{},
+		hll:   newHyperLogLog(),
+		top:   newTopK(topKCapacity),
+		// a fixed seed keeps stats deterministic for the same data
+		rand: rand.New(rand.NewSource(1)),
+	}
+}
+
+func (c *column) write(v interface{}) {
+	if i, ok := v.(int); ok {
+		v = float64(i)
+	}
+
+	if v == nil {
+		c.nulls++
+		return
+	}
+
+	t := typeName(v)
+	c.types[t]++
+	key := valueKey(v)
+	c.hll.add(key)
+
+	switch x := v.(type) {
+	case float64:
+		c.writeNumber(x)
+		c.top.add(key, x)
+	case string:
+		if c.strCount == 0 || x < c.strMin {
+			c.strMin = x
+		}
+		if c.strCount == 0 || x > c.strMax {
+			c.strMax = x
+		}
+		c.strCount++
+		c.top.add(key, x)
+	case bool:
+		c.top.add(key, x)
+	}
+}
+
+func (c *column) writeNumber(x float64) {
+	if c.numCount == 0 || x < c.numMin {
+		c.numMin = x
+	}
+	if c.numCount == 0 || x > c.numMax {
+		c.numMax = x
+	}
+	c.numCount++
+	c.sum += x
+
+	// reservoir sampling keeps a uniform sample of all numbers seen
+	if len(c.sample) < sampleSize {
+		c.sample = append(c.sample, x)
+	} else if i := c.rand.Intn(c.numCount); i < sampleSize {
+		c.sample[i] = x
+	}
+}
+
+func (c *column) stats(name string) *ColumnStats {
+	cs := &ColumnStats{
+		Name:     name,
+		Type:     "null",
+		Nulls:    c.nulls,
+		Distinct: c.hll.count(),
+		TopK:     c.top.top(TopKSize),
+	}
+	for t, n := range c.types {
+		cs.Count += n
+		if cs.Type == "null" {
+			cs.Type = t
+		} else {
+			cs.Type = "mixed"
+		}
+	}
+
+	if c.numCount > 0 {
+		mean := c.sum / float64(c.numCount)
+		cs.Min, cs.Max, cs.Mean = c.numMin, c.numMax, &mean
+		cs.Histogram = histogram(c.sample, c.numMin, c.numMax, c.numCount)
+	} else if c.strCount > 0 {
+		cs.Min, cs.Max = c.strMin, c.strMax
+	}
+	return cs
+}
+
+// histogram bins sampled values, scaling counts up to the total number of
+// values if the sample is incomplete
+func histogram(sample []float64, min, max float64, total int) *Histogram {
+	bins := HistogramBins
+	if min == max {
+		bins = 1
+	}
+	h := &Histogram{Edges: make([]float64, bins+1), Counts: make([]int, bins)}
+	width := (max - min) / float64(bins)
+	for i := range h.Edges {
+		h.Edges[i] = min + width*float64(i)
+	}
+	h.Edges[bins] = max
+
+	for _, x := range sample {
+		i := bins - 1
+		if width > 0 {
+			i = int((x - min) / width)
+		}
+		if i >= bins {
+			i = bins - 1
+		}
+		h.Counts[i]++
+	}
+
+	if len(sample) < total {
+		scale := float64(total) / float64(len(sample))
+		for i, n := range h.Counts {
+			h.Counts[i] = int(math.Floor(float64(n)*scale + 0.5))
+		}
+	}
+	return h
+}
+
+// typeName gives the json type of a normalized value
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+// valueKey gives a string that uniquely identifies a value, including its
+// type so the number 1 and string "1" are distinct
+func valueKey(v interface{}) string {
+	switch x := v.(type) {
+	case float64:
+		return "n:" + strconv.FormatFloat(x, 'g', -1, 64)
+	case string:
+		return "s:" + x
+	case bool:
+		return "b:" + strconv.FormatBool(x)
+	}
+	data, _ := json.Marshal(v)
+	return "j:" + string(data)
+}
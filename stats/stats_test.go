@@ -0,0 +1,175 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestAccumulatorArrayRows(t *testing.T) {
+	a := NewAccumulator([]string{"city", "pop", "avg_age", "in_usa"})
+	rows := [][]interface{}{
+		{"toronto", 40000000.0, 55.5, false},
+		{"new york", 8500000.0, 44.4, true},
+		{"chicago", 300000.0, 44.4, true},
+		{"chatham", 35000.0, 65.25, true},
+		{"raleigh", 250000.0, nil, true},
+	}
+	for _, row := range rows {
+		a.WriteRow(row)
+	}
+	s := a.Stats()
+
+	if s.Rows != 5 {
+		t.Errorf("rows mismatch. expected: 5, got: %d", s.Rows)
+	}
+	if len(s.Columns) != 4 {
+		t.Errorf("columns length mismatch. expected: 4, got: %d", len(s.Columns))
+		return
+	}
+
+	cases := []struct {
+		name     string
+		typ      string
+		count    int
+		nulls    int
+		min, max interface{}
+		distinct uint64
+	}{
+		{"city", "string", 5, 0, "chatham", "toronto", 5},
+		{"pop", "number", 5, 0, 35000.0, 40000000.0, 5},
+		{"avg_age", "number", 4, 1, 44.4, 65.25, 3},
+		{"in_usa", "boolean", 5, 0, nil, nil, 2},
+	}
+	for i, c := range cases {
+		col := s.Column(c.name)
+		if col == nil {
+			t.Errorf("case %d missing column: %s", i, c.name)
+			continue
+		}
+		if col.Type != c.typ {
+			t.Errorf("case %d type mismatch. expected: %s, got: %s", i, c.typ, col.Type)
+		}
+		if col.Count != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, col.Count)
+		}
+		if col.Nulls != c.nulls {
+			t.Errorf("case %d nulls mismatch. expected: %d, got: %d", i, c.nulls, col.Nulls)
+		}
+		if col.Min != c.min || col.Max != c.max {
+			t.Errorf("case %d range mismatch. expected: %v-%v, got: %v-%v", i, c.min, c.max, col.Min, col.Max)
+		}
+		if col.Distinct != c.distinct {
+			t.Errorf("case %d distinct mismatch. expected: %d, got: %d", i, c.distinct, col.Distinct)
+		}
+	}
+
+	age := s.Column("avg_age")
+	if age.Mean == nil || math.Abs(*age.Mean-52.3875) > 1e-9 {
+		t.Errorf("avg_age mean mismatch. expected: 52.3875, got: %v", age.Mean)
+	}
+	if len(age.TopK) == 0 || age.TopK[0].Value != 44.4 || age.TopK[0].Count != 2 {
+		t.Errorf("avg_age top value mismatch. expected: 44.4 x 2, got: %v", age.TopK)
+	}
+	usa := s.Column("in_usa")
+	if len(usa.TopK) != 2 || usa.TopK[0].Value != true || usa.TopK[0].Count != 4 {
+		t.Errorf("in_usa top values mismatch. got: %v", usa.TopK)
+	}
+	if usa.Histogram != nil || usa.Mean != nil {
+		t.Errorf("expected non-numeric column to have no histogram or mean")
+	}
+}
+
+func TestAccumulatorObjectRows(t *testing.T) {
+	a := NewAccumulator(nil)
+	a.WriteRow(map[string]interface{}{"a": 1.0})
+	a.WriteRow(map[string]interface{}{"a": "one", "b": true})
+	a.WriteRow(map[string]interface{}{"b": false})
+	s := a.Stats()
+
+	cases := []struct {
+		name  string
+		typ   string
+		count int
+		nulls int
+	}{
+		{"a", "mixed", 2, 1},
+		{"b", "boolean", 2, 1},
+	}
+	for i, c := range cases {
+		col := s.Column(c.name)
+		if col == nil {
+			t.Errorf("case %d missing column: %s", i, c.name)
+			continue
+		}
+		if col.Type != c.typ || col.Count != c.count || col.Nulls != c.nulls {
+			t.Errorf("case %d mismatch. expected: %s %d/%d, got: %s %d/%d", i, c.typ, c.count, c.nulls, col.Type, col.Count, col.Nulls)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	a := NewAccumulator([]string{"n"})
+	for i := 0; i < 100; i++ {
+		a.WriteRow([]interface{}{float64(i)})
+	}
+	h := a.Stats().Column("n").Histogram
+	if h == nil {
+		t.Errorf("expected histogram")
+		return
+	}
+	if len(h.Edges) != HistogramBins+1 || len(h.Counts) != HistogramBins {
+		t.Errorf("histogram size mismatch. got %d edges, %d counts", len(h.Edges), len(h.Counts))
+		return
+	}
+	total := 0
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != 100 {
+		t.Errorf("histogram total mismatch. expected: 100, got: %d", total)
+	}
+	if h.Edges[0] != 0 || h.Edges[HistogramBins] != 99 {
+		t.Errorf("histogram edges mismatch. got: %v", h.Edges)
+	}
+
+	// single-valued columns get a single bin
+	a = NewAccumulator([]string{"n"})
+	a.WriteRow([]interface{}{5.0})
+	a.WriteRow([]interface{}{5.0})
+	h = a.Stats().Column("n").Histogram
+	if len(h.Counts) != 1 || h.Counts[0] != 2 {
+		t.Errorf("single value histogram mismatch. got: %v", h.Counts)
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	cases := []int{0, 1, 10, 1000, 50000}
+	for i, n := range cases {
+		h := newHyperLogLog()
+		for j := 0; j < n; j++ {
+			h.add(fmt.Sprintf("value_%d", j))
+			// duplicates shouldn't change the estimate
+			h.add(fmt.Sprintf("value_%d", j))
+		}
+		got := float64(h.count())
+		if math.Abs(got-float64(n)) > float64(n)*0.05 {
+			t.Errorf("case %d estimate out of range. expected: %d (+/- 5%%), got: %.0f", i, n, got)
+		}
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tk := newTopK(3)
+	for _, v := range []string{"a", "a", "a", "b", "b", "c", "d"} {
+		tk.add(v, v)
+	}
+	top := tk.top(2)
+	if len(top) != 2 {
+		t.Errorf("length mismatch. expected: 2, got: %d", len(top))
+		return
+	}
+	if top[0].Value != "a" || top[0].Count != 3 {
+		t.Errorf("expected first value to be a x 3, got: %v", top[0])
+	}
+}
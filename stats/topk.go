@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"sort"
+)
+
+// topK tracks frequent values with the space-saving algorithm. When the
+// counter table is full, the least frequent value is replaced & its count
+// inherited, so counts are upper bounds once values have been evicted
+type topK struct {
+	capacity int
+	counters map[string]*counter
+}
+
+type counter struct {
+	key   string
+	value interface{}
+	count int
+}
+
+func newTopK(capacity int) *topK {
+	return &topK{capacity: capacity, counters: map[string]*counter{}}
+}
+
+func (t *topK) add(key string, value interface{}) {
+	if c, ok := t.counters[key]; ok {
+		c.count++
+		return
+	}
+	if len(t.counters) < t.capacity {
+		t.counters[key] = &counter{key: key, value: value, count: 1}
+		return
+	}
+
+	var min *counter
+	for _, c := range t.counters {
+		if min == nil || c.count < min.count || (c.count == min.count && c.key < min.key) {
+			min = c
+		}
+	}
+	delete(t.counters, min.key)
+	t.counters[key] = &counter{key: key, value: value, count: min.count + 1}
+}
+
+// top gives the n most frequent values, breaking ties by value so results
+// are deterministic
+func (t *topK) top(n int) []ValueCount {
+	cs := make([]*counter, 0, len(t.counters))
+	for _, c := range t.counters {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].count != cs[j].count {
+			return cs[i].count > cs[j].count
+		}
+		return cs[i].key < cs[j].key
+	})
+	if len(cs) > n {
+		cs = cs[:n]
+	}
+
+	vcs := make([]ValueCount, len(cs))
+	for i, c := range cs {
+		vcs[i] = ValueCount{Value: c.value, Count: c.count}
+	}
+	return vcs
+}